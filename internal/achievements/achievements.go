@@ -0,0 +1,256 @@
+// Package achievements awards badges for a user's activity history. Badges
+// are declared as rules in Rules: a metric measured over the activities and
+// the threshold it has to reach, so a new badge is one more rule
+package achievements
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+// Metrics a rule can measure
+const (
+	// Workouts counts activities
+	Workouts = "workouts"
+	// Calories sums the calories burned
+	Calories = "calories"
+	// Minutes sums the duration of activities
+	Minutes = "minutes"
+	// Distance is the longest distance in meters covered in one activity
+	Distance = "distance"
+	// Streak is the longest run of consecutive days with an activity
+	Streak = "streak"
+)
+
+// Rule describes a badge and what earns it
+type Rule struct {
+	Code        string
+	Name        string
+	Description string
+	Metric      string
+	// ActivityType limits the rule to one type of activity
+	ActivityType string
+	Threshold    float64
+}
+
+// Rules are the badges that can be earned
+var Rules = []Rule{
+	{Code: "first_workout", Name: "First workout", Description: "Log your first activity", Metric: Workouts, Threshold: 1},
+	{Code: "workouts_10", Name: "10 workouts", Description: "Log 10 activities", Metric: Workouts, Threshold: 10},
+	{Code: "workouts_100", Name: "100 workouts", Description: "Log 100 activities", Metric: Workouts, Threshold: 100},
+	{Code: "workouts_500", Name: "500 workouts", Description: "Log 500 activities", Metric: Workouts, Threshold: 500},
+	{Code: "calories_1000", Name: "1,000 calories", Description: "Burn 1,000 calories in total", Metric: Calories, Threshold: 1000},
+	{Code: "calories_10000", Name: "10,000 calories", Description: "Burn 10,000 calories in total", Metric: Calories, Threshold: 10000},
+	{Code: "calories_100000", Name: "100,000 calories", Description: "Burn 100,000 calories in total", Metric: Calories, Threshold: 100000},
+	{Code: "hours_100", Name: "100 hours", Description: "Spend 100 hours exercising", Metric: Minutes, Threshold: 6000},
+	{Code: "first_5k", Name: "First 5k", Description: "Run 5 km in one activity", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 5000},
+	{Code: "first_10k", Name: "First 10k", Description: "Run 10 km in one activity", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 10000},
+	{Code: "half_marathon", Name: "Half marathon", Description: "Run 21.1 km in one activity", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 21097.5},
+	{Code: "marathon", Name: "Marathon", Description: "Run 42.2 km in one activity", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 42195},
+	{Code: "century_ride", Name: "Century ride", Description: "Cycle 100 km in one activity", Metric: Distance, ActivityType: models.ActivityCycling, Threshold: 100000},
+	{Code: "streak_3", Name: "3-day streak", Description: "Be active 3 days in a row", Metric: Streak, Threshold: 3},
+	{Code: "streak_7", Name: "7-day streak", Description: "Be active 7 days in a row", Metric: Streak, Threshold: 7},
+	{Code: "streak_30", Name: "30-day streak", Description: "Be active 30 days in a row", Metric: Streak, Threshold: 30},
+	{Code: "streak_100", Name: "100-day streak", Description: "Be active 100 days in a row", Metric: Streak, Threshold: 100},
+}
+
+// Find returns the rule with code
+func Find(code string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Code == code {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Describe returns the API representation of an earned badge, or false if
+// its rule no longer exists
+func Describe(a models.Achievement) (models.AchievementResponse, bool) {
+	rule, ok := Find(a.Code)
+	if !ok {
+		return models.AchievementResponse{}, false
+	}
+	return models.AchievementResponse{
+		Code:        rule.Code,
+		Name:        rule.Name,
+		Description: rule.Description,
+		EarnedAt:    a.EarnedAt,
+	}, true
+}
+
+// Totals is what the activities of one type done before a replay added up
+// to
+type Totals struct {
+	ActivityType string
+	Workouts     float64
+	Calories     float64
+	Minutes      float64
+	// Distance is the longest distance in meters covered in one activity
+	Distance float64
+}
+
+// Progress is where a replay starts from: the totals of the activities done
+// before it and the most recent of those activities, which streaks carry on
+// from. Recent needs to go back StreakDays days
+type Progress struct {
+	Totals []Totals
+	Recent []models.Activity
+}
+
+// StreakDays returns how many days of recent activities a Progress needs to
+// carry on the streaks of rules
+func StreakDays(rules []Rule) int {
+	var days float64
+	for _, rule := range rules {
+		if rule.Metric == Streak {
+			days = max(days, rule.Threshold)
+		}
+	}
+	return int(math.Ceil(days))
+}
+
+// Earned replays activities in the order they were done and returns when
+// each of rules was first met, keyed by code. Days are counted in loc
+func Earned(rules []Rule, activities []models.Activity, loc *time.Location) map[string]time.Time {
+	return EarnedFrom(rules, Progress{}, activities, loc)
+}
+
+// EarnedFrom is Earned for activities done after the ones summed up in from.
+// Rules that from already meets are returned with a zero time, as when they
+// were met is not known
+func EarnedFrom(rules []Rule, from Progress, activities []models.Activity, loc *time.Location) map[string]time.Time {
+	progress := make([]float64, len(rules))
+	streaks := make([]streak, len(rules))
+	earned := make(map[string]time.Time)
+
+	// advance adds a to the progress towards rules[i] and reports whether
+	// it counts towards it
+	advance := func(i int, a models.Activity) bool {
+		rule := rules[i]
+		if rule.ActivityType != "" && rule.ActivityType != a.ActivityType {
+			return false
+		}
+		switch rule.Metric {
+		case Workouts:
+			progress[i]++
+		case Calories:
+			progress[i] += float64(a.CaloriesBurned)
+		case Minutes:
+			progress[i] += float64(a.DurationInMinutes)
+		case Distance:
+			if a.DistanceInMeters != nil {
+				progress[i] = max(progress[i], *a.DistanceInMeters)
+			}
+		case Streak:
+			progress[i] = float64(streaks[i].add(a.DoneAt.In(loc)))
+		default:
+			return false
+		}
+		return true
+	}
+
+	for i, rule := range rules {
+		for _, t := range from.Totals {
+			if rule.ActivityType != "" && rule.ActivityType != t.ActivityType {
+				continue
+			}
+			switch rule.Metric {
+			case Workouts:
+				progress[i] += t.Workouts
+			case Calories:
+				progress[i] += t.Calories
+			case Minutes:
+				progress[i] += t.Minutes
+			case Distance:
+				progress[i] = max(progress[i], t.Distance)
+			}
+		}
+	}
+	for _, a := range chronological(from.Recent) {
+		for i, rule := range rules {
+			if rule.Metric == Streak {
+				advance(i, a)
+			}
+		}
+	}
+	for i, rule := range rules {
+		if progress[i] >= rule.Threshold {
+			earned[rule.Code] = time.Time{}
+		}
+	}
+
+	for _, a := range chronological(activities) {
+		for i, rule := range rules {
+			if _, ok := earned[rule.Code]; ok {
+				continue
+			}
+			if advance(i, a) && progress[i] >= rule.Threshold {
+				earned[rule.Code] = a.DoneAt
+			}
+		}
+	}
+	return earned
+}
+
+// Streaks returns the current and longest runs of consecutive days with at
+// least one activity, counting days in loc. The current streak is still
+// alive on now if its last day is today or yesterday
+func Streaks(activities []models.Activity, loc *time.Location, now time.Time) (current, longest int) {
+	var s streak
+	for _, a := range chronological(activities) {
+		s.add(a.DoneAt.In(loc))
+	}
+	today := startOfDay(now.In(loc))
+	if s.last.Equal(today) || s.last.Equal(today.AddDate(0, 0, -1)) {
+		current = s.run
+	}
+	return current, s.longest
+}
+
+// streak follows runs of consecutive days through activities in the order
+// they were done
+type streak struct {
+	last    time.Time
+	run     int
+	longest int
+}
+
+// add records an activity done at t and returns the longest run so far
+func (s *streak) add(t time.Time) int {
+	day := startOfDay(t)
+	switch {
+	case !s.last.IsZero() && !day.After(s.last):
+		// Another activity on the same day
+	case !s.last.IsZero() && day.Equal(s.last.AddDate(0, 0, 1)):
+		s.run++
+		s.last = day
+	default:
+		s.run = 1
+		s.last = day
+	}
+	s.longest = max(s.longest, s.run)
+	return s.longest
+}
+
+// chronological returns a copy of activities ordered by when they were done
+func chronological(activities []models.Activity) []models.Activity {
+	sorted := append([]models.Activity(nil), activities...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].DoneAt.Equal(sorted[j].DoneAt) {
+			return sorted[i].DoneAt.Before(sorted[j].DoneAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// startOfDay returns midnight at the start of t's day, in t's location.
+// Calendar arithmetic on it keeps days aligned across daylight saving
+// changes
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,190 @@
+package achievements
+
+import (
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+func activity(activityType string, doneAt time.Time, minutes int, distance float64) models.Activity {
+	a := models.Activity{
+		ActivityType:      activityType,
+		DoneAt:            doneAt,
+		DurationInMinutes: minutes,
+		CaloriesBurned:    models.CaloriesPerMinute[activityType] * minutes,
+	}
+	if distance > 0 {
+		a.DistanceInMeters = &distance
+	}
+	return a
+}
+
+func TestRules(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, rule := range Rules {
+		if codes[rule.Code] {
+			t.Errorf("duplicate rule code %q", rule.Code)
+		}
+		codes[rule.Code] = true
+		switch rule.Metric {
+		case Workouts, Calories, Minutes, Distance, Streak:
+		default:
+			t.Errorf("rule %q has unknown metric %q", rule.Code, rule.Metric)
+		}
+		if rule.Threshold <= 0 {
+			t.Errorf("rule %q has threshold %v", rule.Code, rule.Threshold)
+		}
+	}
+}
+
+func TestEarned(t *testing.T) {
+	day := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	rules := []Rule{
+		{Code: "first", Metric: Workouts, Threshold: 1},
+		{Code: "three", Metric: Workouts, Threshold: 3},
+		{Code: "calories", Metric: Calories, Threshold: 500},
+		{Code: "5k", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 5000},
+		{Code: "streak", Metric: Streak, Threshold: 2},
+	}
+	activities := []models.Activity{
+		// Listed newest first, as the repository returns them
+		activity(models.ActivityRunning, day.AddDate(0, 0, 5), 30, 5000),
+		activity(models.ActivityCycling, day.AddDate(0, 0, 3), 60, 20000),
+		activity(models.ActivityRunning, day, 20, 3000),
+	}
+
+	earned := Earned(rules, activities, time.UTC)
+	want := map[string]time.Time{
+		"first":    day,
+		"three":    day.AddDate(0, 0, 5),
+		"calories": day.AddDate(0, 0, 3),
+		"5k":       day.AddDate(0, 0, 5),
+	}
+	if len(earned) != len(want) {
+		t.Fatalf("Earned = %v, want %v", earned, want)
+	}
+	for code, at := range want {
+		if !earned[code].Equal(at) {
+			t.Errorf("%s earned at %v, want %v", code, earned[code], at)
+		}
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, berlin)
+	}
+	tests := []struct {
+		name        string
+		doneAt      []time.Time
+		loc         *time.Location
+		now         time.Time
+		wantCurrent int
+		wantLongest int
+	}{
+		{"no activities", nil, berlin, at(time.March, 10, 12), 0, 0},
+		{"active today", []time.Time{at(time.March, 8, 9), at(time.March, 9, 9), at(time.March, 10, 9)}, berlin, at(time.March, 10, 12), 3, 3},
+		{"active yesterday", []time.Time{at(time.March, 8, 9), at(time.March, 9, 9)}, berlin, at(time.March, 10, 12), 2, 2},
+		{"broken", []time.Time{at(time.March, 1, 9), at(time.March, 2, 9), at(time.March, 3, 9), at(time.March, 9, 9)}, berlin, at(time.March, 10, 12), 1, 3},
+		{"lapsed", []time.Time{at(time.March, 1, 9), at(time.March, 2, 9)}, berlin, at(time.March, 10, 12), 0, 2},
+		{"several a day", []time.Time{at(time.March, 9, 7), at(time.March, 9, 20), at(time.March, 10, 8)}, berlin, at(time.March, 10, 12), 2, 2},
+		{"across daylight saving", []time.Time{at(time.March, 30, 9), at(time.March, 31, 9), at(time.April, 1, 9)}, berlin, at(time.April, 1, 12), 3, 3},
+		// Midnight in Berlin is still the previous day in UTC
+		{"days in the user's zone", []time.Time{at(time.March, 9, 23), at(time.March, 11, 0)}, berlin, at(time.March, 11, 12), 1, 1},
+		{"days in UTC", []time.Time{at(time.March, 9, 23), at(time.March, 11, 0)}, time.UTC, at(time.March, 11, 12), 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities := make([]models.Activity, len(tt.doneAt))
+			for i, doneAt := range tt.doneAt {
+				activities[i] = activity(models.ActivityWalking, doneAt, 30, 0)
+			}
+			current, longest := Streaks(activities, tt.loc, tt.now)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("Streaks = %d, %d, want %d, %d", current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
+
+func TestEarnedFrom(t *testing.T) {
+	day := time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)
+	rules := []Rule{
+		{Code: "first", Metric: Workouts, Threshold: 1},
+		{Code: "three", Metric: Workouts, Threshold: 3},
+		{Code: "calories", Metric: Calories, Threshold: 500},
+		{Code: "5k", Metric: Distance, ActivityType: models.ActivityRunning, Threshold: 5000},
+		{Code: "streak", Metric: Streak, Threshold: 3},
+	}
+	activities := []models.Activity{
+		activity(models.ActivityRunning, day, 20, 3000),
+		activity(models.ActivityCycling, day.AddDate(0, 0, 1), 60, 20000),
+		activity(models.ActivityWalking, day.AddDate(0, 0, 3), 30, 0),
+		activity(models.ActivityRunning, day.AddDate(0, 0, 4), 30, 5000),
+		activity(models.ActivityWalking, day.AddDate(0, 0, 5), 30, 0),
+	}
+	full := Earned(rules, activities, time.UTC)
+
+	// Resuming after any number of activities gives the same badges, with
+	// those met before the split reported without a time
+	for split := range len(activities) + 1 {
+		before, after := activities[:split], activities[split:]
+		totals := make(map[string]*Totals)
+		for _, a := range before {
+			t, ok := totals[a.ActivityType]
+			if !ok {
+				t = &Totals{ActivityType: a.ActivityType}
+				totals[a.ActivityType] = t
+			}
+			t.Workouts++
+			t.Calories += float64(a.CaloriesBurned)
+			t.Minutes += float64(a.DurationInMinutes)
+			if a.DistanceInMeters != nil {
+				t.Distance = max(t.Distance, *a.DistanceInMeters)
+			}
+		}
+		from := Progress{Recent: before}
+		for _, t := range totals {
+			from.Totals = append(from.Totals, *t)
+		}
+
+		earned := EarnedFrom(rules, from, after, time.UTC)
+		if len(earned) != len(full) {
+			t.Errorf("split at %d: EarnedFrom = %v, want %v", split, earned, full)
+			continue
+		}
+		for code, at := range full {
+			want := at
+			if split > 0 && !at.After(before[split-1].DoneAt) {
+				want = time.Time{}
+			}
+			if !earned[code].Equal(want) {
+				t.Errorf("split at %d: %s earned at %v, want %v", split, code, earned[code], want)
+			}
+		}
+	}
+}
+
+func TestStreakDays(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  int
+	}{
+		{"no streaks", []Rule{{Code: "first", Metric: Workouts, Threshold: 1}}, 0},
+		{"longest streak", []Rule{{Code: "a", Metric: Streak, Threshold: 3}, {Code: "b", Metric: Streak, Threshold: 7}}, 7},
+		{"all rules", Rules, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StreakDays(tt.rules); got != tt.want {
+				t.Errorf("StreakDays = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Package activityfile decodes workouts recorded by GPS watches and phones
+// from GPX, TCX and FIT files
+package activityfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"fitbyte/internal/fit"
+	"fitbyte/internal/geo"
+	"fitbyte/internal/models"
+)
+
+// ErrInvalidFile is returned for files that are not in a supported format or
+// cannot be decoded
+var ErrInvalidFile = errors.New("invalid activity file")
+
+// maxWorkouts bounds how many workouts one file may hold
+const maxWorkouts = 100
+
+// Workout is one workout decoded from a file
+type Workout struct {
+	// Sport is the sport the file declares, as written there. It is empty
+	// when the file declares none
+	Sport    string
+	Start    time.Time
+	Duration time.Duration
+	// Distance is in meters, zero when unknown
+	Distance float64
+	// ElevationGain is in meters, nil when the file has no elevations
+	ElevationGain *float64
+	// Heart rates are in beats per minute and power in watts, nil when
+	// the file does not record them
+	AverageHeartRate *int
+	MaxHeartRate     *int
+	AveragePower     *int
+	MaxPower         *int
+	// Points is the GPS track, empty for workouts recorded without one
+	Points []geo.Point
+}
+
+// Decode decodes the workouts in a GPX, TCX or FIT file. The format is told
+// from the file contents
+func Decode(data []byte) ([]Workout, error) {
+	var workouts []Workout
+	var err error
+	if fit.IsFIT(data) {
+		workouts, err = decodeFIT(data)
+	} else {
+		var root string
+		if root, err = rootElement(data); err != nil {
+			return nil, err
+		}
+		switch root {
+		case "gpx":
+			workouts, err = decodeGPX(data)
+		case "TrainingCenterDatabase":
+			workouts, err = decodeTCX(data)
+		default:
+			return nil, fmt.Errorf("%w: must be a GPX, TCX or FIT file", ErrInvalidFile)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(workouts) == 0 {
+		return nil, fmt.Errorf("%w: no timed workouts found", ErrInvalidFile)
+	}
+	if len(workouts) > maxWorkouts {
+		return nil, fmt.Errorf("%w: more than %d workouts", ErrInvalidFile, maxWorkouts)
+	}
+	return workouts, nil
+}
+
+// rootElement returns the local name of the root element of an XML document
+func rootElement(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("%w: must be a GPX, TCX or FIT file", ErrInvalidFile)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// InferType returns the FitByte activity type of w: the sport the file
+// declares when it maps onto one, otherwise a guess from the average speed
+// and how hilly the route was
+func InferType(w Workout) string {
+	sport := strings.ToLower(w.Sport)
+	switch {
+	case strings.Contains(sport, "run"):
+		return models.ActivityRunning
+	case strings.Contains(sport, "bik"), strings.Contains(sport, "cycl"), strings.Contains(sport, "ride"):
+		return models.ActivityCycling
+	case strings.Contains(sport, "swim"):
+		return models.ActivitySwimming
+	case strings.Contains(sport, "hik"):
+		return models.ActivityHiking
+	case strings.Contains(sport, "walk"):
+		return models.ActivityWalking
+	case strings.Contains(sport, "hiit"):
+		return models.ActivityHIIT
+	}
+
+	if w.Distance <= 0 || w.Duration <= 0 {
+		return models.ActivityWalking
+	}
+	kmh := w.Distance / 1000 / w.Duration.Hours()
+	switch {
+	case kmh < 7:
+		// More than 50 m of climbing per km is a hike rather than a walk
+		if w.ElevationGain != nil && *w.ElevationGain/(w.Distance/1000) > 50 {
+			return models.ActivityHiking
+		}
+		return models.ActivityWalking
+	case kmh < 16:
+		return models.ActivityRunning
+	default:
+		return models.ActivityCycling
+	}
+}
+
+// summarize fills in the distance and elevation gain of w from its track
+// where the file does not state them
+func summarize(w *Workout, elevations []float64) {
+	if w.Distance == 0 {
+		w.Distance = geo.Distance(w.Points)
+	}
+	if w.ElevationGain == nil && len(elevations) > 0 {
+		gain := geo.ElevationGain(elevations)
+		w.ElevationGain = &gain
+	}
+}
+
+// summarizeSamples fills in the heart rate and power summaries of w from the
+// samples recorded during it where the file does not state them
+func summarizeSamples(w *Workout, heartRates, powers []float64) {
+	if w.AverageHeartRate == nil {
+		w.AverageHeartRate = average(heartRates)
+	}
+	if w.MaxHeartRate == nil {
+		w.MaxHeartRate = maximum(heartRates)
+	}
+	if w.AveragePower == nil {
+		w.AveragePower = average(powers)
+	}
+	if w.MaxPower == nil {
+		w.MaxPower = maximum(powers)
+	}
+}
+
+// average returns the rounded mean of samples, nil when there are none
+func average(samples []float64) *int {
+	if len(samples) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range samples {
+		sum += v
+	}
+	n := int(math.Round(sum / float64(len(samples))))
+	return &n
+}
+
+// maximum returns the rounded largest of samples, nil when there are none
+func maximum(samples []float64) *int {
+	if len(samples) == 0 {
+		return nil
+	}
+	n := int(math.Round(slices.Max(samples)))
+	return &n
+}
@@ -0,0 +1,146 @@
+package activityfile
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+const gpx = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <name>Morning run</name>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="52.5000" lon="13.4000"><ele>30</ele><time>2024-05-01T06:00:00Z</time></trkpt>
+      <trkpt lat="52.5045" lon="13.4000"><ele>40</ele><time>2024-05-01T06:03:00Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="52.5100" lon="13.4000"><ele>35</ele><time>2024-05-01T06:10:00Z</time></trkpt>
+      <trkpt lat="52.5145" lon="13.4000"><ele>50</ele><time>2024-05-01T06:13:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+  <trk>
+    <name>Untimed</name>
+    <trkseg><trkpt lat="52.5" lon="13.4"/></trkseg>
+  </trk>
+</gpx>`
+
+const tcx = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2024-05-02T17:30:00Z</Id>
+      <Lap StartTime="2024-05-02T17:30:00Z">
+        <TotalTimeSeconds>1800</TotalTimeSeconds>
+        <DistanceMeters>12000</DistanceMeters>
+        <Track>
+          <Trackpoint><Time>2024-05-02T17:30:00Z</Time><Position><LatitudeDegrees>48.1</LatitudeDegrees><LongitudeDegrees>11.5</LongitudeDegrees></Position><AltitudeMeters>520</AltitudeMeters></Trackpoint>
+          <Trackpoint><Time>2024-05-02T17:45:00Z</Time><AltitudeMeters>540</AltitudeMeters></Trackpoint>
+        </Track>
+      </Lap>
+      <Lap StartTime="2024-05-02T18:00:00Z">
+        <TotalTimeSeconds>900</TotalTimeSeconds>
+        <DistanceMeters>6000.5</DistanceMeters>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestDecodeGPX(t *testing.T) {
+	workouts, err := Decode([]byte(gpx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 1 {
+		t.Fatalf("got %d workouts, want 1", len(workouts))
+	}
+	w := workouts[0]
+	if w.Sport != "running" || !w.Start.Equal(time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)) || w.Duration != 13*time.Minute {
+		t.Errorf("got sport %q, start %v, duration %v", w.Sport, w.Start, w.Duration)
+	}
+	// Two segments of 0.0045 degrees of latitude; the gap between them is
+	// not counted
+	if math.Abs(w.Distance-1000.75) > 1 {
+		t.Errorf("Distance = %v, want about 1000.75", w.Distance)
+	}
+	if w.ElevationGain == nil || *w.ElevationGain != 25 {
+		t.Errorf("ElevationGain = %v, want 25", w.ElevationGain)
+	}
+	if len(w.Points) != 4 {
+		t.Errorf("got %d points, want 4", len(w.Points))
+	}
+}
+
+func TestDecodeTCX(t *testing.T) {
+	workouts, err := Decode([]byte(tcx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 1 {
+		t.Fatalf("got %d workouts, want 1", len(workouts))
+	}
+	w := workouts[0]
+	if w.Sport != "Biking" || !w.Start.Equal(time.Date(2024, 5, 2, 17, 30, 0, 0, time.UTC)) || w.Duration != 45*time.Minute {
+		t.Errorf("got sport %q, start %v, duration %v", w.Sport, w.Start, w.Duration)
+	}
+	if w.Distance != 18000.5 {
+		t.Errorf("Distance = %v, want 18000.5", w.Distance)
+	}
+	if w.ElevationGain == nil || *w.ElevationGain != 20 {
+		t.Errorf("ElevationGain = %v, want 20", w.ElevationGain)
+	}
+	if len(w.Points) != 1 {
+		t.Errorf("got %d points, want 1", len(w.Points))
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not xml", "hello"},
+		{"other xml", `<kml></kml>`},
+		{"truncated", `<gpx><trk><trkseg><trkpt lat="1" lon="2">`},
+		{"bad time", `<gpx><trk><trkseg><trkpt lat="1" lon="2"><time>yesterday</time></trkpt></trkseg></trk></gpx>`},
+		{"no timed workouts", `<gpx><trk><trkseg><trkpt lat="1" lon="2"/></trkseg></trk></gpx>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tt.data)); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("Decode error = %v, want ErrInvalidFile", err)
+			}
+		})
+	}
+}
+
+func TestInferType(t *testing.T) {
+	gain := func(m float64) *float64 { return &m }
+	tests := []struct {
+		name string
+		w    Workout
+		want string
+	}{
+		{"declared running", Workout{Sport: "trail_running"}, models.ActivityRunning},
+		{"declared biking", Workout{Sport: "Biking"}, models.ActivityCycling},
+		{"declared swim", Workout{Sport: "open_water_swimming"}, models.ActivitySwimming},
+		{"declared hike", Workout{Sport: "Hiking"}, models.ActivityHiking},
+		{"walking pace", Workout{Distance: 5000, Duration: time.Hour}, models.ActivityWalking},
+		{"hilly walk", Workout{Distance: 5000, Duration: time.Hour, ElevationGain: gain(400)}, models.ActivityHiking},
+		{"running pace", Workout{Sport: "Other", Distance: 10000, Duration: time.Hour}, models.ActivityRunning},
+		{"cycling pace", Workout{Distance: 25000, Duration: time.Hour}, models.ActivityCycling},
+		{"no distance", Workout{Duration: time.Hour}, models.ActivityWalking},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferType(tt.w); got != tt.want {
+				t.Errorf("InferType = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
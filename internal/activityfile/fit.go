@@ -0,0 +1,229 @@
+package activityfile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"fitbyte/internal/fit"
+	"fitbyte/internal/geo"
+)
+
+// Fields of the FIT session message
+const (
+	sessionStartTime    = 2
+	sessionSport        = 5
+	sessionElapsedTime  = 7
+	sessionTimerTime    = 8
+	sessionDistance     = 9
+	sessionAvgHeartRate = 16
+	sessionMaxHeartRate = 17
+	sessionAvgPower     = 20
+	sessionMaxPower     = 21
+	sessionTotalAscent  = 22
+)
+
+// Fields of the FIT lap message
+const (
+	lapStartTime   = 2
+	lapElapsedTime = 7
+	lapTimerTime   = 8
+	lapDistance    = 9
+	lapTotalAscent = 21
+	lapSport       = 25
+)
+
+// Fields of the FIT record message
+const (
+	recordLat              = 0
+	recordLon              = 1
+	recordAltitude         = 2
+	recordHeartRate        = 3
+	recordPower            = 7
+	recordEnhancedAltitude = 78
+)
+
+// fitSports names the FIT sport types InferType knows
+var fitSports = map[float64]string{
+	1:  "running",
+	2:  "cycling",
+	5:  "swimming",
+	11: "walking",
+	17: "hiking",
+	62: "hiit",
+}
+
+// semicircle is the size in degrees of a FIT position unit
+const semicircle = 180.0 / (1 << 31)
+
+// decodeFIT returns a workout for every session of a FIT activity file.
+// Files without sessions, which some devices write when a recording is cut
+// short, are read as one workout made of their laps
+func decodeFIT(data []byte) ([]Workout, error) {
+	messages, err := fit.Decode(data)
+	if err != nil {
+		if errors.Is(err, fit.ErrInvalid) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return nil, err
+	}
+
+	var sessions, laps, records []fit.Message
+	for _, m := range messages {
+		switch m.Num {
+		case fit.MessageSession:
+			sessions = append(sessions, m)
+		case fit.MessageLap:
+			laps = append(laps, m)
+		case fit.MessageRecord:
+			records = append(records, m)
+		}
+	}
+	if len(sessions) == 0 && len(laps) > 0 {
+		sessions = []fit.Message{mergeLaps(laps)}
+	}
+	// Every session looks through all records, so bail out early
+	if len(sessions) > maxWorkouts {
+		return nil, fmt.Errorf("%w: more than %d workouts", ErrInvalidFile, maxWorkouts)
+	}
+
+	var workouts []Workout
+	for _, s := range sessions {
+		w, ok := fitWorkout(s, records)
+		if ok {
+			workouts = append(workouts, w)
+		}
+	}
+	return workouts, nil
+}
+
+// mergeLaps sums up laps into a message with the fields of a session
+func mergeLaps(laps []fit.Message) fit.Message {
+	session := fit.Message{Num: fit.MessageSession}
+	for _, lap := range laps {
+		if start, ok := lap.Value(lapStartTime); ok {
+			if first, ok := session.Value(sessionStartTime); !ok || start < first {
+				session.Set(sessionStartTime, start)
+			}
+		}
+		for from, to := range map[byte]byte{
+			lapElapsedTime: sessionElapsedTime,
+			lapTimerTime:   sessionTimerTime,
+			lapDistance:    sessionDistance,
+			lapTotalAscent: sessionTotalAscent,
+		} {
+			if v, ok := lap.Value(from); ok {
+				sum, _ := session.Value(to)
+				session.Set(to, sum+v)
+			}
+		}
+		if sport, ok := lap.Value(lapSport); ok {
+			session.Set(sessionSport, sport)
+		}
+	}
+	return session
+}
+
+// fitWorkout maps a session onto a workout. The records made during the
+// session provide its track and fill in what the session does not state
+func fitWorkout(s fit.Message, records []fit.Message) (Workout, bool) {
+	start, ok := s.Time(sessionStartTime)
+	if !ok {
+		if start, ok = firstRecordTime(records); !ok {
+			return Workout{}, false
+		}
+	}
+
+	w := Workout{Start: start}
+	if sport, ok := s.Value(sessionSport); ok {
+		w.Sport = fitSports[sport]
+	}
+	// Times are in milliseconds, distances in centimeters
+	elapsed, hasElapsed := s.Value(sessionElapsedTime)
+	if timer, ok := s.Value(sessionTimerTime); ok {
+		w.Duration = time.Duration(timer) * time.Millisecond
+	} else if hasElapsed {
+		w.Duration = time.Duration(elapsed) * time.Millisecond
+	}
+	if distance, ok := s.Value(sessionDistance); ok {
+		w.Distance = distance / 100
+	}
+	if ascent, ok := s.Value(sessionTotalAscent); ok {
+		w.ElevationGain = &ascent
+	}
+	w.AverageHeartRate = intValue(s, sessionAvgHeartRate)
+	w.MaxHeartRate = intValue(s, sessionMaxHeartRate)
+	w.AveragePower = intValue(s, sessionAvgPower)
+	w.MaxPower = intValue(s, sessionMaxPower)
+
+	// Without an elapsed time the session takes in every later record
+	end := time.Time{}
+	if hasElapsed {
+		end = start.Add(time.Duration(elapsed) * time.Millisecond)
+	}
+	var elevations, heartRates, powers []float64
+	var last time.Time
+	for _, r := range records {
+		t, ok := r.Time(fit.FieldTimestamp)
+		if !ok || t.Before(start) || (!end.IsZero() && t.After(end)) {
+			continue
+		}
+		last = t
+		elevation := altitude(r)
+		if elevation != nil {
+			elevations = append(elevations, *elevation)
+		}
+		if hr, ok := r.Value(recordHeartRate); ok {
+			heartRates = append(heartRates, hr)
+		}
+		if power, ok := r.Value(recordPower); ok {
+			powers = append(powers, power)
+		}
+		lat, okLat := r.Value(recordLat)
+		lon, okLon := r.Value(recordLon)
+		if okLat && okLon {
+			w.Points = append(w.Points, geo.Point{Lat: lat * semicircle, Lon: lon * semicircle, Elevation: elevation, Time: t})
+		}
+	}
+	if w.Duration <= 0 && !last.IsZero() {
+		w.Duration = last.Sub(start)
+	}
+	summarize(&w, elevations)
+	summarizeSamples(&w, heartRates, powers)
+	return w, true
+}
+
+// firstRecordTime returns the time of the earliest record
+func firstRecordTime(records []fit.Message) (time.Time, bool) {
+	var first time.Time
+	for _, r := range records {
+		if t, ok := r.Time(fit.FieldTimestamp); ok && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// altitude returns the altitude of a record in meters. Both fields are
+// stored in fifths of a meter above 500 m below sea level
+func altitude(r fit.Message) *float64 {
+	v, ok := r.Value(recordEnhancedAltitude)
+	if !ok {
+		if v, ok = r.Value(recordAltitude); !ok {
+			return nil
+		}
+	}
+	meters := v/5 - 500
+	return &meters
+}
+
+// intValue returns a field rounded to a whole number, nil when missing
+func intValue(m fit.Message, field byte) *int {
+	v, ok := m.Value(field)
+	if !ok {
+		return nil
+	}
+	n := int(math.Round(v))
+	return &n
+}
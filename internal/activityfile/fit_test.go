@@ -0,0 +1,181 @@
+package activityfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fitFile wraps FIT records in a file header and the file CRC
+func fitFile(records ...[]byte) []byte {
+	var body []byte
+	for _, r := range records {
+		body = append(body, r...)
+	}
+	f := []byte{12, 0x20, 0x08, 0x08}
+	f = binary.LittleEndian.AppendUint32(f, uint32(len(body)))
+	f = append(f, ".FIT"...)
+	f = append(f, body...)
+	return binary.LittleEndian.AppendUint16(f, fitCRC(f))
+}
+
+// fitCRC is the FIT CRC-16
+func fitCRC(data []byte) uint16 {
+	table := [16]uint16{
+		0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+		0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+	}
+	var sum uint16
+	for _, b := range data {
+		sum = sum>>4&0x0fff ^ table[sum&0xf] ^ table[b&0xf]
+		sum = sum>>4&0x0fff ^ table[sum&0xf] ^ table[b>>4&0xf]
+	}
+	return sum
+}
+
+// fitDefine returns a little endian definition message of fields given as
+// number, size and base type triples
+func fitDefine(local byte, num uint16, fields ...byte) []byte {
+	b := []byte{0x40 | local, 0, 0}
+	b = binary.LittleEndian.AppendUint16(b, num)
+	b = append(b, byte(len(fields)/3))
+	return append(b, fields...)
+}
+
+// fitRecord returns a record message at a FIT timestamp, 45 degrees north
+// and lon degrees east, with a heart rate
+func fitRecord(ts uint32, lon float64, hr byte) []byte {
+	b := binary.LittleEndian.AppendUint32([]byte{0}, ts)
+	b = binary.LittleEndian.AppendUint32(b, uint32(int32(45/semicircle)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(int32(lon/semicircle)))
+	return append(b, hr)
+}
+
+// fitTime converts a FIT timestamp
+func fitTime(ts uint32) time.Time {
+	return time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC).Add(time.Duration(ts) * time.Second)
+}
+
+const fitStart = 1000000000
+
+func fitRecords() [][]byte {
+	return [][]byte{
+		// record: timestamp, position, heart rate
+		fitDefine(0, 20, 253, 4, 0x86, 0, 4, 0x85, 1, 4, 0x85, 3, 1, 0x02),
+		fitRecord(fitStart, 7, 140),
+		fitRecord(fitStart+60, 7.01, 160),
+		// Outside the session below
+		fitRecord(fitStart+4000, 7.02, 200),
+	}
+}
+
+func TestDecodeFIT(t *testing.T) {
+	// session: start time, sport, elapsed and timer time, distance, average
+	// and maximum power
+	session := fitDefine(1, 18, 2, 4, 0x86, 5, 1, 0x00, 7, 4, 0x86, 8, 4, 0x86, 9, 4, 0x86, 20, 2, 0x84, 21, 2, 0x84)
+	values := binary.LittleEndian.AppendUint32([]byte{1}, fitStart)
+	values = append(values, 1)
+	values = binary.LittleEndian.AppendUint32(values, 1900000)
+	values = binary.LittleEndian.AppendUint32(values, 1800000)
+	values = binary.LittleEndian.AppendUint32(values, 500000)
+	values = binary.LittleEndian.AppendUint16(values, 210)
+	values = binary.LittleEndian.AppendUint16(values, 390)
+
+	workouts, err := Decode(fitFile(append(fitRecords(), session, values)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 1 {
+		t.Fatalf("got %d workouts, want 1", len(workouts))
+	}
+	w := workouts[0]
+	if w.Sport != "running" || !w.Start.Equal(fitTime(fitStart)) || w.Duration != 30*time.Minute {
+		t.Errorf("got sport %q, start %v, duration %v", w.Sport, w.Start, w.Duration)
+	}
+	if w.Distance != 5000 {
+		t.Errorf("Distance = %v, want 5000", w.Distance)
+	}
+	if w.ElevationGain != nil {
+		t.Errorf("ElevationGain = %v, want nil", *w.ElevationGain)
+	}
+	if len(w.Points) != 2 || w.Points[1].Lon < 7.009 || w.Points[1].Lon > 7.011 {
+		t.Errorf("got points %+v, want 2 ending at 7.01 east", w.Points)
+	}
+	// Heart rates come from the records in the session, power from the
+	// session itself
+	if w.AverageHeartRate == nil || *w.AverageHeartRate != 150 || w.MaxHeartRate == nil || *w.MaxHeartRate != 160 {
+		t.Errorf("heart rate = %v/%v, want 150/160", w.AverageHeartRate, w.MaxHeartRate)
+	}
+	if w.AveragePower == nil || *w.AveragePower != 210 || w.MaxPower == nil || *w.MaxPower != 390 {
+		t.Errorf("power = %v/%v, want 210/390", w.AveragePower, w.MaxPower)
+	}
+}
+
+func TestDecodeFITLaps(t *testing.T) {
+	// lap: start time, timer time, distance
+	lap := fitDefine(1, 19, 2, 4, 0x86, 8, 4, 0x86, 9, 4, 0x86)
+	lapValues := func(start, timer, distance uint32) []byte {
+		b := binary.LittleEndian.AppendUint32([]byte{1}, start)
+		b = binary.LittleEndian.AppendUint32(b, timer)
+		return binary.LittleEndian.AppendUint32(b, distance)
+	}
+
+	records := append(fitRecords(), lap, lapValues(fitStart+600, 600000, 200000), lapValues(fitStart, 600000, 250000))
+	workouts, err := Decode(fitFile(records...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workouts) != 1 {
+		t.Fatalf("got %d workouts, want 1", len(workouts))
+	}
+	w := workouts[0]
+	if !w.Start.Equal(fitTime(fitStart)) || w.Duration != 20*time.Minute || w.Distance != 4500 {
+		t.Errorf("got start %v, duration %v, distance %v", w.Start, w.Duration, w.Distance)
+	}
+	// Without an elapsed time every later record belongs to the workout
+	if w.MaxHeartRate == nil || *w.MaxHeartRate != 200 {
+		t.Errorf("MaxHeartRate = %v, want 200", w.MaxHeartRate)
+	}
+}
+
+func TestDecodeFITInvalid(t *testing.T) {
+	valid := fitFile(fitRecords()...)
+	corrupt := append([]byte(nil), valid...)
+	corrupt[20]++
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", valid[:len(valid)-3]},
+		{"corrupt", corrupt},
+		{"undefined message", fitFile([]byte{3, 1, 2})},
+		{"no workouts", fitFile()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("Decode error = %v, want ErrInvalidFile", err)
+			}
+		})
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(gpx))
+	f.Add([]byte(tcx))
+	f.Add(fitFile(fitRecords()...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		workouts, err := Decode(data)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidFile) {
+				t.Errorf("Decode error = %v, want ErrInvalidFile", err)
+			}
+			return
+		}
+		if len(workouts) == 0 {
+			t.Error("Decode returned no workouts and no error")
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"fitbyte/internal/geo"
+)
+
+// gpxFile is the part of a GPX 1.1 document holding tracks
+type gpxFile struct {
+	Tracks []struct {
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []struct {
+				Lat       float64   `xml:"lat,attr"`
+				Lon       float64   `xml:"lon,attr"`
+				Elevation *float64  `xml:"ele"`
+				Time      time.Time `xml:"time"`
+				// Garmin's track point extension and the plain power
+				// extension written by most apps
+				HeartRate *float64 `xml:"extensions>TrackPointExtension>hr"`
+				Power     *float64 `xml:"extensions>power"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// decodeGPX returns a workout for every track with timed points. The track
+// runs from its first to its last timed point, and its distance leaves out
+// the gaps between segments
+func decodeGPX(data []byte) ([]Workout, error) {
+	var f gpxFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var workouts []Workout
+	for _, trk := range f.Tracks {
+		w := Workout{Sport: trk.Type}
+		var elevations, heartRates, powers []float64
+		var end time.Time
+		for _, seg := range trk.Segments {
+			for i, p := range seg.Points {
+				point := geo.Point{Lat: p.Lat, Lon: p.Lon, Elevation: p.Elevation, Time: p.Time}
+				if i > 0 {
+					w.Distance += geo.Haversine(w.Points[len(w.Points)-1], point)
+				}
+				w.Points = append(w.Points, point)
+				if p.Elevation != nil {
+					elevations = append(elevations, *p.Elevation)
+				}
+				if p.HeartRate != nil {
+					heartRates = append(heartRates, *p.HeartRate)
+				}
+				if p.Power != nil {
+					powers = append(powers, *p.Power)
+				}
+				if !p.Time.IsZero() {
+					if w.Start.IsZero() {
+						w.Start = p.Time
+					}
+					end = p.Time
+				}
+			}
+		}
+		if w.Start.IsZero() {
+			continue
+		}
+		w.Duration = end.Sub(w.Start)
+		summarize(&w, elevations)
+		summarizeSamples(&w, heartRates, powers)
+		workouts = append(workouts, w)
+	}
+	return workouts, nil
+}
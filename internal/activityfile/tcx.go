@@ -0,0 +1,90 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"fitbyte/internal/geo"
+)
+
+// tcxFile is the part of a Training Center Database document holding
+// activities
+type tcxFile struct {
+	Activities []struct {
+		Sport string    `xml:"Sport,attr"`
+		ID    time.Time `xml:"Id"`
+		Laps  []struct {
+			StartTime        time.Time `xml:"StartTime,attr"`
+			TotalTimeSeconds float64   `xml:"TotalTimeSeconds"`
+			DistanceMeters   float64   `xml:"DistanceMeters"`
+			Points           []struct {
+				Time     time.Time `xml:"Time"`
+				Position *struct {
+					Lat float64 `xml:"LatitudeDegrees"`
+					Lon float64 `xml:"LongitudeDegrees"`
+				} `xml:"Position"`
+				Altitude  *float64 `xml:"AltitudeMeters"`
+				HeartRate *float64 `xml:"HeartRateBpm>Value"`
+				Power     *float64 `xml:"Extensions>TPX>Watts"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+// decodeTCX returns a workout for every activity. The duration and distance
+// are the totals of the laps, which leave out pauses; the track fills in
+// what the laps do not state
+func decodeTCX(data []byte) ([]Workout, error) {
+	var f tcxFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var workouts []Workout
+	for _, a := range f.Activities {
+		w := Workout{Sport: a.Sport, Start: a.ID}
+		var elevations, heartRates, powers []float64
+		var first, last time.Time
+		for _, lap := range a.Laps {
+			if w.Start.IsZero() {
+				w.Start = lap.StartTime
+			}
+			w.Duration += time.Duration(lap.TotalTimeSeconds * float64(time.Second))
+			w.Distance += lap.DistanceMeters
+			for _, p := range lap.Points {
+				if !p.Time.IsZero() {
+					if first.IsZero() {
+						first = p.Time
+					}
+					last = p.Time
+				}
+				if p.Altitude != nil {
+					elevations = append(elevations, *p.Altitude)
+				}
+				if p.HeartRate != nil {
+					heartRates = append(heartRates, *p.HeartRate)
+				}
+				if p.Power != nil {
+					powers = append(powers, *p.Power)
+				}
+				if p.Position != nil {
+					w.Points = append(w.Points, geo.Point{Lat: p.Position.Lat, Lon: p.Position.Lon, Elevation: p.Altitude, Time: p.Time})
+				}
+			}
+		}
+		if w.Start.IsZero() {
+			w.Start = first
+		}
+		if w.Start.IsZero() {
+			continue
+		}
+		if w.Duration <= 0 {
+			w.Duration = last.Sub(first)
+		}
+		summarize(&w, elevations)
+		summarizeSamples(&w, heartRates, powers)
+		workouts = append(workouts, w)
+	}
+	return workouts, nil
+}
@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/config"
+	"fitbyte/internal/database"
+	"fitbyte/internal/handlers"
+	"fitbyte/internal/idempotency"
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/middleware"
+	"fitbyte/internal/ratelimit"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/routes"
+	"fitbyte/internal/services"
+	"fitbyte/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// shutdownTimeout bounds how long Run waits for in-flight requests on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// exportPollInterval is how often the export worker looks for queued exports
+// requested on other instances and removes expired ones
+const exportPollInterval = time.Minute
+
+// App is the composition root: it owns every long-lived dependency of the API
+type App struct {
+	Config *config.Config
+	Logger zerolog.Logger
+	Router *gin.Engine
+
+	DB                 *gorm.DB
+	Users              repository.UserRepository
+	Activities         repository.ActivityRepository
+	Achievements       repository.AchievementRepository
+	Goals              repository.GoalRepository
+	Measurements       repository.MeasurementRepository
+	Exports            repository.ExportRepository
+	Audit              *audit.Log
+	Storage            *storage.LocalStorage
+	Tokens             *auth.TokenManager
+	Guard              *lockout.Guard
+	AuthService        *services.AuthService
+	UserService        *services.UserService
+	ActivityService    *services.ActivityService
+	AchievementService *services.AchievementService
+	ExportService      *services.ExportService
+	GoalService        *services.GoalService
+	MeasurementService *services.MeasurementService
+	MetricsService     *services.MetricsService
+	StatsService       *services.StatsService
+}
+
+// New builds the application from configuration
+func New(cfg *config.Config) (*App, error) {
+	a := &App{
+		Config: cfg,
+		Logger: zerolog.New(os.Stdout).With().Timestamp().Logger(),
+	}
+
+	// Stores
+	db, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	a.DB = db
+	if database.IsMemory(cfg.DatabaseURL) {
+		// Nothing persists between runs, so the schema is always created
+		if err := database.Migrate(db); err != nil {
+			return nil, fmt.Errorf("migrate in-memory database: %w", err)
+		}
+	}
+	a.Users = repository.NewUserRepository(db)
+	a.Activities = repository.NewActivityRepository(db)
+	a.Achievements = repository.NewAchievementRepository(db)
+	a.Goals = repository.NewGoalRepository(db)
+	a.Measurements = repository.NewMeasurementRepository(db)
+	a.Exports = repository.NewExportRepository(db)
+	a.Audit = audit.NewLog(repository.NewAuditRepository(db))
+	a.Storage, err = storage.NewLocalStorage(cfg.UploadDir, cfg.PublicURL)
+	if err != nil {
+		return nil, err
+	}
+	limiterStore, err := ratelimit.NewStore(cfg.RateLimitStore, cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("create rate limit store: %w", err)
+	}
+	idempotencyStore, err := idempotency.NewStore(cfg.IdempotencyStore, cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("create idempotency store: %w", err)
+	}
+
+	// Services
+	a.Tokens = auth.NewTokenManager(cfg.JWTSecret, cfg.JWTExpiry)
+	a.Guard = lockout.NewGuard(lockout.NewMemoryStore(), lockout.Policy{
+		MaxEmailFailures: cfg.LoginMaxEmailFailures,
+		MaxIPFailures:    cfg.LoginMaxIPFailures,
+		Window:           cfg.LoginFailureWindow,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		BaseDelay:        cfg.LoginBaseDelay,
+		MaxDelay:         cfg.LoginMaxDelay,
+	})
+	a.AuthService = services.NewAuthService(a.Users, a.Tokens, a.Guard, a.Audit)
+	a.AchievementService = services.NewAchievementService(a.Achievements, a.Activities, a.Users)
+	a.ActivityService = services.NewActivityService(a.Activities, a.AchievementService, a.Audit, a.Logger)
+	a.GoalService = services.NewGoalService(a.Goals, a.Activities, a.Measurements)
+	a.MeasurementService = services.NewMeasurementService(a.Measurements, a.Users)
+	a.MetricsService = services.NewMetricsService(a.Activities)
+	a.StatsService = services.NewStatsService(a.Activities)
+	a.ExportService, err = services.NewExportService(a.Exports, a.Users, a.Activities, a.Goals, a.Measurements, a.Achievements, a.Storage,
+		auth.NewURLSigner(cfg.JWTSecret), a.Audit, services.ExportConfig{
+			Dir:       cfg.ExportDir,
+			TTL:       cfg.ExportTTL,
+			LinkTTL:   cfg.ExportLinkTTL,
+			PublicURL: cfg.PublicURL,
+		})
+	if err != nil {
+		return nil, err
+	}
+	a.UserService = services.NewUserService(a.Users, a.Storage, a.ExportService, a.Audit, cfg.UserRestorePeriod)
+
+	// Router
+	policies := make(map[string]ratelimit.Policy)
+	for name, spec := range map[string]string{
+		middleware.RateLimitDefault: cfg.RateLimitDefault,
+		middleware.RateLimitLogin:   cfg.RateLimitLogin,
+		middleware.RateLimitUpload:  cfg.RateLimitUpload,
+	} {
+		if policies[name], err = ratelimit.ParsePolicy(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	a.Router = gin.New()
+	if err := a.Router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	a.Router.Use(middleware.RequestID())
+	a.Router.Use(middleware.Logger(a.Logger))
+	a.Router.Use(middleware.Recovery())
+	a.Router.Use(middleware.CORS())
+
+	spec := routes.Spec()
+	var validate gin.HandlerFunc
+	if cfg.OpenAPIValidation {
+		validate = middleware.OpenAPIValidator(spec, cfg.Environment == "development", a.Logger)
+	}
+
+	docsHandler, err := handlers.NewDocsHandler(spec)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenAPI document: %w", err)
+	}
+
+	routes.SetupRoutes(a.Router, routes.Handlers{
+		Health:      handlers.NewHealthHandler(a.ping),
+		User:        handlers.NewUserHandler(a.UserService, a.AuthService),
+		Auth:        handlers.NewAuthHandler(a.AuthService),
+		Lockout:     handlers.NewLockoutHandler(a.Guard),
+		Audit:       handlers.NewAuditHandler(a.Audit),
+		Activity:    handlers.NewActivityHandler(a.ActivityService, a.UserService, cfg.ImportMaxBytes),
+		Achievement: handlers.NewAchievementHandler(a.AchievementService, a.UserService),
+		Docs:        docsHandler,
+		File:        handlers.NewFileHandler(a.Storage, cfg.UploadMaxBytes),
+		Export:      handlers.NewExportHandler(a.ExportService),
+		Goal:        handlers.NewGoalHandler(a.GoalService, a.UserService),
+		Measurement: handlers.NewMeasurementHandler(a.MeasurementService, a.UserService),
+		Metrics:     handlers.NewMetricsHandler(a.MetricsService, a.UserService),
+		Stats:       handlers.NewStatsHandler(a.StatsService, a.UserService),
+		Uploads:     gin.Dir(a.Storage.Dir(), false),
+	}, routes.Middleware{
+		RateLimiter: middleware.NewRateLimiter(limiterStore, policies, a.Logger),
+		Auth:        middleware.Auth(a.AuthService),
+		IfMatch:     middleware.RequireIfMatch(cfg.RequireIfMatch),
+		Idempotency: middleware.Idempotency(idempotencyStore, cfg.IdempotencyTTL, a.Logger),
+		Validate:    validate,
+	})
+
+	return a, nil
+}
+
+// Run serves HTTP on the configured port until ctx is cancelled, then shuts
+// down gracefully
+func (a *App) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              ":" + a.Config.Port,
+		Handler:           a.Router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		a.Logger.Info().Str("port", a.Config.Port).Msg("Server starting")
+		errCh <- srv.ListenAndServe()
+	}()
+	go a.purgeLoop(ctx)
+	go a.exportLoop(ctx)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	a.Logger.Info().Msg("Server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// PurgeDeletedUsers permanently deletes users whose retention period has
+// expired and returns how many were purged
+func (a *App) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	return a.UserService.PurgeDeleted(ctx, time.Now().Add(-a.Config.UserRetention))
+}
+
+// purgeLoop runs PurgeDeletedUsers every PurgeInterval until ctx is cancelled
+func (a *App) purgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.Config.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := a.PurgeDeletedUsers(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.Logger.Error().Err(err).Int("purged", purged).Msg("Purging deleted users failed")
+		} else if purged > 0 {
+			a.Logger.Info().Int("purged", purged).Msg("Purged deleted users")
+		}
+	}
+}
+
+// exportLoop builds requested exports in the background and removes expired
+// ones until ctx is cancelled
+func (a *App) exportLoop(ctx context.Context) {
+	if err := a.ExportService.Recover(ctx); err != nil {
+		a.Logger.Error().Err(err).Msg("Requeueing interrupted exports failed")
+	}
+
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+	for {
+		built, err := a.ExportService.ProcessPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			a.Logger.Error().Err(err).Msg("Building exports failed")
+		}
+		if built > 0 {
+			a.Logger.Info().Int("built", built).Msg("Built exports")
+		}
+		if _, err := a.ExportService.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+			a.Logger.Error().Err(err).Msg("Deleting expired exports failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.ExportService.Pending():
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close releases the database connection
+func (a *App) Close() error {
+	return database.Close(a.DB)
+}
+
+func (a *App) ping(ctx context.Context) error {
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
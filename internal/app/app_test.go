@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"fitbyte/internal/config"
+	"fitbyte/internal/database"
+	"fitbyte/internal/middleware"
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestApp builds the application on a SQLite database of the test's own
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	cfg := config.Load()
+	cfg.Environment = "test"
+	cfg.DatabaseURL = "sqlite://" + filepath.Join(dir, "test.db")
+	cfg.UploadDir = filepath.Join(dir, "uploads")
+	cfg.ExportDir = filepath.Join(dir, "exports")
+
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	if err := database.Migrate(a.DB); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// signIn stores a user with role and returns a bearer token for them
+func signIn(t *testing.T, a *App, email, role string) (*models.User, string) {
+	t.Helper()
+	user := &models.User{Email: email, PasswordHash: "x", Role: role}
+	if err := a.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := a.Tokens.Generate(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, token
+}
+
+// send serves a request with a JSON body, when one is given, as the owner
+// of token
+func send(a *App, method, path, token, body string, headers ...string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminRoutesRequireRole(t *testing.T) {
+	a := newTestApp(t)
+	_, userToken := signIn(t, a, "user@example.com", models.RoleUser)
+	_, coachToken := signIn(t, a, "coach@example.com", models.RoleCoach)
+	_, adminToken := signIn(t, a, "admin@example.com", models.RoleAdmin)
+	target, _ := signIn(t, a, "target@example.com", models.RoleUser)
+	id := strconv.FormatUint(uint64(target.ID), 10)
+
+	staffOnly := []string{models.RoleCoach, models.RoleAdmin}
+	adminOnly := []string{models.RoleAdmin}
+	routes := []struct {
+		method  string
+		path    string
+		body    string
+		allowed []string
+	}{
+		{http.MethodGet, "/api/v1/admin/users/", "", staffOnly},
+		{http.MethodGet, "/api/v1/admin/users/" + id, "", staffOnly},
+		{http.MethodGet, "/api/v1/admin/lockouts", "", adminOnly},
+		{http.MethodDelete, "/api/v1/admin/lockouts?ip=10.0.0.1", "", adminOnly},
+		{http.MethodGet, "/api/v1/admin/audit", "", adminOnly},
+		{http.MethodPost, "/api/v1/admin/users/", `{"email":"new@example.com"}`, adminOnly},
+		{http.MethodPatch, "/api/v1/admin/users/" + id, `{"name":"Target"}`, adminOnly},
+		{http.MethodPut, "/api/v1/admin/users/" + id, `{"email":"target@example.com"}`, adminOnly},
+		{http.MethodPut, "/api/v1/admin/users/" + id + "/role", `{"role":"coach"}`, adminOnly},
+		{http.MethodPost, "/api/v1/admin/users/" + id + "/restore", "", adminOnly},
+		{http.MethodDelete, "/api/v1/admin/users/" + id, "", adminOnly},
+	}
+	tokens := map[string]string{
+		models.RoleUser:  userToken,
+		models.RoleCoach: coachToken,
+		models.RoleAdmin: adminToken,
+	}
+
+	for _, route := range routes {
+		// Admins go last, so the target still exists for everyone else
+		for _, role := range []string{"", models.RoleUser, models.RoleCoach, models.RoleAdmin} {
+			allowed := false
+			for _, r := range route.allowed {
+				allowed = allowed || r == role
+			}
+			t.Run(route.method+" "+route.path+" as "+role, func(t *testing.T) {
+				w := send(a, route.method, route.path, tokens[role], route.body)
+				switch {
+				case role == "":
+					if w.Code != http.StatusUnauthorized {
+						t.Errorf("status = %d, want 401", w.Code)
+					}
+				case !allowed:
+					if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "Insufficient permissions") {
+						t.Errorf("status = %d %s, want 403", w.Code, w.Body)
+					}
+				case w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden:
+					t.Errorf("status = %d %s, want the request to be let through", w.Code, w.Body)
+				}
+			})
+		}
+	}
+
+	// Only the admin's requests changed anything
+	users, total, err := a.Users.List(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 {
+		t.Errorf("%d users remain, want 4: the admin created one and deleted the target", total)
+	}
+	for _, u := range users {
+		if u.ID == target.ID {
+			t.Error("target user was not deleted")
+		}
+	}
+}
+
+func TestRoleChangeTakesEffect(t *testing.T) {
+	a := newTestApp(t)
+	_, adminToken := signIn(t, a, "admin@example.com", models.RoleAdmin)
+	user, userToken := signIn(t, a, "user@example.com", models.RoleUser)
+	path := "/api/v1/admin/users/" + strconv.FormatUint(uint64(user.ID), 10) + "/role"
+
+	if w := send(a, http.MethodGet, "/api/v1/admin/users/", userToken, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("listing users as a user = %d, want 403", w.Code)
+	}
+	if w := send(a, http.MethodPut, path, adminToken, `{"role":"coach"}`); w.Code != http.StatusOK {
+		t.Fatalf("setting the role = %d %s", w.Code, w.Body)
+	}
+	// The role is read from the account on every request, not the token
+	if w := send(a, http.MethodGet, "/api/v1/admin/users/", userToken, ""); w.Code != http.StatusOK {
+		t.Errorf("listing users as a coach = %d, want 200", w.Code)
+	}
+	if w := send(a, http.MethodPut, path, userToken, `{"role":"admin"}`); w.Code != http.StatusForbidden {
+		t.Errorf("a coach promoting themselves = %d, want 403", w.Code)
+	}
+	if w := send(a, http.MethodPut, "/api/v1/admin/users/1/role", adminToken, `{"role":"user"}`); w.Code != http.StatusForbidden {
+		t.Errorf("an admin demoting themselves = %d, want 403", w.Code)
+	}
+}
+
+func TestProfileRejectsNonPositiveMeasurements(t *testing.T) {
+	a := newTestApp(t)
+	_, token := signIn(t, a, "user@example.com", models.RoleUser)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"zero height", http.MethodPatch, `{"height":0}`, http.StatusBadRequest},
+		{"negative weight", http.MethodPatch, `{"weight":-70}`, http.StatusBadRequest},
+		{"replaced with zero weight", http.MethodPut, `{"email":"user@example.com","weight":0}`, http.StatusBadRequest},
+		{"cleared", http.MethodPatch, `{"weight":null,"height":null}`, http.StatusOK},
+		{"positive", http.MethodPatch, `{"weight":70,"height":175}`, http.StatusOK},
+		{"replaced with positive weight", http.MethodPut, `{"email":"user@example.com","weight":70}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(a, tt.method, "/api/v1/user", token, tt.body); w.Code != tt.want {
+				t.Errorf("%s %s = %d %s, want %d", tt.method, tt.body, w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationRunsAfterAuthAndRateLimit(t *testing.T) {
+	t.Setenv("OPENAPI_VALIDATION", "true")
+	t.Setenv("RATE_LIMIT_LOGIN", "1/1m")
+	a := newTestApp(t)
+	_, userToken := signIn(t, a, "user@example.com", models.RoleUser)
+	_, coachToken := signIn(t, a, "coach@example.com", models.RoleCoach)
+	invalid := `{"durationInMinutes":"long"}`
+	oversized := `{"activityType":"` + strings.Repeat("a", middleware.MaxValidatedBody) + `"}`
+
+	// Steps run in order, so the second login is over its limit
+	tests := []struct {
+		name         string
+		method, path string
+		token, body  string
+		want         int
+	}{
+		{"unauthenticated", http.MethodPost, "/api/v1/activity/", "", invalid, http.StatusUnauthorized},
+		{"authenticated", http.MethodPost, "/api/v1/activity/", userToken, invalid, http.StatusBadRequest},
+		{"without the role", http.MethodPost, "/api/v1/admin/users/", coachToken, `{"email":1}`, http.StatusForbidden},
+		{"first login", http.MethodPost, "/api/v1/login", "", `{"email":1}`, http.StatusBadRequest},
+		{"throttled login", http.MethodPost, "/api/v1/login", "", `{"email":1}`, http.StatusTooManyRequests},
+		{"oversized body", http.MethodPost, "/api/v1/activity/", userToken, oversized, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(a, tt.method, tt.path, tt.token, tt.body); w.Code != tt.want {
+				t.Errorf("%s %s = %d %.200s, want %d", tt.method, tt.path, w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
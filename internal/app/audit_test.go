@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+// auditPage is the body of a GET /api/v1/admin/audit response
+type auditPage struct {
+	Data       []models.AuditEntry `json:"data"`
+	Pagination models.Pagination   `json:"pagination"`
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+	admin, adminToken := signIn(t, a, "admin@example.com", models.RoleAdmin)
+	target, targetToken := signIn(t, a, "target@example.com", models.RoleUser)
+	other, _ := signIn(t, a, "other@example.com", models.RoleUser)
+	path := func(u *models.User) string {
+		return "/api/v1/admin/users/" + strconv.FormatUint(uint64(u.ID), 10)
+	}
+
+	// Three audited admin actions, then one by the target themselves
+	for _, step := range []struct {
+		method, path, body, requestID string
+	}{
+		{http.MethodPatch, path(target), `{"name":"Target"}`, "req-update"},
+		{http.MethodPut, path(target) + "/role", `{"role":"coach"}`, "req-role"},
+		{http.MethodDelete, path(other), "", "req-delete"},
+		{http.MethodPatch, "/api/v1/user", `{"name":"Me"}`, "req-profile"},
+	} {
+		token := adminToken
+		if step.requestID == "req-profile" {
+			token = targetToken
+		}
+		if w := send(a, step.method, step.path, token, step.body, "X-Request-ID", step.requestID); w.Code != http.StatusOK {
+			t.Fatalf("%s %s = %d %s", step.method, step.path, w.Code, w.Body)
+		}
+	}
+
+	list := func(t *testing.T, query url.Values) auditPage {
+		t.Helper()
+		w := send(a, http.MethodGet, "/api/v1/admin/audit?"+query.Encode(), adminToken, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET audit?%s = %d %s", query.Encode(), w.Code, w.Body)
+		}
+		var page auditPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+	id := func(u *models.User) string { return strconv.FormatUint(uint64(u.ID), 10) }
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	// Bounds in other zones than the UTC the entries are stored in
+	east, west := time.FixedZone("", 14*60*60), time.FixedZone("", -12*60*60)
+	hourAgo := time.Now().Add(-time.Hour).In(east).Format(time.RFC3339)
+	inAnHour := time.Now().Add(time.Hour).In(west).Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		actions []string
+	}{
+		{"everything, newest first", url.Values{}, []string{audit.ActionUserUpdated, audit.ActionUserDeleted, audit.ActionRoleChanged, audit.ActionUserUpdated}},
+		{"by action", url.Values{"action": {audit.ActionRoleChanged}}, []string{audit.ActionRoleChanged}},
+		{"by actor", url.Values{"actorId": {id(admin)}}, []string{audit.ActionUserDeleted, audit.ActionRoleChanged, audit.ActionUserUpdated}},
+		{"by user", url.Values{"userId": {id(target)}}, []string{audit.ActionUserUpdated, audit.ActionRoleChanged, audit.ActionUserUpdated}},
+		{"by request", url.Values{"requestId": {"req-delete"}}, []string{audit.ActionUserDeleted}},
+		{"combined", url.Values{"actorId": {id(admin)}, "userId": {id(target)}}, []string{audit.ActionRoleChanged, audit.ActionUserUpdated}},
+		{"from the future", url.Values{"from": {future}}, nil},
+		{"until the future", url.Values{"to": {future}, "action": {audit.ActionUserDeleted}}, []string{audit.ActionUserDeleted}},
+		{"offset bounds", url.Values{"from": {hourAgo}, "to": {inAnHour}, "action": {audit.ActionUserDeleted}}, []string{audit.ActionUserDeleted}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := list(t, tt.query)
+			var actions []string
+			for _, e := range page.Data {
+				actions = append(actions, e.Action)
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.actions, ",") {
+				t.Errorf("actions = %v, want %v", actions, tt.actions)
+			}
+			if page.Pagination.Total != int64(len(tt.actions)) {
+				t.Errorf("total = %d, want %d", page.Pagination.Total, len(tt.actions))
+			}
+		})
+	}
+
+	t.Run("entry details", func(t *testing.T) {
+		page := list(t, url.Values{"requestId": {"req-role"}})
+		if len(page.Data) != 1 {
+			t.Fatalf("got %d entries, want 1", len(page.Data))
+		}
+		e := page.Data[0]
+		if e.ActorID == nil || *e.ActorID != admin.ID || e.UserID == nil || *e.UserID != target.ID || e.IP == "" {
+			t.Errorf("entry = %+v, want the admin acting on the target from an IP", e)
+		}
+		if change, ok := e.Changes["role"]; !ok || change.Old != models.RoleUser || change.New != models.RoleCoach {
+			t.Errorf("changes = %+v, want role user -> coach", e.Changes)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		var seen []uint
+		for page := 1; page <= 2; page++ {
+			res := list(t, url.Values{"page": {strconv.Itoa(page)}, "limit": {"3"}})
+			if res.Pagination.Total != 4 || res.Pagination.TotalPages != 2 || res.Pagination.Page != page || res.Pagination.Limit != 3 {
+				t.Errorf("page %d pagination = %+v", page, res.Pagination)
+			}
+			for _, e := range res.Data {
+				seen = append(seen, e.ID)
+			}
+		}
+		if len(seen) != 4 {
+			t.Fatalf("paged through %d entries, want 4", len(seen))
+		}
+		for i := 1; i < len(seen); i++ {
+			if seen[i] >= seen[i-1] {
+				t.Errorf("entries %v are not newest first without repeats", seen)
+				break
+			}
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=101", "page=0", "from=yesterday", "actorId=me"} {
+			if w := send(a, http.MethodGet, "/api/v1/admin/audit?"+query, adminToken, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("GET audit?%s = %d, want 400", query, w.Code)
+			}
+		}
+	})
+
+	t.Run("append only", func(t *testing.T) {
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			for _, p := range []string{"/api/v1/admin/audit", "/api/v1/admin/audit/1"} {
+				if w := send(a, method, p, adminToken, `{}`); w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+					t.Errorf("%s %s = %d, want no such route", method, p, w.Code)
+				}
+			}
+		}
+		entries, total, err := a.Audit.List(ctx, repository.AuditFilter{Limit: 10})
+		if err != nil || total != 4 || len(entries) != 4 {
+			t.Errorf("audit log holds %d entries, %v, want the 4 written", total, err)
+		}
+	})
+}
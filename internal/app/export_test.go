@@ -0,0 +1,111 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+func TestExportDownload(t *testing.T) {
+	a := newTestApp(t)
+	_, token := signIn(t, a, "owner@example.com", models.RoleUser)
+	_, otherToken := signIn(t, a, "other@example.com", models.RoleUser)
+
+	// export fetches the status of export id as the owner of token
+	export := func(t *testing.T, token string, id uint) (int, models.ExportResponse) {
+		t.Helper()
+		w := send(a, http.MethodGet, "/api/v1/user/export/"+strconv.FormatUint(uint64(id), 10), token, "")
+		var res struct {
+			Data models.ExportResponse `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, res.Data
+	}
+	// path returns the path and query of a download link
+	path := func(t *testing.T, link string) string {
+		t.Helper()
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.RequestURI()
+	}
+
+	w := send(a, http.MethodPost, "/api/v1/user/export", token, "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST export = %d %s", w.Code, w.Body)
+	}
+	var requested struct {
+		Data models.ExportResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &requested); err != nil {
+		t.Fatal(err)
+	}
+	id := requested.Data.ID
+	if requested.Data.Status != models.ExportPending || requested.Data.DownloadURL != nil {
+		t.Errorf("requested export = %+v, want it pending without a link", requested.Data)
+	}
+
+	// A correctly signed link does not work before the archive is built
+	expires := time.Now().Add(time.Hour)
+	early := a.ExportService.Response(&models.Export{ID: id, Status: models.ExportCompleted, ExpiresAt: &expires})
+	if w := send(a, http.MethodGet, path(t, *early.DownloadURL), "", ""); w.Code != http.StatusConflict {
+		t.Errorf("download before completion = %d, want 409", w.Code)
+	}
+
+	if _, err := a.ExportService.ProcessPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	code, completed := export(t, token, id)
+	if code != http.StatusOK || completed.Status != models.ExportCompleted || completed.DownloadURL == nil {
+		t.Fatalf("GET export = %d %+v, want it completed with a link", code, completed)
+	}
+	if code, _ := export(t, otherToken, id); code != http.StatusNotFound {
+		t.Errorf("GET another user's export = %d, want 404", code)
+	}
+
+	link := path(t, *completed.DownloadURL)
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"signed link", link, http.StatusOK},
+		{"unsigned", strings.SplitN(link, "?", 2)[0], http.StatusForbidden},
+		{"tampered", strings.Replace(link, "signature=", "signature=00", 1), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Signed links work without a token
+			w := send(a, http.MethodGet, tt.path, "", "")
+			if w.Code != tt.want {
+				t.Fatalf("GET %s = %d %s, want %d", tt.path, w.Code, w.Body, tt.want)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "fitbyte-export-"+strconv.FormatUint(uint64(id), 10)+".zip") {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "private, no-store" {
+				t.Errorf("Cache-Control = %q", got)
+			}
+			if _, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len())); err != nil {
+				t.Errorf("downloaded archive: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package audit records security relevant and data changing events
+package audit
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+// Actions
+const (
+	ActionLoginSucceeded  = "login.succeeded"
+	ActionLoginFailed     = "login.failed"
+	ActionUserUpdated     = "user.updated"
+	ActionRoleChanged     = "user.role_changed"
+	ActionPasswordChanged = "user.password_changed"
+	ActionUserDisabled    = "user.disabled"
+	ActionUserDeleted     = "user.deleted"
+	ActionUserRestored    = "user.restored"
+	ActionUserPurged      = "user.purged"
+	ActionAccountDeleted  = "account.deleted"
+	ActionActivityDeleted = "activity.deleted"
+	ActionExportRequested = "export.requested"
+	ActionExportDownload  = "export.downloaded"
+)
+
+// Event is something that happened to an account
+type Event struct {
+	Action string
+	// UserID is the account the event concerns
+	UserID uint
+	// ActorID is the user who caused the event. The authenticated user of
+	// the request is used when it is zero
+	ActorID uint
+	Changes map[string]models.FieldChange
+	Details map[string]string
+}
+
+// Recorder stores audit events
+type Recorder interface {
+	Record(ctx context.Context, event Event) error
+}
+
+// Log is a Recorder appending to the audit repository. The client IP,
+// request ID and actor are taken from the request context
+type Log struct {
+	entries repository.AuditRepository
+}
+
+// NewLog creates a recorder writing to entries
+func NewLog(entries repository.AuditRepository) *Log {
+	return &Log{entries: entries}
+}
+
+// Record appends event to the audit log
+func (l *Log) Record(ctx context.Context, event Event) error {
+	src := sourceFrom(ctx)
+	entry := &models.AuditEntry{
+		Action:    event.Action,
+		ActorID:   optionalID(event.ActorID),
+		UserID:    optionalID(event.UserID),
+		IP:        src.ip,
+		RequestID: src.requestID,
+		Changes:   event.Changes,
+		Details:   event.Details,
+		CreatedAt: time.Now().UTC(),
+	}
+	if entry.ActorID == nil {
+		entry.ActorID = optionalID(src.actorID)
+	}
+	return l.entries.Create(ctx, entry)
+}
+
+// List returns the entries matching filter and how many match in total
+func (l *Log) List(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, int64, error) {
+	return l.entries.List(ctx, filter)
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,32 @@
+package audit
+
+import "context"
+
+type contextKey struct{}
+
+// source describes the request an event happened in
+type source struct {
+	ip        string
+	requestID string
+	actorID   uint
+}
+
+// WithRequest returns a context recording the client IP and request ID of
+// the request it belongs to
+func WithRequest(ctx context.Context, ip, requestID string) context.Context {
+	src := sourceFrom(ctx)
+	src.ip, src.requestID = ip, requestID
+	return context.WithValue(ctx, contextKey{}, src)
+}
+
+// WithActor returns a context recording the authenticated user of the request
+func WithActor(ctx context.Context, userID uint) context.Context {
+	src := sourceFrom(ctx)
+	src.actorID = userID
+	return context.WithValue(ctx, contextKey{}, src)
+}
+
+func sourceFrom(ctx context.Context) source {
+	src, _ := ctx.Value(contextKey{}).(source)
+	return src
+}
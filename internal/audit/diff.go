@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+
+	"fitbyte/internal/models"
+)
+
+// Diff compares two values of the same struct type field by field and
+// returns the fields that differ, keyed by their JSON name. Pointers are
+// compared by the values they point to; nil stands for a cleared field
+func Diff(before, after interface{}) map[string]models.FieldChange {
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	if b.Type() != a.Type() || b.Kind() != reflect.Struct {
+		panic("audit: Diff needs two structs of the same type")
+	}
+
+	changes := map[string]models.FieldChange{}
+	for i := 0; i < b.NumField(); i++ {
+		f := b.Type().Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		old, cur := value(b.Field(i)), value(a.Field(i))
+		if !reflect.DeepEqual(old, cur) {
+			changes[name] = models.FieldChange{Old: old, New: cur}
+		}
+	}
+	return changes
+}
+
+// value returns the plain value of a field, nil for nil pointers
+func value(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("secret")
+	const path = "/api/v1/user/export/7/download"
+	query := func(t *testing.T, link string) url.Values {
+		t.Helper()
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query()
+	}
+	valid := signer.Sign(path, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		path  string
+		query func(t *testing.T) url.Values
+		want  error
+	}{
+		{"valid", path, func(t *testing.T) url.Values { return query(t, valid) }, nil},
+		{"other path", "/api/v1/user/export/8/download", func(t *testing.T) url.Values { return query(t, valid) }, ErrInvalidSignature},
+		{"expiry extended", path, func(t *testing.T) url.Values {
+			q := query(t, valid)
+			q.Set("expires", q.Get("expires")+"0")
+			return q
+		}, ErrInvalidSignature},
+		{"signature changed", path, func(t *testing.T) url.Values {
+			q := query(t, valid)
+			sig := q.Get("signature")
+			q.Set("signature", strings.Repeat("0", len(sig)))
+			return q
+		}, ErrInvalidSignature},
+		{"signature not hex", path, func(t *testing.T) url.Values {
+			q := query(t, valid)
+			q.Set("signature", "not hex")
+			return q
+		}, ErrInvalidSignature},
+		{"unsigned", path, func(t *testing.T) url.Values { return url.Values{} }, ErrInvalidSignature},
+		{"other secret", path, func(t *testing.T) url.Values {
+			return query(t, NewURLSigner("other").Sign(path, time.Now().Add(time.Hour)))
+		}, ErrInvalidSignature},
+		{"expired", path, func(t *testing.T) url.Values {
+			return query(t, signer.Sign(path, time.Now().Add(-time.Minute)))
+		}, ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.path, tt.query(t)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken is returned when a token cannot be verified
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrRevokedToken is returned for a valid token whose account has been
+	// deleted or disabled since it was issued
+	ErrRevokedToken = errors.New("token has been revoked")
+)
+
+// Claims are the verified contents of a token
+type Claims struct {
+	UserID   uint
+	IssuedAt time.Time
+}
+
+// TokenManager issues and verifies HMAC signed JWTs
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokenManager creates a new token manager
+func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
+	return &TokenManager{secret: []byte(secret), ttl: ttl}
+}
+
+// Generate issues a token for the user
+func (m *TokenManager) Generate(userID uint) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Parse verifies a token and returns its claims
+func (m *TokenManager) Parse(tokenString string) (Claims, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.IssuedAt == nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	id, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	return Claims{UserID: uint(id), IssuedAt: claims.IssuedAt.Time}, nil
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"fitbyte/internal/app"
+	"fitbyte/internal/config"
+	"fitbyte/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: fitbyte <command> [flags]
+
+Commands:
+  serve                 Start the HTTP server (default)
+  migrate               Create or update the database schema
+  seed                  Generate users and activities for development
+  import                Import activities from GPX, TCX and FIT files
+  purge                 Permanently delete users whose retention has expired
+  user create           Create a user
+  user disable          Disable a user so they can no longer log in
+  user reset-password   Set a new password for a user
+  user role             Change a user's role (user, coach or admin)
+  config validate       Check the configuration for errors
+  routes                Print the route table
+  healthcheck           Check the readiness endpoint of a running server
+
+Run "fitbyte <command> -h" for command flags.
+`
+
+// command is a CLI subcommand
+type command func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error
+
+// errUsage signals that usage was already printed
+var errUsage = errors.New("usage")
+
+// Run executes the command in args and returns the process exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	commands := map[string]command{
+		"serve":       serve,
+		"migrate":     migrate,
+		"seed":        seedCommand,
+		"import":      importActivities,
+		"purge":       purge,
+		"user":        userCommand,
+		"config":      configCommand,
+		"routes":      printRoutes,
+		"healthcheck": healthcheck,
+	}
+
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	if name != "serve" {
+		// Keep gin's debug route listing out of command output
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd(ctx, loadConfig(), args, stdout); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintln(stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig loads the .env file, if any, and the configuration
+func loadConfig() *config.Config {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("Failed to load .env file:", err)
+	}
+	return config.Load()
+}
+
+// withApp builds the application, runs fn and closes the application
+func withApp(cfg *config.Config, fn func(a *app.App) error) error {
+	a, err := app.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	return fn(a)
+}
+
+func serve(ctx context.Context, cfg *config.Config, args []string, _ io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return withApp(cfg, func(a *app.App) error {
+		return a.Run(ctx)
+	})
+}
+
+func purge(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withApp(cfg, func(a *app.App) error {
+		purged, err := a.PurgeDeletedUsers(ctx)
+		fmt.Fprintf(out, "Purged %d deleted users\n", purged)
+		return err
+	})
+}
+
+func migrate(_ context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if database.IsMemory(cfg.DatabaseURL) {
+		return errors.New("DATABASE_URL is not set; the in-memory database is migrated on startup")
+	}
+
+	db, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Database migrated")
+	return nil
+}
+
+func configCommand(_ context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprint(out, "Usage: fitbyte config validate\n")
+		return errUsage
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	fmt.Fprintln(out, "Configuration is valid")
+	return nil
+}
+
+func printRoutes(_ context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withApp(cfg, func(a *app.App) error {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "METHOD\tPATH\tHANDLER")
+		for _, route := range a.Router.Routes() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", route.Method, route.Path, route.Handler)
+		}
+		return w.Flush()
+	})
+}
+
+func healthcheck(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	url := fs.String("url", "http://127.0.0.1:"+cfg.Port+"/api/v1/health/ready", "readiness endpoint to check")
+	timeout := fs.Duration("timeout", 3*time.Second, "request timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", *url, res.Status)
+	}
+	fmt.Fprintln(out, "OK")
+	return nil
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/config"
+	"fitbyte/internal/database"
+	"fitbyte/internal/models"
+	"fitbyte/internal/seed"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// Keep gin's route listing out of the test output
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testConfig returns a valid configuration keeping its database and files
+// in a directory of the test's own
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.Load()
+	cfg.Environment = "test"
+	cfg.DatabaseURL = "sqlite://" + filepath.Join(dir, "test.db")
+	cfg.UploadDir = filepath.Join(dir, "uploads")
+	cfg.ExportDir = filepath.Join(dir, "exports")
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// run runs cmd with args and returns its output
+func run(t *testing.T, cmd command, cfg *config.Config, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := cmd(context.Background(), cfg, args, &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{[]string{"help"}, 0, "Usage: fitbyte <command>", ""},
+		{[]string{"frobnicate"}, 2, "", `unknown command "frobnicate"`},
+		{[]string{"user"}, 2, "Usage: fitbyte user", ""},
+		{[]string{"config"}, 2, "Usage: fitbyte config validate", ""},
+		{[]string{"migrate", "-bogus"}, 1, "", "Error: flag provided but not defined: -bogus"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	t.Setenv("PORT", "http")
+	for _, args := range [][]string{{"config", "validate"}, {"serve"}} {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v exit code = %d, want 1", args, code)
+		}
+		if !strings.Contains(stderr.String(), "invalid configuration") || !strings.Contains(stderr.String(), "PORT") {
+			t.Errorf("%v stderr = %q, want the PORT error", args, stderr.String())
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	out, err := run(t, configCommand, testConfig(t), "validate")
+	if err != nil || out != "Configuration is valid\n" {
+		t.Errorf("config validate = %q, %v", out, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	cfg := testConfig(t)
+	for range 2 {
+		if out, err := run(t, migrate, cfg); err != nil || out != "Database migrated\n" {
+			t.Errorf("migrate = %q, %v", out, err)
+		}
+	}
+
+	cfg.DatabaseURL = ""
+	if _, err := run(t, migrate, cfg); err == nil {
+		t.Error("migrate without DATABASE_URL succeeded")
+	}
+}
+
+func TestUserCommands(t *testing.T) {
+	cfg := testConfig(t)
+	if _, err := run(t, migrate, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{"create", []string{"create", "-email", "Coach@example.com", "-password", "hunter2hunter2", "-role", "coach"}, "Created coach 1 <coach@example.com>\n", ""},
+		{"create generates a password", []string{"create", "-email", "runner@example.com"}, "Password: ", ""},
+		{"create duplicate", []string{"create", "-email", "runner@example.com"}, "", "already registered"},
+		{"create without email", []string{"create"}, "", "-email is required"},
+		{"create with unknown role", []string{"create", "-email", "x@example.com", "-role", "owner"}, "", "-role must be"},
+		{"short password", []string{"create", "-email", "x@example.com", "-password", "short"}, "", "at least 8 characters"},
+		{"role", []string{"role", "-email", "runner@example.com", "-role", "admin"}, "is now admin", ""},
+		{"reset password", []string{"reset-password", "-email", "runner@example.com", "-password", "another-password"}, "Password reset for user 2", ""},
+		{"disable", []string{"disable", "-email", "runner@example.com"}, "Disabled user 2 <runner@example.com>\n", ""},
+		{"unknown user", []string{"disable", "-email", "nobody@example.com"}, "", `no user with email "nobody@example.com"`},
+		{"unknown subcommand", []string{"delete"}, "Usage: fitbyte user", "usage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := run(t, userCommand, cfg, tt.args...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintRoutes(t *testing.T) {
+	out, err := run(t, printRoutes, testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"METHOD", "POST    /api/v1/login", "GET     /api/v1/activity/:id"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("routes output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestHealthcheck(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	cfg := testConfig(t)
+
+	if out, err := run(t, healthcheck, cfg, "-url", srv.URL); err != nil || out != "OK\n" {
+		t.Errorf("healthcheck = %q, %v", out, err)
+	}
+	status = http.StatusServiceUnavailable
+	if _, err := run(t, healthcheck, cfg, "-url", srv.URL); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("healthcheck of an unready server = %v, want the 503", err)
+	}
+}
+
+func TestSeedIsDeterministic(t *testing.T) {
+	type row struct {
+		Email          string
+		ActivityType   string
+		DoneAt         time.Time
+		CaloriesBurned int
+	}
+	// seeded runs the seed command with default dates against a new
+	// database and returns what it stored
+	seeded := func() []row {
+		cfg := testConfig(t)
+		if _, err := run(t, migrate, cfg); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"Created 2 users", "Created 0 users with 0 activities, skipped 2"} {
+			out, err := run(t, seedCommand, cfg, "-users", "2", "-months", "1")
+			if err != nil || !strings.Contains(out, want) {
+				t.Fatalf("seed = %q, %v, want %q", out, err, want)
+			}
+		}
+
+		db, err := database.Open(cfg.DatabaseURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer database.Close(db)
+		var rows []row
+		err = db.Model(&models.Activity{}).
+			Select("users.email, activities.activity_type, activities.done_at, activities.calories_burned").
+			Joins("JOIN users ON users.id = activities.user_id").
+			Order("users.email, activities.done_at, activities.id").Scan(&rows).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows
+	}
+
+	first, second := seeded(), seeded()
+	if len(first) == 0 {
+		t.Fatal("seed stored no activities")
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("seeding twice stored different activities")
+	}
+	if last := first[len(first)-1].DoneAt; last.After(seed.DefaultUntil.AddDate(0, 0, 1)) {
+		t.Errorf("last activity at %v, after the default -until", last)
+	}
+}
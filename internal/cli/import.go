@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"fitbyte/internal/app"
+	"fitbyte/internal/config"
+)
+
+func importActivities(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage: fitbyte import -email <email> <file>...\n\nImports the workouts in GPX, TCX and FIT files.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one file is required")
+	}
+
+	return withApp(cfg, func(a *app.App) error {
+		user, err := findUser(ctx, a, *email)
+		if err != nil {
+			return err
+		}
+		for _, path := range fs.Args() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			result, err := a.ActivityService.Import(ctx, user, data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			fmt.Fprintf(out, "%s: imported %d activities, skipped %d duplicates\n", path, len(result.Imported), len(result.Duplicates))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"fitbyte/internal/app"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/config"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/seed"
+)
+
+func seedCommand(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := fs.Int("users", 25, "number of users to generate")
+	months := fs.Int("months", 3, "months of activities per user")
+	randomSeed := fs.Uint64("seed", 42, "random seed; the same seed produces the same data")
+	until := fs.String("until", seed.DefaultUntil.Format(time.DateOnly), "last day to generate activities for (YYYY-MM-DD)")
+	password := fs.String("password", "password123", "password for every generated user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *users < 1 || *months < 0 {
+		return errors.New("-users must be at least 1 and -months must not be negative")
+	}
+	untilDate, err := time.Parse(time.DateOnly, *until)
+	if err != nil {
+		return fmt.Errorf("invalid -until: %w", err)
+	}
+
+	opts := seed.Options{Seed: *randomSeed, Users: *users, Months: *months, Until: untilDate}
+	return withApp(cfg, func(a *app.App) error {
+		// Hashing is deliberately slow, so every user shares one hash
+		hash, err := auth.HashPassword(*password)
+		if err != nil {
+			return err
+		}
+
+		var created, skipped, activities int
+		for i := 0; i < opts.Users; i++ {
+			if _, err := a.Users.GetByEmail(ctx, seed.Email(i)); err == nil {
+				skipped++
+				continue
+			} else if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+
+			account := seed.Generate(opts, i)
+			account.User.PasswordHash = hash
+			if err := a.Users.Create(ctx, &account.User); err != nil {
+				return fmt.Errorf("create %s: %w", account.User.Email, err)
+			}
+			for j := range account.Activities {
+				account.Activities[j].UserID = account.User.ID
+			}
+			if err := a.Activities.CreateBatch(ctx, account.Activities); err != nil {
+				return fmt.Errorf("create activities for %s: %w", account.User.Email, err)
+			}
+			if err := a.AchievementService.Evaluate(ctx, account.User.ID); err != nil {
+				return fmt.Errorf("evaluate achievements for %s: %w", account.User.Email, err)
+			}
+			created++
+			activities += len(account.Activities)
+		}
+
+		fmt.Fprintf(out, "Created %d users with %d activities, skipped %d existing users\n", created, activities, skipped)
+		if created > 0 {
+			fmt.Fprintf(out, "Log in as %s with password %q\n", seed.Email(0), *password)
+		}
+		return nil
+	})
+}
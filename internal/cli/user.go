@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"fitbyte/internal/app"
+	"fitbyte/internal/config"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+const userUsage = `Usage: fitbyte user <create|disable|reset-password|role> [flags]
+`
+
+func userCommand(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, userUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		return createUser(ctx, cfg, args[1:], out)
+	case "disable":
+		return disableUser(ctx, cfg, args[1:], out)
+	case "reset-password":
+		return resetPassword(ctx, cfg, args[1:], out)
+	case "role":
+		return setRole(ctx, cfg, args[1:], out)
+	default:
+		fmt.Fprint(out, userUsage)
+		return errUsage
+	}
+}
+
+func createUser(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	email := fs.String("email", "", "email address (required)")
+	password := fs.String("password", "", "password; generated when empty")
+	name := fs.String("name", "", "display name")
+	role := fs.String("role", models.RoleUser, "role: user, coach or admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+	if err := checkRole(*role); err != nil {
+		return err
+	}
+
+	return withApp(cfg, func(a *app.App) error {
+		req := models.CreateUserRequest{Email: *email}
+		if *name != "" {
+			req.Name = name
+		}
+		user, err := a.UserService.Create(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		pw, generated, err := passwordOrGenerate(*password)
+		if err != nil {
+			return err
+		}
+		if err := a.UserService.SetPassword(ctx, user.ID, pw); err != nil {
+			return err
+		}
+		if *role != models.RoleUser {
+			if user, err = a.UserService.Get(ctx, user.ID); err != nil {
+				return err
+			}
+			if _, err := a.UserService.SetRole(ctx, 0, user, *role); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(out, "Created %s %d <%s>\n", *role, user.ID, user.Email)
+		if generated {
+			fmt.Fprintf(out, "Password: %s\n", pw)
+		}
+		return nil
+	})
+}
+
+func disableUser(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("user disable", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withApp(cfg, func(a *app.App) error {
+		user, err := findUser(ctx, a, *email)
+		if err != nil {
+			return err
+		}
+		if err := a.UserService.Disable(ctx, user.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Disabled user %d <%s>\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+func resetPassword(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	password := fs.String("password", "", "new password; generated when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withApp(cfg, func(a *app.App) error {
+		user, err := findUser(ctx, a, *email)
+		if err != nil {
+			return err
+		}
+
+		pw, generated, err := passwordOrGenerate(*password)
+		if err != nil {
+			return err
+		}
+		if err := a.UserService.SetPassword(ctx, user.ID, pw); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Password reset for user %d <%s>\n", user.ID, user.Email)
+		if generated {
+			fmt.Fprintf(out, "Password: %s\n", pw)
+		}
+		return nil
+	})
+}
+
+func setRole(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("user role", flag.ContinueOnError)
+	email := fs.String("email", "", "email address of the user (required)")
+	role := fs.String("role", "", "new role: user, coach or admin (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkRole(*role); err != nil {
+		return err
+	}
+
+	return withApp(cfg, func(a *app.App) error {
+		user, err := findUser(ctx, a, *email)
+		if err != nil {
+			return err
+		}
+		// The CLI acts as no user, so any account's role can be changed
+		if _, err := a.UserService.SetRole(ctx, 0, user, *role); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "User %d <%s> is now %s\n", user.ID, user.Email, *role)
+		return nil
+	})
+}
+
+func checkRole(role string) error {
+	switch role {
+	case models.RoleUser, models.RoleCoach, models.RoleAdmin:
+		return nil
+	default:
+		return fmt.Errorf("-role must be user, coach or admin, got %q", role)
+	}
+}
+
+func findUser(ctx context.Context, a *app.App, email string) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("-email is required")
+	}
+	user, err := a.UserService.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("no user with email %q", email)
+	}
+	return user, err
+}
+
+// passwordOrGenerate returns password, or a random one when it is empty
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		if len(password) < 8 {
+			return "", false, errors.New("password must be at least 8 characters")
+		}
+		return password, false, nil
+	}
+
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), true, nil
+}
@@ -1,24 +1,113 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitbyte/internal/ratelimit"
 )
 
 // Config holds all configuration for our application
 type Config struct {
-	Environment string
-	Port        string
-	DatabaseURL string
-	JWTSecret   string
+	Environment    string
+	Port           string
+	DatabaseURL    string
+	JWTSecret      string
+	JWTExpiry      time.Duration
+	TrustedProxies []string
+
+	// OpenAPIValidation validates requests against the OpenAPI document.
+	// Responses are validated as well in development
+	OpenAPIValidation bool
+
+	// RequireIfMatch rejects updates and deletes without an If-Match header
+	RequireIfMatch bool
+
+	// Deleted users can be restored for UserRestorePeriod and are purged
+	// with their data after UserRetention, checked every PurgeInterval
+	UserRestorePeriod time.Duration
+	UserRetention     time.Duration
+	PurgeInterval     time.Duration
+
+	// Personal data exports
+	ExportDir     string
+	ExportTTL     time.Duration
+	ExportLinkTTL time.Duration
+
+	// Idempotency keys
+	IdempotencyStore string
+	IdempotencyTTL   time.Duration
+
+	// File uploads
+	UploadDir      string
+	UploadMaxBytes int64
+	ImportMaxBytes int64
+	PublicURL      string
+
+	// Rate limiting
+	RateLimitStore   string
+	RedisURL         string
+	RateLimitDefault string
+	RateLimitLogin   string
+	RateLimitUpload  string
+
+	// Login lockout
+	LoginMaxEmailFailures int
+	LoginMaxIPFailures    int
+	LoginFailureWindow    time.Duration
+	LoginLockoutDuration  time.Duration
+	LoginBaseDelay        time.Duration
+	LoginMaxDelay         time.Duration
 }
 
 // Load reads configuration from environment variables
 func Load() *Config {
 	return &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		Port:        getEnv("PORT", "8080"),
-		DatabaseURL: getEnv("DATABASE_URL", ""),
-		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key"),
+		Environment:    getEnv("ENVIRONMENT", "development"),
+		Port:           getEnv("PORT", "8080"),
+		DatabaseURL:    getEnv("DATABASE_URL", ""),
+		JWTSecret:      getEnv("JWT_SECRET", "your-secret-key"),
+		JWTExpiry:      getEnvDuration("JWT_EXPIRY", 2*time.Hour),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		OpenAPIValidation: getEnvBool("OPENAPI_VALIDATION", false),
+		RequireIfMatch:    getEnvBool("REQUIRE_IF_MATCH", false),
+
+		UserRestorePeriod: getEnvDuration("USER_RESTORE_PERIOD", 30*24*time.Hour),
+		UserRetention:     getEnvDuration("USER_RETENTION", 30*24*time.Hour),
+		PurgeInterval:     getEnvDuration("PURGE_INTERVAL", time.Hour),
+
+		ExportDir:     getEnv("EXPORT_DIR", "./exports"),
+		ExportTTL:     getEnvDuration("EXPORT_TTL", 24*time.Hour),
+		ExportLinkTTL: getEnvDuration("EXPORT_LINK_TTL", 15*time.Minute),
+
+		IdempotencyStore: getEnv("IDEMPOTENCY_STORE", "memory"),
+		IdempotencyTTL:   getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		UploadDir:      getEnv("UPLOAD_DIR", "./uploads"),
+		UploadMaxBytes: int64(getEnvInt("UPLOAD_MAX_BYTES", 1<<20)),
+		ImportMaxBytes: int64(getEnvInt("IMPORT_MAX_BYTES", 16<<20)),
+		PublicURL:      getEnv("PUBLIC_URL", "http://localhost:"+getEnv("PORT", "8080")),
+
+		RateLimitStore:   getEnv("RATE_LIMIT_STORE", "memory"),
+		RedisURL:         getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		RateLimitDefault: getEnv("RATE_LIMIT_DEFAULT", "100/1m"),
+		RateLimitLogin:   getEnv("RATE_LIMIT_LOGIN", "5/1m"),
+		RateLimitUpload:  getEnv("RATE_LIMIT_UPLOAD", "10/1m"),
+
+		LoginMaxEmailFailures: getEnvInt("LOGIN_MAX_EMAIL_FAILURES", 5),
+		LoginMaxIPFailures:    getEnvInt("LOGIN_MAX_IP_FAILURES", 20),
+		LoginFailureWindow:    getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:  getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginBaseDelay:        getEnvDuration("LOGIN_BASE_DELAY", 250*time.Millisecond),
+		LoginMaxDelay:         getEnvDuration("LOGIN_MAX_DELAY", 4*time.Second),
 	}
 }
 
@@ -29,3 +118,134 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList gets a comma separated environment variable as a slice
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+// getEnvDuration gets a duration environment variable or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// Validate checks the configuration for values that would fail at runtime
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Environment {
+	case "development", "staging", "production", "test":
+	default:
+		errs = append(errs, fmt.Errorf("ENVIRONMENT must be development, staging, production or test, got %q", c.Environment))
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+	if c.Environment == "production" {
+		if c.JWTSecret == "your-secret-key" || len(c.JWTSecret) < 32 {
+			errs = append(errs, errors.New("JWT_SECRET must be set to at least 32 characters in production"))
+		}
+		if c.DatabaseURL == "" {
+			errs = append(errs, errors.New("DATABASE_URL must be set in production"))
+		}
+	}
+	if c.JWTExpiry <= 0 {
+		errs = append(errs, errors.New("JWT_EXPIRY must be positive"))
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("TRUSTED_PROXIES entry %q is not an IP or CIDR", proxy))
+			}
+		}
+	}
+	for name, spec := range map[string]string{
+		"RATE_LIMIT_DEFAULT": c.RateLimitDefault,
+		"RATE_LIMIT_LOGIN":   c.RateLimitLogin,
+		"RATE_LIMIT_UPLOAD":  c.RateLimitUpload,
+	} {
+		if _, err := ratelimit.ParsePolicy(spec); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if c.DatabaseURL != "" && !strings.HasPrefix(c.DatabaseURL, "postgres://") &&
+		!strings.HasPrefix(c.DatabaseURL, "postgresql://") && !strings.HasPrefix(c.DatabaseURL, "sqlite://") {
+		errs = append(errs, errors.New("DATABASE_URL must start with postgres://, postgresql:// or sqlite://"))
+	}
+	switch c.RateLimitStore {
+	case "memory", "redis":
+	default:
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be memory or redis, got %q", c.RateLimitStore))
+	}
+	switch c.IdempotencyStore {
+	case "memory", "redis":
+	default:
+		errs = append(errs, fmt.Errorf("IDEMPOTENCY_STORE must be memory or redis, got %q", c.IdempotencyStore))
+	}
+	if c.UserRestorePeriod <= 0 || c.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("USER_RESTORE_PERIOD and PURGE_INTERVAL must be positive"))
+	}
+	if c.UserRetention < c.UserRestorePeriod {
+		errs = append(errs, errors.New("USER_RETENTION must not be shorter than USER_RESTORE_PERIOD"))
+	}
+	if c.ExportTTL <= 0 || c.ExportLinkTTL <= 0 {
+		errs = append(errs, errors.New("EXPORT_TTL and EXPORT_LINK_TTL must be positive"))
+	}
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, errors.New("IDEMPOTENCY_TTL must be positive"))
+	}
+	if c.UploadMaxBytes < 1 || c.ImportMaxBytes < 1 {
+		errs = append(errs, errors.New("UPLOAD_MAX_BYTES and IMPORT_MAX_BYTES must be positive"))
+	}
+	if u, err := url.Parse(c.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("PUBLIC_URL must be an absolute URL, got %q", c.PublicURL))
+	}
+	if c.LoginMaxEmailFailures < 1 || c.LoginMaxIPFailures < 1 {
+		errs = append(errs, errors.New("LOGIN_MAX_EMAIL_FAILURES and LOGIN_MAX_IP_FAILURES must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
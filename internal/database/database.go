@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"fitbyte/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryDSN is used when no DATABASE_URL is configured. The database lives
+// as long as the process, which is convenient for development and tests
+const memoryDSN = "file::memory:?cache=shared"
+
+// Open connects to the database identified by url. Supported forms are
+// postgres://..., postgresql://..., sqlite://<path> and an empty string for
+// an in-memory SQLite database
+func Open(url string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch {
+	case url == "":
+		dialector = sqlite.Open(memoryDSN)
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		dialector = postgres.Open(url)
+	case strings.HasPrefix(url, "sqlite://"):
+		dialector = sqlite.Open(strings.TrimPrefix(url, "sqlite://"))
+	default:
+		return nil, fmt.Errorf("unsupported database url %q", url)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if IsMemory(url) {
+		// Every connection to a shared-cache memory database sees the same
+		// data, but a single connection avoids "database is locked" errors
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// IsMemory reports whether url refers to the in-memory database
+func IsMemory(url string) bool {
+	return url == ""
+}
+
+// Migrate creates or updates the schema for all models
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(Models()...); err != nil {
+		return err
+	}
+	// Emails used to be unique across deleted users too, which kept them
+	// from registering again
+	if m := db.Migrator(); m.HasIndex(&models.User{}, "idx_users_email") {
+		return m.DropIndex(&models.User{}, "idx_users_email")
+	}
+	return nil
+}
+
+// Models returns every persisted model, in migration order
+func Models() []interface{} {
+	return []interface{}{
+		&models.User{},
+		&models.Activity{},
+		&models.Goal{},
+		&models.Measurement{},
+		&models.Achievement{},
+		&models.ActivityTrack{},
+		&models.Export{},
+		&models.AuditEntry{},
+	}
+}
+
+// Close closes the underlying connection pool
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,281 @@
+// Package export writes the archive of personal data users can download
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/achievements"
+	"fitbyte/internal/models"
+	"fitbyte/internal/storage"
+	"fitbyte/internal/units"
+)
+
+// Data is everything stored about a user
+type Data struct {
+	User       models.User
+	Activities []models.Activity
+	Goals      []models.Goal
+	// Measurements are newest first
+	Measurements []models.Measurement
+	// Achievements are earliest earned first
+	Achievements []models.Achievement
+	// Tracks are the GPS tracks of the activities that have one
+	Tracks []models.ActivityTrack
+	// Files are the storage keys of the user's uploads
+	Files []string
+}
+
+// Profile is the account as written to the archive
+type Profile struct {
+	models.UserResponse
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// routeFeature is the track of an activity in routes.geojson
+type routeFeature struct {
+	Type       string               `json:"type"`
+	Geometry   models.RouteGeometry `json:"geometry"`
+	Properties struct {
+		ActivityID uint `json:"activityId"`
+		// CoordTimes holds the time of every coordinate, as GPX to GeoJSON
+		// converters write it
+		CoordTimes []time.Time `json:"coordTimes,omitempty"`
+	} `json:"properties"`
+}
+
+// WriteZip writes data to w as a ZIP archive holding profile.json,
+// profile.csv, activities.json, activities.csv, routes.geojson, goals.json,
+// measurements.json, measurements.csv, achievements.json and the uploaded
+// files under files/, read from files. Times are written in the user's time zone
+func WriteZip(ctx context.Context, w io.Writer, data Data, files storage.Storage) error {
+	zw := zip.NewWriter(w)
+	loc := data.User.Location()
+
+	profile := Profile{
+		UserResponse: data.User.ToResponse(),
+		CreatedAt:    data.User.CreatedAt.In(loc),
+		UpdatedAt:    data.User.UpdatedAt.In(loc),
+	}
+	activities := make([]models.ActivityResponse, len(data.Activities))
+	for i := range data.Activities {
+		a := data.Activities[i].ToResponse()
+		a.DoneAt, a.CreatedAt, a.UpdatedAt = a.DoneAt.In(loc), a.CreatedAt.In(loc), a.UpdatedAt.In(loc)
+		activities[i] = a
+	}
+	routes := struct {
+		Type     string         `json:"type"`
+		Features []routeFeature `json:"features"`
+	}{Type: "FeatureCollection", Features: []routeFeature{}}
+	for i := range data.Tracks {
+		points, err := data.Tracks[i].Points()
+		if err != nil {
+			return err
+		}
+		feature := routeFeature{Type: "Feature", Geometry: models.NewRouteGeometry(points)}
+		feature.Properties.ActivityID = data.Tracks[i].ActivityID
+		for _, p := range points {
+			if !p.Time.IsZero() {
+				feature.Properties.CoordTimes = append(feature.Properties.CoordTimes, p.Time.In(loc))
+			}
+		}
+		routes.Features = append(routes.Features, feature)
+	}
+	weightUnit := units.WeightUnit(data.User.Preference)
+	goals := make([]models.GoalResponse, len(data.Goals))
+	for i := range data.Goals {
+		g := data.Goals[i].ToResponse(weightUnit)
+		g.StartsAt, g.CreatedAt = g.StartsAt.In(loc), g.CreatedAt.In(loc)
+		if g.Deadline != nil {
+			deadline := g.Deadline.In(loc)
+			g.Deadline = &deadline
+		}
+		goals[i] = g
+	}
+	measurements := make([]models.MeasurementResponse, len(data.Measurements))
+	for i := range data.Measurements {
+		m := data.Measurements[i].ToResponse(data.User.Preference)
+		m.MeasuredAt, m.CreatedAt = m.MeasuredAt.In(loc), m.CreatedAt.In(loc)
+		measurements[i] = m
+	}
+	badges := []models.AchievementResponse{}
+	for _, a := range data.Achievements {
+		if badge, ok := achievements.Describe(a); ok {
+			badge.EarnedAt = badge.EarnedAt.In(loc)
+			badges = append(badges, badge)
+		}
+	}
+
+	if err := writeJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+	if err := writeCSV(zw, "profile.csv", profileRows(profile)); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "activities.json", activities); err != nil {
+		return err
+	}
+	if err := writeCSV(zw, "activities.csv", activityRows(activities)); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "routes.geojson", routes); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "goals.json", goals); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "measurements.json", measurements); err != nil {
+		return err
+	}
+	if err := writeCSV(zw, "measurements.csv", measurementRows(measurements)); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "achievements.json", badges); err != nil {
+		return err
+	}
+
+	for _, key := range data.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := copyFile(ctx, zw, files, key); err != nil {
+			return fmt.Errorf("add %s: %w", key, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := create(zw, name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSV(zw *zip.Writer, name string, rows [][]string) error {
+	f, err := create(zw, name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func copyFile(ctx context.Context, zw *zip.Writer, files storage.Storage, key string) error {
+	r, err := files.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := create(zw, path.Join("files", path.Base(key)))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func profileRows(p Profile) [][]string {
+	return [][]string{
+		{"field", "value"},
+		{"id", strconv.FormatUint(uint64(p.ID), 10)},
+		{"email", p.Email},
+		{"name", stringValue(p.Name)},
+		{"preference", stringValue(p.Preference)},
+		{"weightUnit", stringValue(p.WeightUnit)},
+		{"heightUnit", stringValue(p.HeightUnit)},
+		{"weight", floatValue(p.Weight)},
+		{"height", floatValue(p.Height)},
+		{"birthDate", stringValue(p.BirthDate)},
+		{"sex", stringValue(p.Sex)},
+		{"timeZone", stringValue(p.TimeZone)},
+		{"locale", stringValue(p.Locale)},
+		{"imageUri", stringValue(p.ImageURI)},
+		{"createdAt", p.CreatedAt.Format(time.RFC3339)},
+		{"updatedAt", p.UpdatedAt.Format(time.RFC3339)},
+	}
+}
+
+func activityRows(activities []models.ActivityResponse) [][]string {
+	rows := [][]string{{"activityId", "activityType", "doneAt", "durationInMinutes", "caloriesBurned", "distanceInMeters", "elevationGainInMeters", "averageHeartRate", "maxHeartRate", "averagePower", "maxPower", "hasRoute", "createdAt", "updatedAt"}}
+	for _, a := range activities {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(a.ID), 10),
+			a.ActivityType,
+			a.DoneAt.Format(time.RFC3339),
+			strconv.Itoa(a.DurationInMinutes),
+			strconv.Itoa(a.CaloriesBurned),
+			floatValue(a.DistanceInMeters),
+			floatValue(a.ElevationGainInMeters),
+			intValue(a.AverageHeartRate),
+			intValue(a.MaxHeartRate),
+			intValue(a.AveragePower),
+			intValue(a.MaxPower),
+			strconv.FormatBool(a.HasRoute),
+			a.CreatedAt.Format(time.RFC3339),
+			a.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return rows
+}
+
+func measurementRows(measurements []models.MeasurementResponse) [][]string {
+	rows := [][]string{{"measurementId", "measuredAt", "weight", "weightUnit", "bodyFat", "waist", "hips", "chest", "lengthUnit"}}
+	for _, m := range measurements {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(m.ID), 10),
+			m.MeasuredAt.Format(time.RFC3339),
+			floatValue(m.Weight),
+			m.WeightUnit,
+			floatValue(m.BodyFat),
+			floatValue(m.Waist),
+			floatValue(m.Hips),
+			floatValue(m.Chest),
+			m.LengthUnit,
+		})
+	}
+	return rows
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func intValue(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func floatValue(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// create adds a compressed entry stamped with the current time, so the
+// archive shows when it was exported instead of the ZIP epoch
+func create(zw *zip.Writer, name string) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+}
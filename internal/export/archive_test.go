@@ -0,0 +1,183 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/geo"
+	"fitbyte/internal/models"
+	"fitbyte/internal/storage"
+)
+
+// readZip returns the contents of the entries of a ZIP archive by name
+func readZip(t *testing.T, archive []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name], err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return entries
+}
+
+func TestWriteZip(t *testing.T) {
+	ctx := context.Background()
+	files, err := storage.NewLocalStorage(filepath.Join(t.TempDir(), "uploads"), "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := storage.UserPrefix(1) + "/avatar.png"
+	if _, err := files.Save(ctx, key, strings.NewReader("png bytes")); err != nil {
+		t.Fatal(err)
+	}
+
+	zone, name := "Asia/Jakarta", "Ayu"
+	doneAt := time.Date(2024, time.July, 1, 23, 30, 0, 0, time.UTC)
+	distance, weight := 5000.0, 60.5
+	start := geo.Point{Lat: -6.2, Lon: 106.8, Time: doneAt}
+	end := geo.Point{Lat: -6.21, Lon: 106.81, Time: doneAt.Add(time.Minute)}
+	track := models.NewActivityTrack([]geo.Point{start, end})
+	track.ActivityID = 3
+	data := Data{
+		User:         models.User{ID: 1, Email: "ayu@example.com", Name: &name, TimeZone: &zone, CreatedAt: doneAt, UpdatedAt: doneAt},
+		Activities:   []models.Activity{{ID: 3, UserID: 1, ActivityType: models.ActivityRunning, DoneAt: doneAt, DurationInMinutes: 30, CaloriesBurned: 300, DistanceInMeters: &distance, HasRoute: true}},
+		Goals:        []models.Goal{{ID: 4, UserID: 1, Type: "weekly_calories", Target: 2000, StartsAt: doneAt}},
+		Measurements: []models.Measurement{{ID: 5, UserID: 1, MeasuredAt: doneAt, Weight: &weight}},
+		Achievements: []models.Achievement{{UserID: 1, Code: "first_workout", EarnedAt: doneAt}, {UserID: 1, Code: "retired_badge", EarnedAt: doneAt}},
+		Tracks:       []models.ActivityTrack{track},
+		Files:        []string{key},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZip(ctx, &buf, data, files); err != nil {
+		t.Fatal(err)
+	}
+	entries := readZip(t, buf.Bytes())
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"achievements.json", "activities.csv", "activities.json", "files/avatar.png", "goals.json",
+		"measurements.csv", "measurements.json", "profile.csv", "profile.json", "routes.geojson"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	if got := string(entries["files/avatar.png"]); got != "png bytes" {
+		t.Errorf("files/avatar.png = %q, want the uploaded file", got)
+	}
+
+	// Times are written in the user's zone, seven hours ahead of UTC
+	const local = "2024-07-02T06:30:00+07:00"
+
+	t.Run("json", func(t *testing.T) {
+		tests := []struct {
+			name string
+			v    interface{}
+			ok   func(v interface{}) bool
+		}{
+			{"profile.json", &Profile{}, func(v interface{}) bool {
+				p := v.(*Profile)
+				return p.Email == "ayu@example.com" && *p.Name == name && p.CreatedAt.Format(time.RFC3339) == local
+			}},
+			{"activities.json", &[]models.ActivityResponse{}, func(v interface{}) bool {
+				a := *v.(*[]models.ActivityResponse)
+				return len(a) == 1 && a[0].ID == 3 && a[0].DoneAt.Format(time.RFC3339) == local
+			}},
+			{"goals.json", &[]models.GoalResponse{}, func(v interface{}) bool {
+				g := *v.(*[]models.GoalResponse)
+				return len(g) == 1 && g[0].ID == 4
+			}},
+			{"measurements.json", &[]models.MeasurementResponse{}, func(v interface{}) bool {
+				m := *v.(*[]models.MeasurementResponse)
+				return len(m) == 1 && *m[0].Weight == weight
+			}},
+			// Badges whose rule no longer exists are left out
+			{"achievements.json", &[]models.AchievementResponse{}, func(v interface{}) bool {
+				a := *v.(*[]models.AchievementResponse)
+				return len(a) == 1 && a[0].Code == "first_workout" && a[0].EarnedAt.Format(time.RFC3339) == local
+			}},
+			{"routes.geojson", &routeCollection{}, func(v interface{}) bool {
+				r := v.(*routeCollection)
+				return r.Type == "FeatureCollection" && len(r.Features) == 1 && r.Features[0].Properties.ActivityID == 3 &&
+					len(r.Features[0].Geometry.Coordinates) == 2 && len(r.Features[0].Properties.CoordTimes) == 2
+			}},
+		}
+		for _, tt := range tests {
+			if err := json.Unmarshal(entries[tt.name], tt.v); err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if !tt.ok(tt.v) {
+				t.Errorf("%s = %s", tt.name, entries[tt.name])
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		tests := []struct {
+			name string
+			rows int
+			// cell is a column of the header and its value in the first row
+			cell [2]string
+		}{
+			{"profile.csv", 16, [2]string{"field", "id"}},
+			{"activities.csv", 2, [2]string{"doneAt", local}},
+			{"activities.csv", 2, [2]string{"distanceInMeters", "5000"}},
+			{"measurements.csv", 2, [2]string{"weight", "60.5"}},
+		}
+		for _, tt := range tests {
+			rows, err := csv.NewReader(bytes.NewReader(entries[tt.name])).ReadAll()
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if len(rows) != tt.rows {
+				t.Errorf("%s has %d rows, want %d", tt.name, len(rows), tt.rows)
+				continue
+			}
+			column := slices.Index(rows[0], tt.cell[0])
+			if column < 0 || rows[1][column] != tt.cell[1] {
+				t.Errorf("%s %s = %v, want %s", tt.name, tt.cell[0], rows[1], tt.cell[1])
+			}
+		}
+	})
+}
+
+// routeCollection is routes.geojson as read back
+type routeCollection struct {
+	Type     string         `json:"type"`
+	Features []routeFeature `json:"features"`
+}
+
+func TestWriteZipMissingFile(t *testing.T) {
+	files, err := storage.NewLocalStorage(t.TempDir(), "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Data{User: models.User{ID: 1}, Files: []string{storage.UserPrefix(1) + "/gone.png"}}
+	if err := WriteZip(context.Background(), io.Discard, data, files); err == nil {
+		t.Error("WriteZip succeeded with a file missing from storage")
+	}
+}
@@ -0,0 +1,419 @@
+// Package fit decodes files in the Garmin Flexible and Interoperable Data
+// Transfer (FIT) protocol: the file header, definition messages and the data
+// messages they describe, checked against the file's CRC. Decoding yields
+// the raw data messages; what their fields mean is left to the caller
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalid is returned for data that is not a well formed FIT file
+var ErrInvalid = errors.New("invalid FIT file")
+
+// Global message numbers of the messages describing an activity
+const (
+	MessageFileID  = 0
+	MessageSession = 18
+	MessageLap     = 19
+	MessageRecord  = 20
+)
+
+// FieldTimestamp is the field number of the timestamp every message can have
+const FieldTimestamp = 253
+
+// maxMessages bounds how many data messages Decode returns, about twelve
+// days of one second records
+const maxMessages = 1 << 20
+
+// minMessageBytes is how many bytes of the file each data message Decode
+// returns takes up on average at least. Real records are larger, and the
+// bound keeps a small file of tiny messages from taking up a lot of memory
+const minMessageBytes = 4
+
+// fieldChunk is how many fields the decoder allocates at once for the
+// messages it returns
+const fieldChunk = 4096
+
+// epoch is the zero of FIT timestamps
+var epoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// Field is a field of a data message
+type Field struct {
+	Num   byte
+	Value float64
+}
+
+// Message is a decoded data message. Only numeric fields holding a single
+// valid value are kept; strings, arrays and fields set to their base type's
+// invalid value are left out
+type Message struct {
+	// Num is the global message number
+	Num    uint16
+	Fields []Field
+}
+
+// Value returns the value of a field and whether the message has it
+func (m Message) Value(field byte) (float64, bool) {
+	for _, f := range m.Fields {
+		if f.Num == field {
+			return f.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Set sets the value of a field, adding it if the message does not have it
+func (m *Message) Set(field byte, v float64) {
+	for i := range m.Fields {
+		if m.Fields[i].Num == field {
+			m.Fields[i].Value = v
+			return
+		}
+	}
+	m.Fields = append(m.Fields, Field{Num: field, Value: v})
+}
+
+// Time returns a timestamp field as a time and whether the message has it
+func (m Message) Time(field byte) (time.Time, bool) {
+	v, ok := m.Value(field)
+	if !ok {
+		return time.Time{}, false
+	}
+	return epoch.Add(time.Duration(v) * time.Second), true
+}
+
+// IsFIT reports whether data starts with a FIT file header
+func IsFIT(data []byte) bool {
+	return len(data) >= 12 && (data[0] == 12 || data[0] == 14) && string(data[8:12]) == ".FIT"
+}
+
+// Decode decodes the data messages of a FIT file, or of several FIT files
+// chained one after another. Messages without any valid field are left out
+func Decode(data []byte) ([]Message, error) {
+	limit := min(maxMessages, len(data)/minMessageBytes)
+	var messages []Message
+	for len(data) > 0 {
+		n, decoded, err := decodeFile(data, limit-len(messages))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, decoded...)
+		data = data[n:]
+	}
+	return messages, nil
+}
+
+// decodeFile decodes up to limit messages of the FIT file at the start of
+// data and returns its length
+func decodeFile(data []byte, limit int) (int, []Message, error) {
+	if !IsFIT(data) {
+		return 0, nil, fmt.Errorf("%w: missing file header", ErrInvalid)
+	}
+	headerSize := int(data[0])
+	if len(data) < headerSize {
+		return 0, nil, fmt.Errorf("%w: truncated file header", ErrInvalid)
+	}
+	if headerSize == 14 {
+		if sum := binary.LittleEndian.Uint16(data[12:14]); sum != 0 && sum != crc(data[:12]) {
+			return 0, nil, fmt.Errorf("%w: header CRC mismatch", ErrInvalid)
+		}
+	}
+
+	size := int64(binary.LittleEndian.Uint32(data[4:8]))
+	end := int64(headerSize) + size
+	if end+2 > int64(len(data)) {
+		return 0, nil, fmt.Errorf("%w: truncated file", ErrInvalid)
+	}
+	if crc(data[:end]) != binary.LittleEndian.Uint16(data[end:end+2]) {
+		return 0, nil, fmt.Errorf("%w: CRC mismatch", ErrInvalid)
+	}
+
+	d := decoder{data: data[headerSize:end], limit: limit}
+	messages, err := d.decode()
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(end) + 2, messages, nil
+}
+
+// field describes one field of a definition message
+type field struct {
+	num      byte
+	size     int
+	baseType byte
+}
+
+// definition describes the data messages of one local message type
+type definition struct {
+	num       uint16
+	bigEndian bool
+	fields    []field
+	// devSize is the total size of the developer fields, which are skipped
+	devSize int
+}
+
+// decoder walks the records of one file
+type decoder struct {
+	data []byte
+	pos  int
+	defs [16]*definition
+	// timestamp is the last timestamp seen, the base of compressed ones
+	timestamp uint32
+	limit     int
+	// fields is the chunk the fields of the next messages are taken from
+	fields []Field
+}
+
+func (d *decoder) decode() ([]Message, error) {
+	var messages []Message
+	for d.pos < len(d.data) {
+		header := d.data[d.pos]
+		d.pos++
+
+		switch {
+		case header&0x80 != 0:
+			// Compressed timestamp header: a data message whose timestamp is
+			// given as an offset in the low 5 bits
+			offset := uint32(header & 0x1f)
+			ts := d.timestamp&^0x1f | offset
+			if offset < d.timestamp&0x1f {
+				ts += 0x20
+			}
+			d.timestamp = ts
+			msg, err := d.message(int(header>>5&0x3), true, ts)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, msg)
+		case header&0x40 != 0:
+			if err := d.definition(int(header&0xf), header&0x20 != 0); err != nil {
+				return nil, err
+			}
+			continue
+		default:
+			msg, err := d.message(int(header&0xf), false, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(msg.Fields) > 0 {
+				messages = append(messages, msg)
+			}
+		}
+		if len(messages) > d.limit {
+			return nil, fmt.Errorf("%w: too many messages for a file of its size", ErrInvalid)
+		}
+	}
+	return messages, nil
+}
+
+// take returns the next n bytes
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, fmt.Errorf("%w: record runs past the end of the file", ErrInvalid)
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) definition(local int, developer bool) error {
+	b, err := d.take(5)
+	if err != nil {
+		return err
+	}
+	def := &definition{bigEndian: b[1] == 1}
+	if def.bigEndian {
+		def.num = binary.BigEndian.Uint16(b[2:4])
+	} else {
+		def.num = binary.LittleEndian.Uint16(b[2:4])
+	}
+
+	fields, err := d.take(3 * int(b[4]))
+	if err != nil {
+		return err
+	}
+	def.fields = make([]field, 0, b[4])
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, field{num: fields[i], size: int(fields[i+1]), baseType: fields[i+2]})
+	}
+
+	if developer {
+		n, err := d.take(1)
+		if err != nil {
+			return err
+		}
+		devFields, err := d.take(3 * int(n[0]))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devSize += int(devFields[i+1])
+		}
+	}
+
+	d.defs[local] = def
+	return nil
+}
+
+// message decodes a data message of a local type. A message with a
+// compressed timestamp header is given its timestamp ts
+func (d *decoder) message(local int, compressed bool, ts uint32) (Message, error) {
+	def := d.defs[local]
+	if def == nil {
+		return Message{}, fmt.Errorf("%w: data message of undefined local type %d", ErrInvalid, local)
+	}
+
+	// The fields are taken from a chunk shared with the other messages, and
+	// what they do not use is handed back
+	if cap(d.fields)-len(d.fields) < len(def.fields)+1 {
+		d.fields = make([]Field, 0, max(fieldChunk, len(def.fields)+1))
+	}
+	start := len(d.fields)
+	for _, f := range def.fields {
+		b, err := d.take(f.size)
+		if err != nil {
+			return Message{}, err
+		}
+		if v, ok := value(b, f.baseType, def.bigEndian); ok {
+			d.fields = append(d.fields, Field{Num: f.num, Value: v})
+		}
+	}
+	if _, err := d.take(def.devSize); err != nil {
+		return Message{}, err
+	}
+
+	if compressed {
+		i := start
+		for i < len(d.fields) && d.fields[i].Num != FieldTimestamp {
+			i++
+		}
+		if i == len(d.fields) {
+			d.fields = append(d.fields, Field{Num: FieldTimestamp})
+		}
+		d.fields[i].Value = float64(ts)
+	}
+
+	msg := Message{Num: def.num, Fields: d.fields[start:len(d.fields):len(d.fields)]}
+	if v, ok := msg.Value(FieldTimestamp); ok && !compressed {
+		d.timestamp = uint32(v)
+	}
+	return msg, nil
+}
+
+// value decodes a field holding a single number of baseType, reporting
+// false for other fields and invalid values
+func value(b []byte, baseType byte, bigEndian bool) (float64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	switch baseType & 0x1f {
+	case 0x00, 0x02: // enum, uint8
+		if len(b) == 1 && b[0] != 0xff {
+			return float64(b[0]), true
+		}
+	case 0x01: // sint8
+		if len(b) == 1 && b[0] != 0x7f {
+			return float64(int8(b[0])), true
+		}
+	case 0x0a: // uint8z
+		if len(b) == 1 && b[0] != 0 {
+			return float64(b[0]), true
+		}
+	case 0x03: // sint16
+		if len(b) == 2 {
+			if v := int16(order.Uint16(b)); v != math.MaxInt16 {
+				return float64(v), true
+			}
+		}
+	case 0x04: // uint16
+		if len(b) == 2 {
+			if v := order.Uint16(b); v != math.MaxUint16 {
+				return float64(v), true
+			}
+		}
+	case 0x0b: // uint16z
+		if len(b) == 2 {
+			if v := order.Uint16(b); v != 0 {
+				return float64(v), true
+			}
+		}
+	case 0x05: // sint32
+		if len(b) == 4 {
+			if v := int32(order.Uint32(b)); v != math.MaxInt32 {
+				return float64(v), true
+			}
+		}
+	case 0x06: // uint32
+		if len(b) == 4 {
+			if v := order.Uint32(b); v != math.MaxUint32 {
+				return float64(v), true
+			}
+		}
+	case 0x0c: // uint32z
+		if len(b) == 4 {
+			if v := order.Uint32(b); v != 0 {
+				return float64(v), true
+			}
+		}
+	case 0x08: // float32
+		if len(b) == 4 {
+			if bits := order.Uint32(b); bits != math.MaxUint32 {
+				if v := float64(math.Float32frombits(bits)); !math.IsNaN(v) && !math.IsInf(v, 0) {
+					return v, true
+				}
+			}
+		}
+	case 0x09: // float64
+		if len(b) == 8 {
+			if bits := order.Uint64(b); bits != math.MaxUint64 {
+				if v := math.Float64frombits(bits); !math.IsNaN(v) && !math.IsInf(v, 0) {
+					return v, true
+				}
+			}
+		}
+	case 0x0e: // sint64
+		if len(b) == 8 {
+			if v := int64(order.Uint64(b)); v != math.MaxInt64 {
+				return float64(v), true
+			}
+		}
+	case 0x0f: // uint64
+		if len(b) == 8 {
+			if v := order.Uint64(b); v != math.MaxUint64 {
+				return float64(v), true
+			}
+		}
+	case 0x10: // uint64z
+		if len(b) == 8 {
+			if v := order.Uint64(b); v != 0 {
+				return float64(v), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// crcTable is the nibble table of the FIT CRC-16
+var crcTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// crc returns the FIT CRC-16 of data
+func crc(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data {
+		tmp := crcTable[sum&0xf]
+		sum = sum>>4&0x0fff ^ tmp ^ crcTable[b&0xf]
+		tmp = crcTable[sum&0xf]
+		sum = sum>>4&0x0fff ^ tmp ^ crcTable[b>>4&0xf]
+	}
+	return sum
+}
@@ -0,0 +1,245 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// builder writes FIT files for tests
+type builder struct {
+	records []byte
+}
+
+// define writes a little endian definition message of fields given as
+// number, size and base type triples
+func (b *builder) define(local byte, num uint16, fields ...byte) {
+	b.records = append(b.records, 0x40|local, 0, 0)
+	b.records = binary.LittleEndian.AppendUint16(b.records, num)
+	b.records = append(b.records, byte(len(fields)/3))
+	b.records = append(b.records, fields...)
+}
+
+// data writes a data message with a normal header
+func (b *builder) data(local byte, values ...byte) {
+	b.records = append(b.records, local)
+	b.records = append(b.records, values...)
+}
+
+// file returns the records wrapped in a 14 byte header and the file CRC
+func (b *builder) file() []byte {
+	f := []byte{14, 0x20, 0x08, 0x08}
+	f = binary.LittleEndian.AppendUint32(f, uint32(len(b.records)))
+	f = append(f, ".FIT"...)
+	f = binary.LittleEndian.AppendUint16(f, crc(f))
+	f = append(f, b.records...)
+	return binary.LittleEndian.AppendUint16(f, crc(f))
+}
+
+func u16(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+func join(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// sample is a file with a record message, a compressed timestamp record
+// and a big endian session message with developer fields
+func sample() []byte {
+	var b builder
+	// record: timestamp, heart rate, power
+	b.define(0, MessageRecord, FieldTimestamp, 4, 0x86, 3, 1, 0x02, 7, 2, 0x84)
+	b.data(0, join(u32(1000000000), []byte{150}, u16(250))...)
+	// Compressed timestamp 1000000000 is 0x3B9ACA00; an offset of 5 moves it
+	// on five seconds
+	b.define(1, MessageRecord, 3, 1, 0x02, 7, 2, 0x84)
+	b.records = append(b.records, 0x80|1<<5|5, 155, 0xff, 0xff)
+
+	b.records = append(b.records, 0x40|0x20|2, 0, 1)
+	b.records = binary.BigEndian.AppendUint16(b.records, MessageSession)
+	b.records = append(b.records, 2, 2, 4, 0x86, 9, 4, 0x86)
+	b.records = append(b.records, 1, 0, 3, 0)
+	b.data(2, join(binary.BigEndian.AppendUint32(nil, 999999990), binary.BigEndian.AppendUint32(nil, 500000), []byte{1, 2, 3})...)
+	return b.file()
+}
+
+// tinyMessages is a file of n one byte data messages: compressed timestamp
+// headers of a local type without fields
+func tinyMessages(n int) []byte {
+	var b builder
+	b.define(0, MessageRecord)
+	b.records = append(b.records, bytes.Repeat([]byte{0x80}, n)...)
+	return b.file()
+}
+
+// records is a file of n record messages holding a timestamp and a heart
+// rate
+func records(n int) []byte {
+	var b builder
+	b.define(0, MessageRecord, FieldTimestamp, 4, 0x86, 3, 1, 0x02)
+	for i := range n {
+		b.data(0, append(u32(uint32(1000000000+i)), 150)...)
+	}
+	return b.file()
+}
+
+func TestDecode(t *testing.T) {
+	messages, err := Decode(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+
+	first, second, session := messages[0], messages[1], messages[2]
+	if hr, _ := first.Value(3); hr != 150 {
+		t.Errorf("heart rate = %v, want 150", hr)
+	}
+	if power, _ := first.Value(7); power != 250 {
+		t.Errorf("power = %v, want 250", power)
+	}
+	if ts, _ := first.Time(FieldTimestamp); !ts.Equal(time.Date(2021, time.September, 8, 1, 46, 40, 0, time.UTC)) {
+		t.Errorf("timestamp = %v", ts)
+	}
+
+	if ts, _ := second.Value(FieldTimestamp); ts != 1000000005 {
+		t.Errorf("compressed timestamp = %v, want 1000000005", ts)
+	}
+	if _, ok := second.Value(7); ok {
+		t.Error("invalid power value was kept")
+	}
+
+	if session.Num != MessageSession {
+		t.Errorf("message number = %d, want %d", session.Num, MessageSession)
+	}
+	if start, _ := session.Value(2); start != 999999990 {
+		t.Errorf("big endian start time = %v, want 999999990", start)
+	}
+	if distance, _ := session.Value(9); distance != 500000 {
+		t.Errorf("big endian distance = %v, want 500000", distance)
+	}
+}
+
+func TestDecodeChained(t *testing.T) {
+	f := sample()
+	messages, err := Decode(append(append([]byte{}, f...), f...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 6 {
+		t.Errorf("got %d messages, want 6", len(messages))
+	}
+}
+
+func TestDecodeCompressedRollover(t *testing.T) {
+	var b builder
+	b.define(0, MessageRecord, FieldTimestamp, 4, 0x86)
+	b.data(0, u32(0x3e)...)
+	b.define(1, MessageRecord, 3, 1, 0x02)
+	// 0x3e has 0x1e in the low bits, so an offset of 2 rolls over to 0x42
+	b.records = append(b.records, 0x80|1<<5|2, 100)
+
+	messages, err := Decode(b.file())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _ := messages[1].Value(FieldTimestamp); ts != 0x42 {
+		t.Errorf("timestamp = %#x, want 0x42", int(ts))
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := sample()
+	corrupt := append([]byte{}, valid...)
+	corrupt[20] ^= 0xff
+
+	var undefined builder
+	undefined.data(3, 1, 2, 3)
+	var short builder
+	short.define(0, MessageRecord, 3, 4, 0x86)
+	short.data(0, 1, 2)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty header", []byte{14}},
+		{"not FIT", []byte("<gpx></gpx> and some more bytes")},
+		{"truncated", valid[:len(valid)-5]},
+		{"corrupt", corrupt},
+		{"trailing bytes", append(append([]byte{}, valid...), 0)},
+		{"undefined local type", undefined.file()},
+		{"short data message", short.file()},
+		{"too many messages for its size", tinyMessages(1 << 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestDecodeAllocations(t *testing.T) {
+	data := records(10000)
+	messages, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 10000 {
+		t.Fatalf("got %d messages, want 10000", len(messages))
+	}
+	if ts, _ := messages[9999].Value(FieldTimestamp); ts != 1000009999 {
+		t.Errorf("last timestamp = %v, want 1000009999", ts)
+	}
+
+	// The fields of all messages share a few chunks, rather than each message
+	// allocating its own
+	allocs := testing.AllocsPerRun(10, func() { Decode(data) })
+	if allocs > 100 {
+		t.Errorf("decoding 10000 messages took %v allocations", allocs)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add(sample())
+	f.Add(sample()[:30])
+	f.Add([]byte{12, 0x10, 0, 0, 0, 0, 0, 0, '.', 'F', 'I', 'T', 0, 0})
+	f.Add(tinyMessages(1 << 20))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		messages, err := Decode(data)
+		if err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode error = %v, want ErrInvalid", err)
+			}
+			return
+		}
+		for _, m := range messages {
+			if len(m.Fields) == 0 {
+				t.Error("message without fields")
+			}
+		}
+	})
+}
+
+// FuzzDecodeRecords wraps the input in a valid header and CRC, so that the
+// records themselves are fuzzed rather than the checksum
+func FuzzDecodeRecords(f *testing.F) {
+	s := sample()
+	f.Add(s[14 : len(s)-2])
+	f.Add([]byte{0x40, 0, 0, 20, 0, 1, 3, 1, 2, 0, 150, 0x80, 160})
+	f.Fuzz(func(t *testing.T, records []byte) {
+		b := builder{records: records}
+		if _, err := Decode(b.file()); err != nil && !errors.Is(err, ErrInvalid) {
+			t.Errorf("Decode error = %v, want ErrInvalid", err)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+// Package geo measures GPS tracks. Coordinates are WGS 84 degrees;
+// elevations and distances are in meters
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// elevationNoise is the smallest change in elevation ElevationGain counts,
+// so that GPS and barometer jitter does not add up to a climb
+const elevationNoise = 3.0
+
+// Point is a recorded position
+type Point struct {
+	Lat float64
+	Lon float64
+	// Elevation is nil when the point has none
+	Elevation *float64
+	// Time is zero when the point has none
+	Time time.Time
+}
+
+// Haversine returns the great-circle distance between a and b
+func Haversine(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Distance returns the length of the track through points
+func Distance(points []Point) float64 {
+	var d float64
+	for i := 1; i < len(points); i++ {
+		d += Haversine(points[i-1], points[i])
+	}
+	return d
+}
+
+// ElevationGain sums the climbs in a series of elevations. Changes smaller
+// than a few meters are ignored until they add up
+func ElevationGain(elevations []float64) float64 {
+	if len(elevations) == 0 {
+		return 0
+	}
+	var gain float64
+	ref := elevations[0]
+	for _, e := range elevations[1:] {
+		switch {
+		case e-ref >= elevationNoise:
+			gain += e - ref
+			ref = e
+		case ref-e >= elevationNoise:
+			ref = e
+		}
+	}
+	return gain
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
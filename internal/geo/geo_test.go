@@ -0,0 +1,150 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+		tol  float64
+	}{
+		{"same point", Point{Lat: 52.52, Lon: 13.405}, Point{Lat: 52.52, Lon: 13.405}, 0, 0.001},
+		{"one degree of latitude", Point{Lat: 0, Lon: 0}, Point{Lat: 1, Lon: 0}, 111195, 1},
+		{"Berlin to Paris", Point{Lat: 52.5200, Lon: 13.4050}, Point{Lat: 48.8566, Lon: 2.3522}, 877460, 500},
+		{"across the antimeridian", Point{Lat: 0, Lon: 179.5}, Point{Lat: 0, Lon: -179.5}, 111195, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Haversine(tt.a, tt.b); math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("Haversine = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	points := []Point{{Lat: 0, Lon: 0}, {Lat: 0.5, Lon: 0}, {Lat: 1, Lon: 0}}
+	if got := Distance(points); math.Abs(got-111195) > 1 {
+		t.Errorf("Distance = %v, want 111195", got)
+	}
+	if got := Distance(points[:1]); got != 0 {
+		t.Errorf("Distance of one point = %v, want 0", got)
+	}
+}
+
+func TestElevationGain(t *testing.T) {
+	tests := []struct {
+		name       string
+		elevations []float64
+		want       float64
+	}{
+		{"none", nil, 0},
+		{"steady climb", []float64{100, 110, 120, 130}, 30},
+		{"climb and descent", []float64{100, 150, 100, 120}, 70},
+		{"jitter", []float64{100, 101, 100, 102, 100, 101}, 0},
+		{"slow climb", []float64{100, 101, 102, 103, 104}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ElevationGain(tt.elevations); got != tt.want {
+				t.Errorf("ElevationGain = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolyline(t *testing.T) {
+	// The example of the Encoded Polyline Algorithm Format documentation
+	points := []Point{{Lat: 38.5, Lon: -120.2}, {Lat: 40.7, Lon: -120.95}, {Lat: 43.252, Lon: -126.453}}
+	const want = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+	if got := EncodePolyline(points); got != want {
+		t.Errorf("EncodePolyline = %q, want %q", got, want)
+	}
+	decoded, err := DecodePolyline(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(decoded, points) {
+		t.Errorf("DecodePolyline = %v, want %v", decoded, points)
+	}
+
+	for _, s := range []string{"_p~iF~ps|U_ulL", "_p~iF~ps|U_", "abc\x00"} {
+		if _, err := DecodePolyline(s); !errors.Is(err, ErrInvalidPolyline) {
+			t.Errorf("DecodePolyline(%q) error = %v, want ErrInvalidPolyline", s, err)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	values := []int64{1728720000, 1728720001, 1728720010, 0, -15, math.MaxInt32 * 4}
+	got, err := DecodeSeries(EncodeSeries(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, values) {
+		t.Errorf("DecodeSeries = %v, want %v", got, values)
+	}
+	if got, err := DecodeSeries(""); err != nil || len(got) != 0 {
+		t.Errorf("DecodeSeries of empty string = %v, %v", got, err)
+	}
+}
+
+// along returns a point meters east of the origin on the equator
+func along(meters float64, seconds int, elevation float64) Point {
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	return Point{Lon: meters / (earthRadius * math.Pi / 180), Elevation: &elevation, Time: start.Add(time.Duration(seconds) * time.Second)}
+}
+
+func TestSplits(t *testing.T) {
+	// A steady 5 minutes per kilometer
+	points := []Point{along(0, 0, 100), along(1500, 450, 130), along(2500, 750, 110)}
+	want := []struct {
+		distance float64
+		duration time.Duration
+		change   float64
+	}{
+		{1000, 300 * time.Second, 20},
+		{1000, 300 * time.Second, 0},
+		{500, 150 * time.Second, -10},
+	}
+
+	splits := Splits(points, 1000)
+	if len(splits) != len(want) {
+		t.Fatalf("got %d splits, want %d", len(splits), len(want))
+	}
+	for i, s := range splits {
+		w := want[i]
+		if math.Abs(s.Distance-w.distance) > 0.01 || (s.Duration-w.duration).Abs() > time.Millisecond ||
+			s.ElevationChange == nil || math.Abs(*s.ElevationChange-w.change) > 0.01 {
+			t.Errorf("split %d = %v, %v, %v; want %v, %v, %v", i+1, s.Distance, s.Duration, s.ElevationChange, w.distance, w.duration, w.change)
+		}
+	}
+
+	untimed := []Point{points[0], {Lat: 0, Lon: 0.01}}
+	if got := Splits(untimed, 1000); got != nil {
+		t.Errorf("Splits of untimed track = %v, want nil", got)
+	}
+}
+
+func TestElevationProfile(t *testing.T) {
+	var points []Point
+	for i := range 101 {
+		points = append(points, along(float64(i*10), i, float64(i)))
+	}
+	points = append(points, Point{Lon: points[100].Lon + 0.001})
+
+	if got := ElevationProfile(points, 1000); len(got) != 101 {
+		t.Errorf("got %d profile points, want 101", len(got))
+	}
+	got := ElevationProfile(points, 11)
+	if len(got) != 11 || got[0].Elevation != 0 || got[10].Elevation != 100 || math.Abs(got[5].Distance-500) > 0.01 {
+		t.Errorf("ElevationProfile = %v, want every tenth point", got)
+	}
+}
@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// ErrInvalidPolyline is returned for strings that are not encoded polylines
+var ErrInvalidPolyline = errors.New("invalid polyline")
+
+// polylinePrecision scales degrees to the integers a polyline holds: five
+// decimal places, about a meter
+const polylinePrecision = 1e5
+
+// EncodePolyline encodes the positions of points in the Encoded Polyline
+// Algorithm Format used by most map libraries. Elevations and times are
+// left out; EncodeSeries stores them alongside
+func EncodePolyline(points []Point) string {
+	var b strings.Builder
+	var lat, lon int64
+	for _, p := range points {
+		nextLat := int64(math.Round(p.Lat * polylinePrecision))
+		nextLon := int64(math.Round(p.Lon * polylinePrecision))
+		writeValue(&b, nextLat-lat)
+		writeValue(&b, nextLon-lon)
+		lat, lon = nextLat, nextLon
+	}
+	return b.String()
+}
+
+// DecodePolyline decodes the positions of an encoded polyline
+func DecodePolyline(s string) ([]Point, error) {
+	deltas, err := decodeDeltas(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(deltas)%2 != 0 {
+		return nil, ErrInvalidPolyline
+	}
+	points := make([]Point, len(deltas)/2)
+	var lat, lon int64
+	for i := range points {
+		lat += deltas[2*i]
+		lon += deltas[2*i+1]
+		points[i] = Point{Lat: float64(lat) / polylinePrecision, Lon: float64(lon) / polylinePrecision}
+	}
+	return points, nil
+}
+
+// EncodeSeries encodes a series of integers the way a polyline encodes
+// coordinates: each value as the difference from the one before, so that
+// slowly changing series such as elevations and timestamps stay short
+func EncodeSeries(values []int64) string {
+	var b strings.Builder
+	var prev int64
+	for _, v := range values {
+		writeValue(&b, v-prev)
+		prev = v
+	}
+	return b.String()
+}
+
+// DecodeSeries decodes a series written by EncodeSeries
+func DecodeSeries(s string) ([]int64, error) {
+	values, err := decodeDeltas(s)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(values); i++ {
+		values[i] += values[i-1]
+	}
+	return values, nil
+}
+
+// decodeDeltas decodes the zigzag encoded values in s
+func decodeDeltas(s string) ([]int64, error) {
+	var values []int64
+	for i := 0; i < len(s); {
+		var u uint64
+		var shift uint
+		for {
+			if i >= len(s) || shift > 63 {
+				return nil, ErrInvalidPolyline
+			}
+			c := int64(s[i]) - 63
+			i++
+			if c < 0 || c > 63 {
+				return nil, ErrInvalidPolyline
+			}
+			u |= uint64(c&0x1f) << shift
+			shift += 5
+			if c < 0x20 {
+				break
+			}
+		}
+		values = append(values, int64(u>>1)^-int64(u&1))
+	}
+	return values, nil
+}
+
+// writeValue writes one zigzag encoded value in chunks of five bits, lowest
+// first, each but the last flagged with 0x20
+func writeValue(b *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte(0x20|u&0x1f) + 63)
+		u >>= 5
+	}
+	b.WriteByte(byte(u) + 63)
+}
@@ -0,0 +1,117 @@
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// minSplit is the shortest final split Splits reports, so that rounding at
+// the end of a track does not add an empty one
+const minSplit = 1.0
+
+// Split is one stretch of a track
+type Split struct {
+	// Distance is in meters: the split length, or less for the last split
+	Distance float64
+	Duration time.Duration
+	// ElevationChange is in meters, nil when the track has no elevations
+	// where the split starts or ends
+	ElevationChange *float64
+}
+
+// Splits cuts a track into consecutive splits of length meters, timing each
+// by interpolating between the points around its ends. It returns nil unless
+// every point has a time
+func Splits(points []Point, length float64) []Split {
+	if len(points) < 2 || length <= 0 {
+		return nil
+	}
+	for _, p := range points {
+		if p.Time.IsZero() {
+			return nil
+		}
+	}
+
+	var splits []Split
+	start, startElevation := points[0].Time, points[0].Elevation
+	// covered is the distance into the current split
+	var covered float64
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		d := Haversine(a, b)
+		// pos is how far along the segment the current split started
+		var pos float64
+		for covered+d-pos >= length {
+			pos += length - covered
+			f := pos / d
+			t := a.Time.Add(time.Duration(f * float64(b.Time.Sub(a.Time))))
+			elevation := interpolate(a.Elevation, b.Elevation, f)
+			splits = append(splits, Split{Distance: length, Duration: t.Sub(start), ElevationChange: change(startElevation, elevation)})
+			start, startElevation, covered = t, elevation, 0
+		}
+		covered += max(0, d-pos)
+	}
+
+	if covered >= minSplit {
+		last := points[len(points)-1]
+		splits = append(splits, Split{Distance: covered, Duration: last.Time.Sub(start), ElevationChange: change(startElevation, last.Elevation)})
+	}
+	return splits
+}
+
+// ProfilePoint is the elevation at a distance along a track, both in meters
+type ProfilePoint struct {
+	Distance  float64
+	Elevation float64
+}
+
+// ElevationProfile returns the elevations along a track at the points that
+// have one. Long tracks are thinned out to about limit points spread evenly
+// over the distance, always keeping the first and last
+func ElevationProfile(points []Point, limit int) []ProfilePoint {
+	var profile []ProfilePoint
+	var distance float64
+	for i, p := range points {
+		if i > 0 {
+			distance += Haversine(points[i-1], p)
+		}
+		if p.Elevation != nil {
+			profile = append(profile, ProfilePoint{Distance: distance, Elevation: *p.Elevation})
+		}
+	}
+	if len(profile) <= limit || limit < 2 {
+		return profile
+	}
+
+	// Keep the first point of every step; the small epsilon absorbs the
+	// rounding in the summed distances
+	step := profile[len(profile)-1].Distance / float64(limit-1)
+	thinned := []ProfilePoint{profile[0]}
+	last := 0
+	for _, p := range profile[1 : len(profile)-1] {
+		if n := int(math.Floor(p.Distance/step + 1e-9)); n > last && n < limit-1 {
+			thinned = append(thinned, p)
+			last = n
+		}
+	}
+	return append(thinned, profile[len(profile)-1])
+}
+
+// interpolate returns the elevation a fraction f of the way from a to b, nil
+// unless both are known
+func interpolate(a, b *float64, f float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	e := *a + (*b-*a)*f
+	return &e
+}
+
+// change returns the difference from a to b, nil unless both are known
+func change(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	d := *b - *a
+	return &d
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementHandler handles streaks and badges for the authenticated user
+type AchievementHandler struct {
+	achievementService *services.AchievementService
+	userService        *services.UserService
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(achievementService *services.AchievementService, userService *services.UserService) *AchievementHandler {
+	return &AchievementHandler{achievementService: achievementService, userService: userService}
+}
+
+// GetAchievements returns the user's streaks and earned badges
+func (h *AchievementHandler) GetAchievements(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	summary, err := h.achievementService.Summary(c.Request.Context(), user, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Achievements retrieved successfully",
+		Data:    summary,
+	})
+}
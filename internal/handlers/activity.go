@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActivityHandler handles activity endpoints for the authenticated user
+type ActivityHandler struct {
+	activityService *services.ActivityService
+	userService     *services.UserService
+	importMaxBytes  int64
+}
+
+// NewActivityHandler creates a new activity handler accepting imported files
+// up to importMaxBytes
+func NewActivityHandler(activityService *services.ActivityService, userService *services.UserService, importMaxBytes int64) *ActivityHandler {
+	return &ActivityHandler{activityService: activityService, userService: userService, importMaxBytes: importMaxBytes}
+}
+
+// GetActivities returns the user's activities
+func (h *ActivityHandler) GetActivities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var query models.ActivityQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	activities, err := h.activityService.List(c.Request.Context(), userID, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	data := make([]models.ActivityResponse, len(activities))
+	for i := range activities {
+		data[i] = activities[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Activities retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetActivity returns one of the user's activities
+func (h *ActivityHandler) GetActivity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	activity, err := h.activityService.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if notModified(c, activity.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Activity retrieved successfully",
+		Data:    activity.ToResponse(),
+	})
+}
+
+// GetActivityRoute returns the GPS track of one of the user's activities as
+// GeoJSON for map rendering
+func (h *ActivityHandler) GetActivityRoute(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	route, err := h.activityService.Route(c.Request.Context(), user, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// GeoJSON is served bare so that map libraries can load it directly
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, route)
+}
+
+// CreateActivity logs a new activity
+func (h *ActivityHandler) CreateActivity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	activity, err := h.activityService.Create(c.Request.Context(), user, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setETag(c, activity.Version)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Activity created successfully",
+		Data:    activity.ToResponse(),
+	})
+}
+
+// ReplaceActivity replaces one of the user's activities
+func (h *ActivityHandler) ReplaceActivity(c *gin.Context) {
+	activity, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	h.save(c, activity, req)
+}
+
+// UpdateActivity applies a JSON merge patch to one of the user's activities
+func (h *ActivityHandler) UpdateActivity(c *gin.Context) {
+	activity, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceActivityRequest
+	if !bindMergePatch(c, activity.ReplaceRequest(), &req) {
+		return
+	}
+
+	h.save(c, activity, req)
+}
+
+// DeleteActivity deletes one of the user's activities
+func (h *ActivityHandler) DeleteActivity(c *gin.Context) {
+	activity, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	if err := h.activityService.Delete(c.Request.Context(), activity); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Activity deleted successfully",
+	})
+}
+
+// ImportActivities logs the workouts in a GPX, TCX or FIT file sent as the
+// "file" multipart field
+func (h *ActivityHandler) ImportActivities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.importMaxBytes+1<<10)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "file is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if header.Size > h.importMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("file must be at most %d bytes", h.importMaxBytes),
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	result, err := h.activityService.Import(c.Request.Context(), user, data)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: fmt.Sprintf("Imported %d activities", len(result.Imported)),
+		Data:    result,
+	})
+}
+
+// current loads the activity a write applies to and checks the request's
+// If-Match header against it
+func (h *ActivityHandler) current(c *gin.Context) (*models.Activity, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return nil, false
+	}
+
+	activity, err := h.activityService.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return activity, checkIfMatch(c, activity.Version)
+}
+
+func (h *ActivityHandler) save(c *gin.Context, activity *models.Activity, req models.ReplaceActivityRequest) {
+	user, err := h.userService.Get(c.Request.Context(), activity.UserID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	activity, err = h.activityService.Replace(c.Request.Context(), user, activity, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, activity.Version)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Activity updated successfully",
+		Data:    activity.ToResponse(),
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles the admin endpoint for the audit log
+type AuditHandler struct {
+	log *audit.Log
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(log *audit.Log) *AuditHandler {
+	return &AuditHandler{log: log}
+}
+
+// GetAuditLog returns a page of audit entries matching the query, newest first
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	entries, total, err := h.log.List(c.Request.Context(), repository.AuditFilter{
+		Action:    query.Action,
+		ActorID:   query.ActorID,
+		UserID:    query.UserID,
+		RequestID: query.RequestID,
+		From:      query.From,
+		To:        query.To,
+		Offset:    (query.Page - 1) * query.Limit,
+		Limit:     query.Limit,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PaginatedResponse{
+		Success: true,
+		Message: "Audit log retrieved successfully",
+		Data:    entries,
+		Pagination: models.Pagination{
+			Page:       query.Page,
+			Limit:      query.Limit,
+			Total:      total,
+			TotalPages: int((total + int64(query.Limit) - 1) / int64(query.Limit)),
+		},
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler handles registration and login endpoints
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+// Register creates a new account
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	res, err := h.authService.Register(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "User registered successfully",
+		Data:    res,
+	})
+}
+
+// Login authenticates a user and returns a token
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	res, err := h.authService.Login(c.Request.Context(), req, c.ClientIP())
+	if err != nil {
+		switch {
+		case respondLocked(c, err):
+		case errors.Is(err, services.ErrAccountDisabled):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    http.StatusForbidden,
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    http.StatusUnauthorized,
+			})
+		default:
+			internalError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    res,
+	})
+}
+
+// respondLocked responds with 429 and reports true if err is a
+// *lockout.LockedError
+func respondLocked(c *gin.Context, err error) bool {
+	var locked *lockout.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	retryAfter := int(time.Until(locked.Until).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, models.LockoutErrorResponse{
+		Success:     false,
+		Error:       locked.Error(),
+		Code:        http.StatusTooManyRequests,
+		LockedUntil: locked.Until,
+	})
+	return true
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"fitbyte/internal/activityfile"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/middleware"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError maps service and repository errors to an error response
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, repository.ErrDuplicateEmail):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, auth.ErrInvalidSignature), errors.Is(err, services.ErrWrongPassword),
+		errors.Is(err, services.ErrOwnRole):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusForbidden,
+		})
+	case errors.Is(err, services.ErrInvalidGoal), errors.Is(err, services.ErrWeightUnknown),
+		errors.Is(err, services.ErrInvalidMeasurement), errors.Is(err, services.ErrInvalidBirthDate),
+		errors.Is(err, services.ErrInvalidRange), errors.Is(err, services.ErrInvalidTimeZone),
+		errors.Is(err, services.ErrInvalidLocale), errors.Is(err, activityfile.ErrInvalidFile),
+		errors.Is(err, services.ErrInvalidTrack):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	case errors.Is(err, services.ErrExportNotReady):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, services.ErrRestoreExpired), errors.Is(err, auth.ErrLinkExpired):
+		c.JSON(http.StatusGone, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusGone,
+		})
+	case errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+			Success: false,
+			Error:   "Resource has been modified; fetch it again and retry",
+			Code:    http.StatusPreconditionFailed,
+		})
+	default:
+		internalError(c, err)
+	}
+}
+
+// internalError records err on the context for the logger and responds with
+// a generic 500
+func internalError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Success: false,
+		Error:   "Internal server error",
+		Code:    http.StatusInternalServerError,
+	})
+}
+
+// currentUserID returns the authenticated user's ID, responding with 401 if
+// the request is not authenticated
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Success: false,
+			Error:   "Authentication required",
+			Code:    http.StatusUnauthorized,
+		})
+	}
+	return userID, ok
+}
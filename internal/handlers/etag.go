@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns the entity tag of a resource version
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// setETag sets the ETag header to the entity tag of version
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+}
+
+// notModified sets the ETag header and, if the request's If-None-Match
+// matches it, responds with 304 and returns true
+func notModified(c *gin.Context, version uint) bool {
+	setETag(c, version)
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !matchETag(header, etag(version), true) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch responds with 412 and returns false when the request has an
+// If-Match header that does not match version. Whether the header is
+// required is decided by middleware.RequireIfMatch
+func checkIfMatch(c *gin.Context, version uint) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchETag(header, etag(version), false) {
+		return true
+	}
+	c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+		Success: false,
+		Error:   "Resource has been modified; fetch it again and retry",
+		Code:    http.StatusPreconditionFailed,
+	})
+	return false
+}
+
+// matchETag reports whether the comma separated list of entity tags in
+// header contains tag or "*". Weak tags only match under weak comparison
+// (RFC 9110, section 8.8.3.2)
+func matchETag(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testContext returns a gin context for a GET with header set
+func testContext(header, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		c.Request.Header.Set(header, value)
+	}
+	return c, w
+}
+
+func TestETag(t *testing.T) {
+	tests := []struct {
+		version uint
+		want    string
+	}{
+		{1, `"1"`},
+		{42, `"42"`},
+	}
+	for _, tt := range tests {
+		if got := etag(tt.version); got != tt.want {
+			t.Errorf("etag(%d) = %s, want %s", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"3"`, false, true},
+		{`"2"`, false, false},
+		{`"1", "3"`, false, true},
+		{`"1","2"`, false, false},
+		{`*`, false, true},
+		{`W/"3"`, false, false},
+		{`W/"3"`, true, true},
+		{`"2", W/"3"`, true, true},
+		{`3`, true, false},
+	}
+	for _, tt := range tests {
+		if got := matchETag(tt.header, `"3"`, tt.weak); got != tt.want {
+			t.Errorf("matchETag(%s, weak %v) = %v, want %v", tt.header, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"current", `"3"`, true},
+		{"weak current", `W/"3"`, true},
+		{"stale", `"2"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext("If-None-Match", tt.ifNoneMatch)
+			if got := notModified(c, 3); got != tt.want {
+				t.Fatalf("notModified = %v, want %v", got, tt.want)
+			}
+			c.Writer.WriteHeaderNow()
+			if got := w.Header().Get("ETag"); got != `"3"` {
+				t.Errorf("ETag = %s, want \"3\"", got)
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", w.Code)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{"no header", "", true},
+		{"current", `"3"`, true},
+		{"any", `*`, true},
+		{"stale", `"2"`, false},
+		// Weak tags never match If-Match
+		{"weak", `W/"3"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext("If-Match", tt.ifMatch)
+			if got := checkIfMatch(c, 3); got != tt.want {
+				t.Fatalf("checkIfMatch = %v, want %v", got, tt.want)
+			}
+			if !tt.want && w.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want 412", w.Code)
+			}
+		})
+	}
+}
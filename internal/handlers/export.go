@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles personal data exports for the authenticated user
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// RequestExport queues an archive of the user's data
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.Request(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Export requested successfully",
+		Data:    h.exportService.Response(export),
+	})
+}
+
+// GetExport returns the status of one of the user's exports, with a
+// download link once it has completed
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.exportService.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Export retrieved successfully",
+		Data:    h.exportService.Response(export),
+	})
+}
+
+// Download serves the archive of an export to holders of a signed link
+func (h *ExportHandler) Download(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	path, err := h.exportService.Open(c.Request.Context(), id, c.Request.URL.Query())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.FileAttachment(path, "fitbyte-export-"+strconv.FormatUint(uint64(id), 10)+".zip")
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// allowedImageTypes maps accepted content types to file extensions
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// FileHandler handles file uploads
+type FileHandler struct {
+	storage  storage.Storage
+	maxBytes int64
+}
+
+// NewFileHandler creates a new file handler accepting files up to maxBytes
+func NewFileHandler(storage storage.Storage, maxBytes int64) *FileHandler {
+	return &FileHandler{storage: storage, maxBytes: maxBytes}
+}
+
+// Upload stores an image sent as the "file" multipart field and returns its URI
+func (h *FileHandler) Upload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+1<<10)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "file is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if header.Size > h.maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("file must be at most %d bytes", h.maxBytes),
+			Code:    http.StatusRequestEntityTooLarge,
+		})
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer f.Close()
+
+	// Trust the file contents rather than the client supplied content type
+	sniff := make([]byte, 512)
+	n, _ := f.Read(sniff)
+	ext, ok := allowedImageTypes[http.DetectContentType(sniff[:n])]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "file must be a JPEG or PNG image",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		internalError(c, err)
+		return
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		internalError(c, err)
+		return
+	}
+	key := path.Join(storage.UserPrefix(userID), hex.EncodeToString(name)+ext)
+
+	uri, err := h.storage.Save(c.Request.Context(), key, f)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "File uploaded successfully",
+		Data:    models.FileResponse{URI: uri},
+	})
+}
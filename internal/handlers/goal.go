@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+	"fitbyte/internal/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GoalHandler handles goal endpoints for the authenticated user. Weights
+// are shown in the unit of the user's preference
+type GoalHandler struct {
+	goalService *services.GoalService
+	userService *services.UserService
+}
+
+// NewGoalHandler creates a new goal handler
+func NewGoalHandler(goalService *services.GoalService, userService *services.UserService) *GoalHandler {
+	return &GoalHandler{goalService: goalService, userService: userService}
+}
+
+// GetGoals returns the user's goals
+func (h *GoalHandler) GetGoals(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	goals, err := h.goalService.List(c.Request.Context(), user.ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	weightUnit := units.WeightUnit(user.Preference)
+	data := make([]models.GoalResponse, len(goals))
+	for i := range goals {
+		data[i] = goals[i].ToResponse(weightUnit)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Goals retrieved successfully",
+		Data:    data,
+	})
+}
+
+// GetGoal returns one of the user's goals
+func (h *GoalHandler) GetGoal(c *gin.Context) {
+	user, goal, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if notModified(c, goal.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Goal retrieved successfully",
+		Data:    goal.ToResponse(units.WeightUnit(user.Preference)),
+	})
+}
+
+// CreateGoal sets a new goal
+func (h *GoalHandler) CreateGoal(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	goal, err := h.goalService.Create(c.Request.Context(), user, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setETag(c, goal.Version)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Goal created successfully",
+		Data:    goal.ToResponse(units.WeightUnit(user.Preference)),
+	})
+}
+
+// ReplaceGoal replaces one of the user's goals
+func (h *GoalHandler) ReplaceGoal(c *gin.Context) {
+	user, goal, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	h.save(c, user, goal, req)
+}
+
+// UpdateGoal applies a JSON merge patch to one of the user's goals
+func (h *GoalHandler) UpdateGoal(c *gin.Context) {
+	user, goal, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceGoalRequest
+	if !bindMergePatch(c, goal.ReplaceRequest(units.WeightUnit(user.Preference)), &req) {
+		return
+	}
+
+	h.save(c, user, goal, req)
+}
+
+// DeleteGoal deletes one of the user's goals
+func (h *GoalHandler) DeleteGoal(c *gin.Context) {
+	_, goal, ok := h.current(c)
+	if !ok {
+		return
+	}
+
+	if err := h.goalService.Delete(c.Request.Context(), goal.UserID, goal.ID, goal.Version); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Goal deleted successfully",
+	})
+}
+
+// GetGoalProgress returns how far the user is towards one of their goals
+func (h *GoalHandler) GetGoalProgress(c *gin.Context) {
+	user, goal, ok := h.load(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.goalService.Progress(c.Request.Context(), user, goal, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Goal progress retrieved successfully",
+		Data:    progress,
+	})
+}
+
+// user loads the authenticated user, whose preference decides the units
+// goals are shown in
+func (h *GoalHandler) user(c *gin.Context) (*models.User, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// load loads the authenticated user and the goal named by the request
+func (h *GoalHandler) load(c *gin.Context) (*models.User, *models.Goal, bool) {
+	user, ok := h.user(c)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	goal, err := h.goalService.Get(c.Request.Context(), user.ID, id)
+	if err != nil {
+		respondError(c, err)
+		return nil, nil, false
+	}
+	return user, goal, true
+}
+
+// current loads the goal a write applies to and checks the request's
+// If-Match header against it
+func (h *GoalHandler) current(c *gin.Context) (*models.User, *models.Goal, bool) {
+	user, goal, ok := h.load(c)
+	if !ok {
+		return nil, nil, false
+	}
+	return user, goal, checkIfMatch(c, goal.Version)
+}
+
+func (h *GoalHandler) save(c *gin.Context, user *models.User, goal *models.Goal, req models.ReplaceGoalRequest) {
+	goal, err := h.goalService.Replace(c.Request.Context(), user, goal, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, goal.Version)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Goal updated successfully",
+		Data:    goal.ToResponse(units.WeightUnit(user.Preference)),
+	})
+}
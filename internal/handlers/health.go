@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"fitbyte/internal/models"
 
@@ -9,11 +11,14 @@ import (
 )
 
 // HealthHandler handles health check endpoints
-type HealthHandler struct{}
+type HealthHandler struct {
+	ping func(ctx context.Context) error
+}
 
-// NewHealthHandler creates a new health handler
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+// NewHealthHandler creates a new health handler. ping is used by the
+// readiness check to verify the database connection
+func NewHealthHandler(ping func(ctx context.Context) error) *HealthHandler {
+	return &HealthHandler{ping: ping}
 }
 
 // Health returns the health status of the API
@@ -32,7 +37,17 @@ func (h *HealthHandler) Health(c *gin.Context) {
 
 // Ready returns the readiness status of the API
 func (h *HealthHandler) Ready(c *gin.Context) {
-	// Add your readiness checks here (database connection, external services, etc.)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := h.ping(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Success: false,
+			Error:   "Database unavailable: " + err.Error(),
+			Code:    http.StatusServiceUnavailable,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "API is ready",
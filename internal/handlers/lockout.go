@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LockoutHandler handles admin endpoints for login lockouts
+type LockoutHandler struct {
+	guard *lockout.Guard
+}
+
+// NewLockoutHandler creates a new lockout handler
+func NewLockoutHandler(guard *lockout.Guard) *LockoutHandler {
+	return &LockoutHandler{guard: guard}
+}
+
+// GetLockouts returns tracked failed login entries. Pass locked=true to only
+// return entries that are currently locked
+func (h *LockoutHandler) GetLockouts(c *gin.Context) {
+	entries, err := h.guard.List(c.Request.Context())
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	if c.Query("locked") == "true" {
+		now := time.Now()
+		locked := entries[:0]
+		for _, entry := range entries {
+			if entry.Locked(now) {
+				locked = append(locked, entry)
+			}
+		}
+		entries = locked
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Lockouts retrieved successfully",
+		Data:    entries,
+	})
+}
+
+// ClearLockout removes failed attempts and any lock for an email and/or IP
+func (h *LockoutHandler) ClearLockout(c *gin.Context) {
+	email, ip := c.Query("email"), c.Query("ip")
+	if email == "" && ip == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   "email or ip query parameter is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if email != "" {
+		if err := h.guard.Clear(ctx, lockout.EmailKey(email)); err != nil {
+			internalError(c, err)
+			return
+		}
+	}
+	if ip != "" {
+		if err := h.guard.Clear(ctx, lockout.IPKey(ip)); err != nil {
+			internalError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Lockout cleared successfully",
+	})
+}
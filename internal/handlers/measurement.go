@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MeasurementHandler handles body measurement endpoints for the
+// authenticated user. Values are shown in the units of the user's preference
+type MeasurementHandler struct {
+	measurementService *services.MeasurementService
+	userService        *services.UserService
+}
+
+// NewMeasurementHandler creates a new measurement handler
+func NewMeasurementHandler(measurementService *services.MeasurementService, userService *services.UserService) *MeasurementHandler {
+	return &MeasurementHandler{measurementService: measurementService, userService: userService}
+}
+
+// GetMeasurements returns the user's measurements with moving averages
+func (h *MeasurementHandler) GetMeasurements(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	var query models.MeasurementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	data, err := h.measurementService.List(c.Request.Context(), user, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Measurements retrieved successfully",
+		Data:    data,
+	})
+}
+
+// CreateMeasurement records a body measurement
+func (h *MeasurementHandler) CreateMeasurement(c *gin.Context) {
+	user, ok := h.user(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateMeasurementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	measurement, err := h.measurementService.Create(c.Request.Context(), user, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Measurement recorded successfully",
+		Data:    measurement.ToResponse(user.Preference),
+	})
+}
+
+// user loads the authenticated user, whose preference decides the units
+// measurements are shown in
+func (h *MeasurementHandler) user(c *gin.Context) (*models.User, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler handles the health metrics of the authenticated user
+type MetricsHandler struct {
+	metricsService *services.MetricsService
+	userService    *services.UserService
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(metricsService *services.MetricsService, userService *services.UserService) *MetricsHandler {
+	return &MetricsHandler{metricsService: metricsService, userService: userService}
+}
+
+// GetMetrics returns the user's BMI, BMR and TDEE
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var query models.MetricsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	metrics, err := h.metricsService.Metrics(c.Request.Context(), user, query, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Health metrics retrieved successfully",
+		Data:    metrics,
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"fitbyte/internal/mergepatch"
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindMergePatch applies the request body as a JSON merge patch (RFC 7396)
+// to current, the replacement payload describing the resource as it is, and
+// binds and validates the result into obj. It responds with 415 or 400 and
+// returns false when the patch cannot be applied
+func bindMergePatch(c *gin.Context, current, obj interface{}) bool {
+	if contentType := c.GetHeader("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergepatch.ContentType && mediaType != binding.MIMEJSON) {
+			c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+				Success: false,
+				Error:   "Content-Type must be " + mergepatch.ContentType + " or " + binding.MIMEJSON,
+				Code:    http.StatusUnsupportedMediaType,
+			})
+			return false
+		}
+	}
+
+	err := applyMergePatch(c.Request.Body, current, obj)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(obj)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return false
+	}
+	return true
+}
+
+func applyMergePatch(body io.Reader, current, obj interface{}) error {
+	patch, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	target, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	merged, err := mergepatch.Apply(target, patch)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(merged, obj)
+}
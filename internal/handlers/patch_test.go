@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindMergePatch(t *testing.T) {
+	doneAt := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	distance := 5000.0
+	current := models.ReplaceActivityRequest{
+		ActivityType:      "Running",
+		DoneAt:            doneAt,
+		DurationInMinutes: 30,
+		DistanceInMeters:  &distance,
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        func(models.ReplaceActivityRequest) bool
+	}{
+		{
+			name:        "changes only the patched field",
+			contentType: "application/merge-patch+json",
+			body:        `{"durationInMinutes":45}`,
+			want: func(r models.ReplaceActivityRequest) bool {
+				return r.DurationInMinutes == 45 && r.ActivityType == "Running" && r.DoneAt.Equal(doneAt) &&
+					r.DistanceInMeters != nil && *r.DistanceInMeters == distance
+			},
+		},
+		{
+			name:        "null removes a field",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"distanceInMeters":null}`,
+			want: func(r models.ReplaceActivityRequest) bool {
+				return r.DistanceInMeters == nil && r.DurationInMinutes == 30
+			},
+		},
+		{
+			name:        "plain JSON is accepted",
+			contentType: "application/json",
+			body:        `{"activityType":"Walking"}`,
+			want: func(r models.ReplaceActivityRequest) bool {
+				return r.ActivityType == "Walking"
+			},
+		},
+		{
+			name: "no content type",
+			body: `{}`,
+			want: func(r models.ReplaceActivityRequest) bool {
+				return r.ActivityType == "Running"
+			},
+		},
+		{"other content type", "text/plain", `{}`, http.StatusUnsupportedMediaType, nil},
+		{"malformed content type", "application/", `{}`, http.StatusUnsupportedMediaType, nil},
+		{"malformed patch", "application/merge-patch+json", `{"durationInMinutes":`, http.StatusBadRequest, nil},
+		{"wrong type", "application/merge-patch+json", `{"durationInMinutes":"long"}`, http.StatusBadRequest, nil},
+		{"fails validation", "application/merge-patch+json", `{"durationInMinutes":0}`, http.StatusBadRequest, nil},
+		{"removes a required field", "application/merge-patch+json", `{"activityType":null}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				c.Request.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var got models.ReplaceActivityRequest
+			ok := bindMergePatch(c, current, &got)
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("bindMergePatch() = %v, status %d: %s", ok, w.Code, w.Body)
+			}
+			if !ok {
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				return
+			}
+			if !tt.want(got) {
+				t.Errorf("bindMergePatch() bound %+v", got)
+			}
+		})
+	}
+}
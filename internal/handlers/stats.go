@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatsHandler handles activity statistics for the authenticated user
+type StatsHandler struct {
+	statsService *services.StatsService
+	userService  *services.UserService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService *services.StatsService, userService *services.UserService) *StatsHandler {
+	return &StatsHandler{statsService: statsService, userService: userService}
+}
+
+// GetSummary returns the user's activity totals per day, week or month
+func (h *StatsHandler) GetSummary(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var query models.StatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	summary, err := h.statsService.Summary(c.Request.Context(), user, query, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Activity summary retrieved successfully",
+		Data:    summary,
+	})
+}
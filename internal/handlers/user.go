@@ -5,19 +5,20 @@ import (
 	"strconv"
 
 	"fitbyte/internal/models"
+	"fitbyte/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 // UserHandler handles user-related endpoints
 type UserHandler struct {
-	// In a real application, you would inject a service or repository here
-	// userService services.UserService
+	userService *services.UserService
+	authService *services.AuthService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+func NewUserHandler(userService *services.UserService, authService *services.AuthService) *UserHandler {
+	return &UserHandler{userService: userService, authService: authService}
 }
 
 // GetUsers returns a list of users
@@ -25,87 +26,57 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
 
-	// In a real application, you would fetch users from a database
-	name1 := "John Doe"
-	name2 := "Jane Smith"
-	preference1 := "metric"
-	weightUnit1 := "kg"
-	heightUnit1 := "cm"
-	weight1 := 75.5
-	height1 := 180.0
-	imageURI1 := "https://example.com/image1.jpg"
-	
-	users := []models.UserResponse{
-		{
-			ID:         1,
-			Email:      "john@example.com",
-			Name:       &name1,
-			Preference: &preference1,
-			WeightUnit: &weightUnit1,
-			HeightUnit: &heightUnit1,
-			Weight:     &weight1,
-			Height:     &height1,
-			ImageURI:   &imageURI1,
-		},
-		{
-			ID:    2,
-			Email: "jane@example.com",
-			Name:  &name2,
-			// Other fields are nil (null in JSON)
-		},
+	users, total, err := h.userService.List(c.Request.Context(), page, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	data := make([]models.UserResponse, len(users))
+	for i := range users {
+		data[i] = users[i].ToResponse()
 	}
 
 	c.JSON(http.StatusOK, models.PaginatedResponse{
 		Success: true,
 		Message: "Users retrieved successfully",
-		Data:    users,
+		Data:    data,
 		Pagination: models.Pagination{
 			Page:       page,
 			Limit:      limit,
-			Total:      int64(len(users)),
-			TotalPages: 1,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
 		},
 	})
 }
 
 // GetUser returns a specific user by ID
 func (h *UserHandler) GetUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Success: false,
-			Error:   "Invalid user ID",
-			Code:    http.StatusBadRequest,
-		})
+	id, ok := parseID(c)
+	if !ok {
 		return
 	}
 
-	// In a real application, you would fetch the user from a database
-	name := "John Doe"
-	preference := "metric"
-	weightUnit := "kg"
-	heightUnit := "cm"
-	weight := 75.5
-	height := 180.0
-	imageURI := "https://example.com/image1.jpg"
-	
-	user := models.UserResponse{
-		ID:         uint(id),
-		Email:      "john@example.com",
-		Name:       &name,
-		Preference: &preference,
-		WeightUnit: &weightUnit,
-		HeightUnit: &heightUnit,
-		Weight:     &weight,
-		Height:     &height,
-		ImageURI:   &imageURI,
+	user, err := h.userService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if notModified(c, user.Version) {
+		return
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Message: "User retrieved successfully",
-		Data:    user,
+		Data:    user.ToResponse(),
 	})
 }
 
@@ -121,119 +92,273 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// In a real application, you would create the user in a database
-	user := models.UserResponse{
-		ID:         1,
-		Email:      req.Email,
-		Name:       req.Name,
-		Preference: req.Preference,
-		WeightUnit: req.WeightUnit,
-		HeightUnit: req.HeightUnit,
-		Weight:     req.Weight,
-		Height:     req.Height,
-		ImageURI:   req.ImageURI,
+	user, err := h.userService.Create(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
+	setETag(c, user.Version)
 
 	c.JSON(http.StatusCreated, models.APIResponse{
 		Success: true,
 		Message: "User created successfully",
-		Data:    user,
+		Data:    user.ToResponse(),
 	})
 }
 
-// UpdateUser updates an existing user
+// ReplaceUser replaces an existing user
+func (h *UserHandler) ReplaceUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	h.replace(c, id, "User updated successfully")
+}
+
+// UpdateUser applies a JSON merge patch to an existing user
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	h.patch(c, id, "User updated successfully")
+}
+
+// DeleteUser deletes a user
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	user, ok := h.current(c, id)
+	if !ok {
+		return
+	}
+	if err := h.userService.Delete(c.Request.Context(), id, user.Version); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// SetUserRole changes a user's role
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Invalid user ID",
+			Error:   err.Error(),
 			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
-	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	user, ok := h.current(c, id)
+	if !ok {
+		return
+	}
+	user, err := h.userService.SetRole(c.Request.Context(), actorID, user, req.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role updated successfully",
+		Data:    user.ToResponse(),
+	})
+}
+
+// RestoreUser undoes the deletion of a user within the restore period
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Restore(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User restored successfully",
+		Data:    user.ToResponse(),
+	})
+}
+
+// parseID parses the :id path parameter, responding with 400 if it is invalid
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "Invalid ID",
 			Code:    http.StatusBadRequest,
 		})
-		return
+		return 0, false
 	}
+	return uint(id), true
+}
 
-	// In a real application, you would update the user in a database
-	name := "John Doe"
-	preference := "metric"
-	weightUnit := "kg"
-	heightUnit := "cm"
-	weight := 75.5
-	height := 180.0
-	imageURI := "https://example.com/image1.jpg"
-	
-	user := models.UserResponse{
-		ID:         uint(id),
-		Email:      "john@example.com",
-		Name:       &name,
-		Preference: &preference,
-		WeightUnit: &weightUnit,
-		HeightUnit: &heightUnit,
-		Weight:     &weight,
-		Height:     &height,
-		ImageURI:   &imageURI,
+// GetProfile returns the authenticated user's profile
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
 	}
 
-	// Apply updates
-	if req.Email != nil {
-		user.Email = *req.Email
+	user, err := h.userService.Get(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
-	if req.Name != nil {
-		user.Name = req.Name
+	if notModified(c, user.Version) {
+		return
 	}
-	if req.Preference != nil {
-		user.Preference = req.Preference
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Profile retrieved successfully",
+		Data:    user.ToResponse(),
+	})
+}
+
+// ReplaceProfile replaces the authenticated user's profile
+func (h *UserHandler) ReplaceProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
 	}
-	if req.WeightUnit != nil {
-		user.WeightUnit = req.WeightUnit
+	h.replace(c, userID, "Profile updated successfully")
+}
+
+// UpdateProfile applies a JSON merge patch to the authenticated user's profile
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
 	}
-	if req.HeightUnit != nil {
-		user.HeightUnit = req.HeightUnit
+	h.patch(c, userID, "Profile updated successfully")
+}
+
+// DeleteProfile permanently deletes the authenticated user's account after
+// confirming their password
+func (h *UserHandler) DeleteProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
 	}
-	if req.Weight != nil {
-		user.Weight = req.Weight
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
 	}
-	if req.Height != nil {
-		user.Height = req.Height
+
+	user, ok := h.current(c, userID)
+	if !ok {
+		return
 	}
-	if req.ImageURI != nil {
-		user.ImageURI = req.ImageURI
+	ctx := c.Request.Context()
+	if err := h.authService.ConfirmPassword(ctx, user, req.Password, c.ClientIP()); err != nil {
+		if !respondLocked(c, err) {
+			respondError(c, err)
+		}
+		return
+	}
+	if err := h.userService.DeleteAccount(ctx, user); err != nil {
+		respondError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "User updated successfully",
-		Data:    user,
+		Message: "Account deleted successfully",
 	})
 }
 
-// DeleteUser deletes a user
-func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
+// replace replaces the user with the request body
+func (h *UserHandler) replace(c *gin.Context, id uint, message string) {
+	user, ok := h.current(c, id)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Success: false,
-			Error:   "Invalid user ID",
+			Error:   err.Error(),
 			Code:    http.StatusBadRequest,
 		})
 		return
 	}
 
-	// In a real application, you would delete the user from a database
-	_ = id // Use the ID to delete the user
+	h.save(c, user, req, message)
+}
+
+// patch merges the request body into the user's current state and replaces
+// the user with the result
+func (h *UserHandler) patch(c *gin.Context, id uint, message string) {
+	user, ok := h.current(c, id)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceUserRequest
+	if !bindMergePatch(c, user.ReplaceRequest(), &req) {
+		return
+	}
+
+	h.save(c, user, req, message)
+}
 
+// current loads the user a write applies to and checks the request's
+// If-Match header against it
+func (h *UserHandler) current(c *gin.Context, id uint) (*models.User, bool) {
+	user, err := h.userService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return user, checkIfMatch(c, user.Version)
+}
+
+func (h *UserHandler) save(c *gin.Context, user *models.User, req models.ReplaceUserRequest, message string) {
+	user, err := h.userService.Replace(c.Request.Context(), user, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setETag(c, user.Version)
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: "User deleted successfully",
+		Message: message,
+		Data:    user.ToResponse(),
 	})
 }
@@ -0,0 +1,147 @@
+// Package health derives health metrics such as BMI, basal metabolic rate
+// and total daily energy expenditure from body measurements. Weights are in
+// kilograms and heights in centimeters
+package health
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnknownSex is returned by the BMR formulas for a sex they have no
+// coefficients for
+var ErrUnknownSex = errors.New("sex must be male or female")
+
+// Sexes the BMR formulas have coefficients for
+const (
+	Male   = "male"
+	Female = "female"
+)
+
+// BMI categories as defined by the WHO for adults
+const (
+	Underweight = "underweight"
+	Normal      = "normal"
+	Overweight  = "overweight"
+	Obese       = "obese"
+)
+
+// Activity levels, from no exercise to hard exercise every day
+const (
+	Sedentary        = "sedentary"
+	LightlyActive    = "lightly_active"
+	ModeratelyActive = "moderately_active"
+	VeryActive       = "very_active"
+	ExtraActive      = "extra_active"
+)
+
+// activityFactors are the multipliers applied to BMR for each activity level
+var activityFactors = map[string]float64{
+	Sedentary:        1.2,
+	LightlyActive:    1.375,
+	ModeratelyActive: 1.55,
+	VeryActive:       1.725,
+	ExtraActive:      1.9,
+}
+
+// BMI returns the body mass index for a weight and height
+func BMI(weightKg, heightCm float64) float64 {
+	m := heightCm / 100
+	return weightKg / (m * m)
+}
+
+// BMICategory returns the WHO category of a body mass index
+func BMICategory(bmi float64) string {
+	switch {
+	case bmi < 18.5:
+		return Underweight
+	case bmi < 25:
+		return Normal
+	case bmi < 30:
+		return Overweight
+	default:
+		return Obese
+	}
+}
+
+// MifflinStJeor returns the basal metabolic rate in kcal per day using the
+// Mifflin-St Jeor equation
+func MifflinStJeor(weightKg, heightCm float64, age int, sex string) (float64, error) {
+	bmr := 10*weightKg + 6.25*heightCm - 5*float64(age)
+	switch sex {
+	case Male:
+		return bmr + 5, nil
+	case Female:
+		return bmr - 161, nil
+	default:
+		return 0, ErrUnknownSex
+	}
+}
+
+// HarrisBenedict returns the basal metabolic rate in kcal per day using the
+// Harris-Benedict equation as revised by Roza and Shizgal
+func HarrisBenedict(weightKg, heightCm float64, age int, sex string) (float64, error) {
+	a := float64(age)
+	switch sex {
+	case Male:
+		return 88.362 + 13.397*weightKg + 4.799*heightCm - 5.677*a, nil
+	case Female:
+		return 447.593 + 9.247*weightKg + 3.098*heightCm - 4.330*a, nil
+	default:
+		return 0, ErrUnknownSex
+	}
+}
+
+// ActivityFactor returns the BMR multiplier of an activity level and
+// whether the level is known
+func ActivityFactor(level string) (float64, bool) {
+	factor, ok := activityFactors[level]
+	return factor, ok
+}
+
+// ActivityLevel infers an activity level from the minutes of exercise in an
+// average week
+func ActivityLevel(weeklyMinutes float64) string {
+	switch {
+	case weeklyMinutes < 60:
+		return Sedentary
+	case weeklyMinutes < 150:
+		return LightlyActive
+	case weeklyMinutes < 300:
+		return ModeratelyActive
+	case weeklyMinutes < 600:
+		return VeryActive
+	default:
+		return ExtraActive
+	}
+}
+
+// TDEE returns the total daily energy expenditure for a BMR and activity
+// level. Unknown levels are treated as sedentary
+func TDEE(bmr float64, level string) float64 {
+	factor, ok := ActivityFactor(level)
+	if !ok {
+		factor = activityFactors[Sedentary]
+	}
+	return bmr * factor
+}
+
+// TDEEFromActivities returns the total daily energy expenditure for a BMR
+// given the calories actually burned in logged activities over a number of
+// days: the sedentary expenditure plus the average burned per day
+func TDEEFromActivities(bmr float64, caloriesBurned float64, days int) float64 {
+	tdee := bmr * activityFactors[Sedentary]
+	if days > 0 {
+		tdee += caloriesBurned / float64(days)
+	}
+	return tdee
+}
+
+// Age returns the age in whole years on now of someone born on birthDate
+func Age(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,176 @@
+package health
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+func TestBMI(t *testing.T) {
+	tests := []struct {
+		name         string
+		weightKg     float64
+		heightCm     float64
+		want         float64
+		wantCategory string
+	}{
+		{"underweight", 50, 180, 15.432, Underweight},
+		{"normal", 70, 175, 22.857, Normal},
+		{"overweight", 85, 180, 26.235, Overweight},
+		{"obese", 120, 170, 41.522, Obese},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BMI(tt.weightKg, tt.heightCm)
+			if !approx(got, tt.want) {
+				t.Errorf("BMI(%v, %v) = %v, want %v", tt.weightKg, tt.heightCm, got, tt.want)
+			}
+			if category := BMICategory(got); category != tt.wantCategory {
+				t.Errorf("BMICategory(%v) = %q, want %q", got, category, tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestBMICategory(t *testing.T) {
+	tests := []struct {
+		bmi  float64
+		want string
+	}{
+		{18.49, Underweight},
+		{18.5, Normal},
+		{24.99, Normal},
+		{25, Overweight},
+		{29.99, Overweight},
+		{30, Obese},
+	}
+	for _, tt := range tests {
+		if got := BMICategory(tt.bmi); got != tt.want {
+			t.Errorf("BMICategory(%v) = %q, want %q", tt.bmi, got, tt.want)
+		}
+	}
+}
+
+func TestBMR(t *testing.T) {
+	tests := []struct {
+		name               string
+		weightKg           float64
+		heightCm           float64
+		age                int
+		sex                string
+		wantMifflinStJeor  float64
+		wantHarrisBenedict float64
+		wantErr            error
+	}{
+		{"male", 70, 175, 30, Male, 1648.75, 1695.667, nil},
+		{"female", 60, 165, 25, Female, 1345.25, 1405.333, nil},
+		{"older male", 90, 180, 65, Male, 1705, 1788.907, nil},
+		{"unknown sex", 70, 175, 30, "other", 0, 0, ErrUnknownSex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MifflinStJeor(tt.weightKg, tt.heightCm, tt.age, tt.sex)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MifflinStJeor error = %v, want %v", err, tt.wantErr)
+			}
+			if !approx(got, tt.wantMifflinStJeor) {
+				t.Errorf("MifflinStJeor = %v, want %v", got, tt.wantMifflinStJeor)
+			}
+
+			got, err = HarrisBenedict(tt.weightKg, tt.heightCm, tt.age, tt.sex)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HarrisBenedict error = %v, want %v", err, tt.wantErr)
+			}
+			if !approx(got, tt.wantHarrisBenedict) {
+				t.Errorf("HarrisBenedict = %v, want %v", got, tt.wantHarrisBenedict)
+			}
+		})
+	}
+}
+
+func TestTDEE(t *testing.T) {
+	tests := []struct {
+		level string
+		want  float64
+	}{
+		{Sedentary, 1800},
+		{LightlyActive, 2062.5},
+		{ModeratelyActive, 2325},
+		{VeryActive, 2587.5},
+		{ExtraActive, 2850},
+		{"unknown", 1800},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			if got := TDEE(1500, tt.level); !approx(got, tt.want) {
+				t.Errorf("TDEE(1500, %q) = %v, want %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTDEEFromActivities(t *testing.T) {
+	tests := []struct {
+		name           string
+		caloriesBurned float64
+		days           int
+		want           float64
+	}{
+		{"no activities", 0, 28, 1800},
+		{"300 kcal a day", 8400, 28, 2100},
+		{"no days", 500, 0, 1800},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TDEEFromActivities(1500, tt.caloriesBurned, tt.days); !approx(got, tt.want) {
+				t.Errorf("TDEEFromActivities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivityLevel(t *testing.T) {
+	tests := []struct {
+		weeklyMinutes float64
+		want          string
+	}{
+		{0, Sedentary},
+		{59, Sedentary},
+		{60, LightlyActive},
+		{150, ModeratelyActive},
+		{299, ModeratelyActive},
+		{300, VeryActive},
+		{600, ExtraActive},
+	}
+	for _, tt := range tests {
+		if got := ActivityLevel(tt.weeklyMinutes); got != tt.want {
+			t.Errorf("ActivityLevel(%v) = %q, want %q", tt.weeklyMinutes, got, tt.want)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	birth := time.Date(1990, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"day before birthday", time.Date(2020, time.June, 14, 23, 0, 0, 0, time.UTC), 29},
+		{"on birthday", time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC), 30},
+		{"earlier month", time.Date(2020, time.March, 20, 0, 0, 0, 0, time.UTC), 29},
+		{"later month", time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC), 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Age(birth, tt.now); got != tt.want {
+				t.Errorf("Age = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
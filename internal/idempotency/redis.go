@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/redis"
+)
+
+// RedisStore keeps records in Redis (or any server speaking its protocol) so
+// that retries are recognised by every API instance
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Reserve stores rec under key with SET NX, reading the existing record
+// when the key is taken
+func (s *RedisStore) Reserve(ctx context.Context, key string, rec Record, ttl time.Duration) (*Record, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The existing record can expire between SET and GET; try again then
+	for range 3 {
+		_, err := s.client.Do(ctx, "SET", s.prefix+key, string(data), "NX", "PX", millis(ttl))
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, redis.ErrNil) {
+			return nil, err
+		}
+
+		existing, err := redis.String(s.client.Do(ctx, "GET", s.prefix+key))
+		if errors.Is(err, redis.ErrNil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var stored Record
+		if err := json.Unmarshal([]byte(existing), &stored); err != nil {
+			return nil, err
+		}
+		return &stored, nil
+	}
+	return nil, errors.New("idempotency: could not reserve key")
+}
+
+// Save replaces the record under key
+func (s *RedisStore) Save(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Do(ctx, "SET", s.prefix+key, string(data), "PX", millis(ttl))
+	return err
+}
+
+// Delete removes the record under key
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	_, err := s.client.Do(ctx, "DEL", s.prefix+key)
+	return err
+}
+
+func millis(d time.Duration) string {
+	return strconv.FormatInt(max(d.Milliseconds(), 1), 10)
+}
@@ -0,0 +1,49 @@
+// Package idempotency keeps the outcome of requests sent with an
+// Idempotency-Key so that retries can be answered without repeating them
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fitbyte/internal/redis"
+)
+
+// Record is the stored outcome of a request. A record that is not Completed
+// marks a request that is still being processed
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store keeps records for a limited time
+type Store interface {
+	// Reserve stores rec under key if no record exists yet. It returns the
+	// existing record when there is one and nil when rec was stored
+	Reserve(ctx context.Context, key string, rec Record, ttl time.Duration) (*Record, error)
+	// Save replaces the record under key
+	Save(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	// Delete removes the record under key
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore creates a store of the given kind: "memory" or "redis"
+func NewStore(kind, redisURL string) (Store, error) {
+	switch kind {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		client, err := redis.NewClient(redisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisStore(client, "fitbyte:idempotency:"), nil
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q", kind)
+	}
+}
@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fitbyte/internal/redis"
+	"fitbyte/internal/redis/redistest"
+)
+
+// testStore checks the behaviour every Store shares
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	pending := Record{Fingerprint: "abc"}
+
+	existing, err := store.Reserve(ctx, "k", pending, time.Minute)
+	if err != nil || existing != nil {
+		t.Fatalf("first Reserve = %v, %v, want nil, nil", existing, err)
+	}
+	existing, err = store.Reserve(ctx, "k", Record{Fingerprint: "other"}, time.Minute)
+	if err != nil || existing == nil || !reflect.DeepEqual(*existing, pending) {
+		t.Fatalf("second Reserve = %v, %v, want the pending record", existing, err)
+	}
+
+	done := Record{Fingerprint: "abc", Completed: true, Status: http.StatusCreated, Header: http.Header{"Etag": {`"1"`}}, Body: []byte(`{"ok":true}`)}
+	if err := store.Save(ctx, "k", done, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	existing, err = store.Reserve(ctx, "k", pending, time.Minute)
+	if err != nil || existing == nil || !reflect.DeepEqual(*existing, done) {
+		t.Fatalf("Reserve after Save = %v, %v, want the saved record", existing, err)
+	}
+
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	existing, err = store.Reserve(ctx, "k", pending, time.Minute)
+	if err != nil || existing != nil {
+		t.Fatalf("Reserve after Delete = %v, %v, want nil, nil", existing, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := store.Reserve(ctx, "k", Record{Fingerprint: "a"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(59 * time.Second)
+	if existing, _ := store.Reserve(ctx, "k", Record{Fingerprint: "b"}, time.Minute); existing == nil {
+		t.Error("record expired early")
+	}
+	now = now.Add(time.Second)
+	if existing, _ := store.Reserve(ctx, "k", Record{Fingerprint: "b"}, time.Minute); existing != nil {
+		t.Errorf("expired record %+v returned", existing)
+	}
+	// Later sweeps drop expired records from memory
+	now = now.Add(2 * time.Minute)
+	if _, err := store.Reserve(ctx, "other", Record{}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.entries["k"]; ok {
+		t.Error("expired record was not swept")
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	// SET, GET and DEL on a map, without expiry
+	var mu sync.Mutex
+	values := map[string]string{}
+	srv := redistest.NewServer(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "SET":
+			if _, ok := values[args[1]]; ok && strings.EqualFold(args[3], "NX") {
+				return "$-1\r\n"
+			}
+			values[args[1]] = args[2]
+			return "+OK\r\n"
+		case "GET":
+			if v, ok := values[args[1]]; ok {
+				return redistest.Bulk(v)
+			}
+			return "$-1\r\n"
+		case "DEL":
+			delete(values, args[1])
+			return redistest.Int(1)
+		}
+		return "-ERR unknown command\r\n"
+	})
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	testStore(t, NewRedisStore(client, "test:"))
+	for _, cmd := range srv.Commands() {
+		if !strings.HasPrefix(cmd[1], "test:") {
+			t.Errorf("%v does not use the prefix", cmd)
+		}
+		if strings.EqualFold(cmd[0], "SET") && (cmd[len(cmd)-2] != "PX" || cmd[len(cmd)-1] == "0") {
+			t.Errorf("%v does not expire", cmd)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Policy configures when attempts are slowed down and locked out
+type Policy struct {
+	// MaxEmailFailures locks an account after this many failures in Window
+	MaxEmailFailures int
+	// MaxIPFailures locks a client IP after this many failures in Window
+	MaxIPFailures int
+	// Window is how long failures are remembered
+	Window time.Duration
+	// LockoutDuration is how long a lock lasts
+	LockoutDuration time.Duration
+	// BaseDelay is doubled for every failure after the first, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// LockedError is returned when an email or IP is locked out
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again after %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+// Guard tracks failed logins per email and per IP
+type Guard struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+// NewGuard creates a new guard
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, now: time.Now}
+}
+
+// EmailKey returns the store key for an email address
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey returns the store key for a client IP
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns a *LockedError if either the email or the IP is locked
+func (g *Guard) Check(ctx context.Context, email, ip string) error {
+	now := g.now()
+	var until time.Time
+	for _, key := range []string{EmailKey(email), IPKey(ip)} {
+		entry, ok, err := g.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if ok && entry.Locked(now) && entry.LockedUntil.After(until) {
+			until = entry.LockedUntil
+		}
+	}
+	if !until.IsZero() {
+		return &LockedError{Until: until}
+	}
+	return nil
+}
+
+// Delay returns how long to wait before evaluating a login attempt, based on
+// recent failures for the email
+func (g *Guard) Delay(ctx context.Context, email string) (time.Duration, error) {
+	entry, ok, err := g.store.Get(ctx, EmailKey(email))
+	if err != nil || !ok || entry.Failures == 0 || g.policy.BaseDelay <= 0 {
+		return 0, err
+	}
+
+	delay := g.policy.BaseDelay
+	for i := 1; i < entry.Failures && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if g.policy.MaxDelay > 0 && delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	return delay, nil
+}
+
+// RecordFailure counts a failed attempt against the email and the IP. It
+// returns a *LockedError if the failure caused either to be locked
+func (g *Guard) RecordFailure(ctx context.Context, email, ip string) error {
+	emailUntil, err := g.fail(ctx, EmailKey(email), g.policy.MaxEmailFailures)
+	if err != nil {
+		return err
+	}
+	ipUntil, err := g.fail(ctx, IPKey(ip), g.policy.MaxIPFailures)
+	if err != nil {
+		return err
+	}
+
+	if ipUntil.After(emailUntil) {
+		emailUntil = ipUntil
+	}
+	if !emailUntil.IsZero() {
+		return &LockedError{Until: emailUntil}
+	}
+	return nil
+}
+
+// RecordSuccess clears failures for the email. IP failures are kept so that
+// one valid account cannot be used to reset a credential stuffing run
+func (g *Guard) RecordSuccess(ctx context.Context, email string) error {
+	return g.store.Delete(ctx, EmailKey(email))
+}
+
+// Clear removes the lockout state for a key
+func (g *Guard) Clear(ctx context.Context, key string) error {
+	return g.store.Delete(ctx, key)
+}
+
+// List returns all tracked entries
+func (g *Guard) List(ctx context.Context) ([]Entry, error) {
+	return g.store.List(ctx)
+}
+
+func (g *Guard) fail(ctx context.Context, key string, max int) (time.Time, error) {
+	now := g.now()
+	entry, ok, err := g.store.Get(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok || now.Sub(entry.FirstFailure) > g.policy.Window {
+		entry = Entry{Key: key, FirstFailure: now}
+	}
+
+	entry.Failures++
+	entry.LastFailure = now
+	ttl := g.policy.Window
+	if max > 0 && entry.Failures >= max {
+		entry.LockedUntil = now.Add(g.policy.LockoutDuration)
+		if g.policy.LockoutDuration > ttl {
+			ttl = g.policy.LockoutDuration
+		}
+	}
+
+	if err := g.store.Save(ctx, entry, ttl); err != nil {
+		return time.Time{}, err
+	}
+	if entry.Locked(now) {
+		return entry.LockedUntil, nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,233 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source shared by a guard and its store
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+var testPolicy = Policy{
+	MaxEmailFailures: 3,
+	MaxIPFailures:    5,
+	Window:           15 * time.Minute,
+	LockoutDuration:  time.Hour,
+	BaseDelay:        time.Second,
+	MaxDelay:         4 * time.Second,
+}
+
+func newTestGuard() (*Guard, *clock) {
+	c := &clock{t: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = c.now
+	g := NewGuard(store, testPolicy)
+	g.now = c.now
+	return g, c
+}
+
+// lockedUntil returns when err says the lock ends, or the zero time when err
+// is nil
+func lockedUntil(t *testing.T, err error) time.Time {
+	t.Helper()
+	if err == nil {
+		return time.Time{}
+	}
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("error = %v, want *LockedError", err)
+	}
+	return locked.Until
+}
+
+func TestGuardLocksEmail(t *testing.T) {
+	ctx := context.Background()
+	g, c := newTestGuard()
+
+	for i := 1; i < testPolicy.MaxEmailFailures; i++ {
+		if err := g.RecordFailure(ctx, "a@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("failure %d: %v", i, err)
+		}
+		c.advance(time.Minute)
+	}
+	until := lockedUntil(t, g.RecordFailure(ctx, "A@Example.com ", "10.0.0.2"))
+	if want := c.now().Add(testPolicy.LockoutDuration); !until.Equal(want) {
+		t.Errorf("locked until %v, want %v", until, want)
+	}
+
+	// The lock covers the email from any IP, but not other emails
+	if got := lockedUntil(t, g.Check(ctx, "a@example.com", "10.0.0.9")); !got.Equal(until) {
+		t.Errorf("Check() locked until %v, want %v", got, until)
+	}
+	if err := g.Check(ctx, "b@example.com", "10.0.0.1"); err != nil {
+		t.Errorf("Check() other email = %v", err)
+	}
+
+	// It lasts LockoutDuration, even though the window is shorter
+	c.t = until.Add(-time.Second)
+	if err := g.Check(ctx, "a@example.com", "10.0.0.9"); err == nil {
+		t.Error("lock ended early")
+	}
+	c.t = until
+	if err := g.Check(ctx, "a@example.com", "10.0.0.9"); err != nil {
+		t.Errorf("Check() after lock = %v", err)
+	}
+}
+
+func TestGuardLocksIP(t *testing.T) {
+	ctx := context.Background()
+	g, c := newTestGuard()
+
+	// Credential stuffing: one failure per email from the same IP
+	var err error
+	emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+	for i, email := range emails {
+		err = g.RecordFailure(ctx, email, "10.0.0.1")
+		if i < len(emails)-1 && err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+	}
+	until := lockedUntil(t, err)
+	if want := c.now().Add(testPolicy.LockoutDuration); !until.Equal(want) {
+		t.Errorf("locked until %v, want %v", until, want)
+	}
+
+	if err := g.Check(ctx, "new@example.com", "10.0.0.1"); err == nil {
+		t.Error("locked IP can try another email")
+	}
+	if err := g.Check(ctx, "a@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("Check() from another IP = %v", err)
+	}
+
+	// Logging in successfully clears the email, not the IP
+	if err := g.RecordSuccess(ctx, "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(ctx, "a@example.com", "10.0.0.1"); err == nil {
+		t.Error("success cleared the IP lock")
+	}
+}
+
+func TestGuardWindow(t *testing.T) {
+	ctx := context.Background()
+	g, c := newTestGuard()
+
+	// Failures spread out further than the window never lock
+	for i := range 2 * testPolicy.MaxEmailFailures {
+		if i > 0 && i%2 == 0 {
+			c.advance(testPolicy.Window + time.Second)
+		}
+		if err := g.RecordFailure(ctx, "a@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("failure %d: %v", i+1, err)
+		}
+	}
+
+	entry, ok, err := g.store.Get(ctx, EmailKey("a@example.com"))
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if entry.Failures != 2 {
+		t.Errorf("failures = %d, want 2 since the window restarted", entry.Failures)
+	}
+
+	// And they are forgotten once the window has passed
+	c.advance(testPolicy.Window + time.Second)
+	if _, ok, _ := g.store.Get(ctx, EmailKey("a@example.com")); ok {
+		t.Error("entry outlived the window")
+	}
+}
+
+func TestGuardDelay(t *testing.T) {
+	ctx := context.Background()
+	policy := testPolicy
+	policy.MaxEmailFailures, policy.MaxIPFailures = 0, 0
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 4 * time.Second},
+		{40, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		g, _ := newTestGuard()
+		g.policy = policy
+		for range tt.failures {
+			if err := g.RecordFailure(ctx, "a@example.com", "10.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := g.Delay(ctx, "a@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Delay() after %d failures = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestGuardDelayDisabled(t *testing.T) {
+	ctx := context.Background()
+	g, _ := newTestGuard()
+	g.policy.BaseDelay = 0
+	if err := g.RecordFailure(ctx, "a@example.com", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := g.Delay(ctx, "a@example.com"); err != nil || got != 0 {
+		t.Errorf("Delay() = %v, %v, want 0", got, err)
+	}
+}
+
+func TestMemoryStoreList(t *testing.T) {
+	ctx := context.Background()
+	g, c := newTestGuard()
+	for _, ip := range []string{"10.0.0.2", "10.0.0.1"} {
+		if err := g.RecordFailure(ctx, "a@example.com", ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := g.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	want := []string{"email:a@example.com", "ip:10.0.0.1", "ip:10.0.0.2"}
+	if len(keys) != len(want) {
+		t.Fatalf("List() keys = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("List() keys = %v, want %v", keys, want)
+			break
+		}
+	}
+
+	if err := g.Clear(ctx, IPKey("10.0.0.1")); err != nil {
+		t.Fatal(err)
+	}
+	c.advance(testPolicy.Window + time.Second)
+	if entries, _ := g.List(ctx); len(entries) != 0 {
+		t.Errorf("List() after the window = %v", entries)
+	}
+}
@@ -0,0 +1,99 @@
+package lockout
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry tracks failed login attempts for a single key (an email or an IP)
+type Entry struct {
+	Key          string    `json:"key"`
+	Failures     int       `json:"failures"`
+	FirstFailure time.Time `json:"first_failure"`
+	LastFailure  time.Time `json:"last_failure"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+// Locked reports whether the entry is locked at the given time
+func (e Entry) Locked(now time.Time) bool {
+	return now.Before(e.LockedUntil)
+}
+
+// Store persists lockout entries
+type Store interface {
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	Save(ctx context.Context, entry Entry, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context) ([]Entry, error)
+}
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	entry   Entry
+	expires time.Time
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the entry for key, if it exists and has not expired
+func (s *MemoryStore) Get(_ context.Context, key string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || !s.now().Before(e.expires) {
+		delete(s.entries, key)
+		return Entry{}, false, nil
+	}
+	return e.entry, true, nil
+}
+
+// Save stores entry until ttl elapses
+func (s *MemoryStore) Save(_ context.Context, entry Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.Key] = memoryEntry{entry: entry, expires: s.now().Add(ttl)}
+	return nil
+}
+
+// Delete removes the entry for key
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// List returns all unexpired entries ordered by key
+func (s *MemoryStore) List(_ context.Context) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entries := make([]Entry, 0, len(s.entries))
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+			continue
+		}
+		entries = append(entries, e.entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
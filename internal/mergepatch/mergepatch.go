@@ -0,0 +1,66 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396)
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ContentType is the media type of a JSON merge patch document
+const ContentType = "application/merge-patch+json"
+
+// ErrInvalidPatch is returned when a patch is not valid JSON
+var ErrInvalidPatch = errors.New("merge patch must be a valid JSON document")
+
+// Apply applies patch to the JSON document target and returns the result.
+// Members of patch replace those of target, members set to null are removed
+// and nested objects are merged recursively
+func Apply(target, patch []byte) ([]byte, error) {
+	p, err := decode(patch)
+	if err != nil {
+		return nil, ErrInvalidPatch
+	}
+	t, err := decode(target)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(t, p))
+}
+
+// merge implements the MergePatch function of RFC 7396, section 2
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = merge(t[name], value)
+	}
+	return t
+}
+
+// decode parses data keeping numbers as json.Number, so that integers survive
+// the round trip unchanged
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	return v, nil
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextRoleKey is the gin context key holding the authenticated user's role
+const ContextRoleKey = "role"
+
+// Authenticator resolves a bearer token to the user it was issued for. It
+// fails with auth.ErrInvalidToken or auth.ErrRevokedToken for tokens that
+// must be rejected
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*models.User, error)
+}
+
+// Auth returns a gin.HandlerFunc that requires a valid bearer token and
+// stores the authenticated user's ID and role under ContextUserIDKey and
+// ContextRoleKey. The role is read on every request, so changes apply at once
+func Auth(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			abortUnauthorized(c, "Missing bearer token")
+			return
+		}
+
+		user, err := authenticator.Authenticate(c.Request.Context(), token)
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrRevokedToken) {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+		if err != nil {
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+
+		c.Set(ContextUserIDKey, user.ID)
+		c.Set(ContextRoleKey, user.Role)
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), user.ID))
+		c.Next()
+	}
+}
+
+// RequireRole returns a gin.HandlerFunc that only lets users with one of
+// roles through. It must run after Auth
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(ContextRoleKey)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+			Success: false,
+			Error:   "Insufficient permissions",
+			Code:    http.StatusForbidden,
+		})
+	}
+}
+
+// UserID returns the authenticated user's ID set by Auth
+func UserID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(ContextUserIDKey)
+	if !ok {
+		return 0, false
+	}
+	userID, ok := id.(uint)
+	return userID, ok
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+		Success: false,
+		Error:   message,
+		Code:    http.StatusUnauthorized,
+	})
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"fitbyte/internal/idempotency"
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// IdempotencyKeyHeader is the request header identifying a retryable request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// idempotencyLockTTL is how long a request in progress holds its key. It
+// only matters if the instance dies before the response is stored
+const idempotencyLockTTL = time.Minute
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency returns a gin.HandlerFunc that makes requests carrying an
+// Idempotency-Key header safe to retry. The first response for a key is
+// stored for ttl, scoped to the authenticated user (or client IP), and
+// replayed for retries with the same body; reusing a key with a different
+// body is rejected with 422, and a retry arriving while the first request is
+// still running gets 409. Server errors and panics are not stored, so they
+// can be retried. Store errors are logged to log
+func Idempotency(store idempotency.Store, ttl time.Duration, log zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortIdempotency(c, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortIdempotency(c, http.StatusBadRequest, "Request body could not be read")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := "ip:" + c.ClientIP()
+		if userID, ok := c.Get(ContextUserIDKey); ok {
+			scope = fmt.Sprintf("user:%v", userID)
+		}
+		storeKey := scope + ":" + key
+		fingerprint := fingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		ctx := c.Request.Context()
+		existing, err := store.Reserve(ctx, storeKey, idempotency.Record{Fingerprint: fingerprint}, idempotencyLockTTL)
+		if err != nil {
+			// Fail open, like the rate limiter: a broken store should not
+			// take the API down
+			log.Error().Err(err).Msg("Idempotency store failed")
+			c.Next()
+			return
+		}
+
+		switch {
+		case existing == nil:
+		case existing.Fingerprint != fingerprint:
+			abortIdempotency(c, http.StatusUnprocessableEntity, IdempotencyKeyHeader+" was already used for a different request")
+			return
+		case !existing.Completed:
+			abortIdempotency(c, http.StatusConflict, "A request with this "+IdempotencyKeyHeader+" is still being processed")
+			return
+		default:
+			for name, values := range existing.Header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Status(existing.Status)
+			_, _ = c.Writer.Write(existing.Body)
+			c.Abort()
+			return
+		}
+
+		// The outcome is stored even if the client has gone away meanwhile
+		ctx = context.WithoutCancel(ctx)
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		// A panicking handler leaves no response to store, so its key is
+		// released for a retry while the panic unwinds to the recovery
+		// middleware
+		returned := false
+		defer func() {
+			if returned {
+				return
+			}
+			if err := store.Delete(ctx, storeKey); err != nil {
+				log.Error().Err(err).Msg("Idempotency store failed")
+			}
+		}()
+		c.Next()
+		returned = true
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = store.Delete(ctx, storeKey)
+		} else {
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if values := recorder.Header().Values(name); len(values) > 0 {
+					header[name] = values
+				}
+			}
+			err = store.Save(ctx, storeKey, idempotency.Record{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      recorder.Status(),
+				Header:      header,
+				Body:        recorder.body.Bytes(),
+			}, ttl)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Idempotency store failed")
+		}
+	}
+}
+
+// fingerprint identifies a request by method, path and body
+func fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func abortIdempotency(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, models.ErrorResponse{
+		Success: false,
+		Error:   message,
+		Code:    status,
+	})
+}
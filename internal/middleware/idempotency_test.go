@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fitbyte/internal/idempotency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// idempotencyServer counts the requests its handlers run. POST /items
+// creates an item, POST /fail fails with the status in the body, POST
+// /panic panics and POST /slow waits for release
+type idempotencyServer struct {
+	router  *gin.Engine
+	calls   atomic.Int32
+	release chan struct{}
+	started chan struct{}
+}
+
+func newIdempotencyServer(store idempotency.Store, log zerolog.Logger) *idempotencyServer {
+	gin.SetMode(gin.TestMode)
+	s := &idempotencyServer{router: gin.New(), release: make(chan struct{}), started: make(chan struct{}, 1)}
+	s.router.Use(Recovery(), func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(ContextUserIDKey, user)
+		}
+	}, Idempotency(store, time.Hour, log))
+
+	s.router.POST("/items", func(c *gin.Context) {
+		n := s.calls.Add(1)
+		c.Header("ETag", `"1"`)
+		c.Header("X-Not-Replayed", "yes")
+		c.JSON(http.StatusCreated, gin.H{"item": n})
+	})
+	s.router.POST("/fail", func(c *gin.Context) {
+		s.calls.Add(1)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+	s.router.POST("/panic", func(c *gin.Context) {
+		s.calls.Add(1)
+		panic("boom")
+	})
+	s.router.POST("/slow", func(c *gin.Context) {
+		s.calls.Add(1)
+		s.started <- struct{}{}
+		<-s.release
+		c.JSON(http.StatusCreated, gin.H{"slow": true})
+	})
+	return s
+}
+
+// send posts body to path with an Idempotency-Key, as user when it is set
+func (s *idempotencyServer) send(path, key, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	s := newIdempotencyServer(idempotency.NewMemoryStore(), zerolog.Nop())
+
+	first := s.send("/items", "k1", "1", `{"a":1}`)
+	retry := s.send("/items", "k1", "1", `{"a":1}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated {
+		t.Fatalf("got %d then %d, want 201 twice", first.Code, retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("replayed %s, want %s", retry.Body, first.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Idempotent-Replayed is not only set on the replay")
+	}
+	if retry.Header().Get("ETag") != `"1"` || retry.Header().Get("X-Not-Replayed") != "" {
+		t.Errorf("replayed headers %v, want only the stored ones", retry.Header())
+	}
+	if n := s.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+
+	// Keys are scoped to the user, and requests without one always run
+	s.send("/items", "k1", "2", `{"a":1}`)
+	s.send("/items", "", "1", `{"a":1}`)
+	s.send("/items", "", "1", `{"a":1}`)
+	if n := s.calls.Load(); n != 4 {
+		t.Errorf("handler ran %d times, want 4", n)
+	}
+}
+
+func TestIdempotencyRejects(t *testing.T) {
+	s := newIdempotencyServer(idempotency.NewMemoryStore(), zerolog.Nop())
+	s.send("/items", "k1", "1", `{"a":1}`)
+
+	tests := []struct {
+		name string
+		path string
+		key  string
+		body string
+		want int
+	}{
+		{"different body", "/items", "k1", `{"a":2}`, http.StatusUnprocessableEntity},
+		{"different path", "/slow", "k1", `{"a":1}`, http.StatusUnprocessableEntity},
+		{"key too long", "/items", strings.Repeat("k", 256), `{"a":1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.send(tt.path, tt.key, "1", tt.body); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+	if n := s.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	s := newIdempotencyServer(idempotency.NewMemoryStore(), zerolog.Nop())
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.send("/slow", "k1", "1", "{}") }()
+	<-s.started
+
+	// The first request holds the key until it finishes
+	const retries = 5
+	codes := make(chan int, retries)
+	for range retries {
+		go func() { codes <- s.send("/slow", "k1", "1", "{}").Code }()
+	}
+	for range retries {
+		if code := <-codes; code != http.StatusConflict {
+			t.Errorf("retry while in flight got %d, want 409", code)
+		}
+	}
+
+	close(s.release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Errorf("first request got %d, want 201", w.Code)
+	}
+	if w := s.send("/slow", "k1", "1", "{}"); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion got %d, replayed %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if n := s.calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestIdempotencyReleasesFailures(t *testing.T) {
+	for _, path := range []string{"/fail", "/panic"} {
+		t.Run(path, func(t *testing.T) {
+			s := newIdempotencyServer(idempotency.NewMemoryStore(), zerolog.Nop())
+			for i := range 2 {
+				if w := s.send(path, "k1", "1", "{}"); w.Code != http.StatusInternalServerError {
+					t.Errorf("attempt %d got %d, want 500", i+1, w.Code)
+				}
+			}
+			// Each retry ran the handler again instead of getting 409
+			if n := s.calls.Load(); n != 2 {
+				t.Errorf("handler ran %d times, want 2", n)
+			}
+		})
+	}
+}
+
+// brokenStore is an idempotency.Store that is always down
+type brokenStore struct{}
+
+func (brokenStore) Reserve(context.Context, string, idempotency.Record, time.Duration) (*idempotency.Record, error) {
+	return nil, errors.New("store down")
+}
+
+func (brokenStore) Save(context.Context, string, idempotency.Record, time.Duration) error {
+	return errors.New("store down")
+}
+
+func (brokenStore) Delete(context.Context, string) error {
+	return errors.New("store down")
+}
+
+func TestIdempotencyFailsOpen(t *testing.T) {
+	var logs bytes.Buffer
+	s := newIdempotencyServer(brokenStore{}, zerolog.New(&logs))
+	for i := range 2 {
+		if w := s.send("/items", "k1", "1", "{}"); w.Code != http.StatusCreated {
+			t.Errorf("attempt %d got %d, want 201", i+1, w.Code)
+		}
+	}
+	if n := s.calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2", n)
+	}
+	if got := strings.Count(logs.String(), "store down"); got != 2 {
+		t.Errorf("logged %d store errors, want 2:\n%s", got, logs.String())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := fingerprint(http.MethodPost, "/items", []byte("{}"))
+	for i, other := range []string{
+		fingerprint(http.MethodPut, "/items", []byte("{}")),
+		fingerprint(http.MethodPost, "/items/1", []byte("{}")),
+		fingerprint(http.MethodPost, "/items", []byte("{ }")),
+	} {
+		if other == base {
+			t.Errorf("variant %d has the same fingerprint", i)
+		}
+	}
+	if len(base) != 64 {
+		t.Errorf("fingerprint %q is not a SHA-256 hex digest", base)
+	}
+}
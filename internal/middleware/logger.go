@@ -1,24 +1,21 @@
 package middleware
 
 import (
-	"time"
-
 	"github.com/gin-contrib/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
 
-// Logger returns a gin.HandlerFunc for logging requests
-func Logger() gin.HandlerFunc {
+// Logger returns a gin.HandlerFunc for logging requests with the given logger.
+// Method, path, status, client IP, user agent and latency are added per
+// request, and the request ID when RequestID runs first
+func Logger(base zerolog.Logger) gin.HandlerFunc {
 	return logger.SetLogger(
-		logger.WithLogger(func(c *gin.Context, l zerolog.Logger) zerolog.Logger {
-			return l.Output(gin.DefaultWriter).With().
-				Str("method", c.Request.Method).
-				Str("path", c.Request.URL.Path).
-				Str("ip", c.ClientIP()).
-				Str("user_agent", c.Request.UserAgent()).
-				Dur("latency", time.Since(time.Now())).
-				Logger()
+		logger.WithLogger(func(c *gin.Context, _ zerolog.Logger) zerolog.Logger {
+			if id := c.GetString(ContextRequestIDKey); id != "" {
+				return base.With().Str("request_id", id).Logger()
+			}
+			return base
 		}),
 	)
 }
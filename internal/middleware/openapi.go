@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// MaxValidatedBody is the largest JSON request body the validator reads
+const MaxValidatedBody = 1 << 20
+
+// OpenAPIValidator returns a gin.HandlerFunc that rejects requests whose path
+// parameters, query parameters or JSON body do not match the operation in doc.
+// When validateResponses is set, JSON responses are checked too and any
+// mismatch is logged as contract drift; responses are never altered. Bodies
+// over MaxValidatedBody are rejected with 413
+func OpenAPIValidator(doc *openapi.Document, validateResponses bool, log zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := doc.Operation(c.Request.Method, openapi.Path(c.FullPath()))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		errs, err := validateRequest(doc, op, c)
+		if err != nil {
+			status, message := http.StatusBadRequest, "Request body could not be read"
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status, message = http.StatusRequestEntityTooLarge, "Request body must be at most "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes"
+			}
+			c.AbortWithStatusJSON(status, models.ErrorResponse{Success: false, Error: message, Code: status})
+			return
+		}
+		if len(errs) > 0 {
+			messages := make([]string, len(errs))
+			for i, err := range errs {
+				messages[i] = err.Error()
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Success: false,
+				Error:   "Request does not match the API specification: " + strings.Join(messages, "; "),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+
+		if !validateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		for _, err := range validateResponse(doc, op, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()) {
+			log.Warn().
+				Str("method", c.Request.Method).
+				Str("route", c.FullPath()).
+				Int("status", recorder.Status()).
+				Str("path", err.Path).
+				Msg("Response does not match the API specification: " + err.Message)
+		}
+	}
+}
+
+// validateRequest returns how the request does not match op, or an error when
+// its body cannot be read
+func validateRequest(doc *openapi.Document, op *openapi.Operation, c *gin.Context) ([]openapi.ValidationError, error) {
+	var errs []openapi.ValidationError
+
+	for _, param := range op.Parameters {
+		var raw string
+		var present bool
+		switch param.In {
+		case "path":
+			raw = c.Param(param.Name)
+			present = raw != ""
+		case "query":
+			raw, present = c.GetQuery(param.Name)
+		default:
+			continue
+		}
+
+		path := param.In + "." + param.Name
+		if !present {
+			if param.Required {
+				errs = append(errs, openapi.ValidationError{Path: path, Message: "is required"})
+			}
+			continue
+		}
+
+		value, ok := coerce(param.Schema, raw)
+		if !ok {
+			errs = append(errs, openapi.ValidationError{Path: path, Message: "must be of type " + param.Schema.Type})
+			continue
+		}
+		errs = append(errs, doc.Validate(param.Schema, value, path)...)
+	}
+
+	if op.RequestBody == nil {
+		return errs, nil
+	}
+	media, ok := jsonMedia(op.RequestBody.Content)
+	if !ok {
+		return errs, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxValidatedBody))
+	if err != nil {
+		return nil, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			errs = append(errs, openapi.ValidationError{Path: "body", Message: "is required"})
+		}
+		return errs, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return append(errs, openapi.ValidationError{Path: "body", Message: "must be valid JSON"}), nil
+	}
+	return append(errs, doc.Validate(media.Schema, value, "body")...), nil
+}
+
+// jsonMedia returns the JSON media type of content: application/json or a
+// structured +json type such as application/merge-patch+json
+func jsonMedia(content map[string]openapi.MediaType) (openapi.MediaType, bool) {
+	for contentType, media := range content {
+		if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+			return media, true
+		}
+	}
+	return openapi.MediaType{}, false
+}
+
+func validateResponse(doc *openapi.Document, op *openapi.Operation, status int, contentType string, body []byte) []openapi.ValidationError {
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []openapi.ValidationError{{Path: "status", Message: strconv.Itoa(status) + " is not documented"}}
+	}
+	media, ok := res.Content["application/json"]
+	if !ok || !strings.HasPrefix(contentType, "application/json") {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []openapi.ValidationError{{Path: "body", Message: "is not valid JSON"}}
+	}
+	return doc.Validate(media.Schema, value, "body")
+}
+
+// coerce converts a path or query string to the JSON type of schema
+func coerce(schema *openapi.Schema, raw string) (interface{}, bool) {
+	switch schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		return n, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	default:
+		return raw, true
+	}
+}
+
+// bodyRecorder keeps a copy of the response body while writing it through
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
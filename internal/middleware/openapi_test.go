@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type itemQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type itemRequest struct {
+	Name  string `json:"name" binding:"required,min=2"`
+	Count int    `json:"count" binding:"omitempty,min=0"`
+}
+
+type item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// newValidatedRouter serves PUT /items/:id, documented to take an
+// itemQuery and an itemRequest and return an item. The handler answers with
+// the data in the X-Data header, so tests can make the response drift
+func newValidatedRouter(validateResponses bool, log zerolog.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	b := openapi.NewBuilder(openapi.Info{Title: "Test", Version: "1"})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/items/:id", Summary: "Replace an item", Tag: "Items",
+		Query: itemQuery{}, Body: itemRequest{}, Response: item{}, Errors: []int{http.StatusNotFound},
+	})
+
+	router := gin.New()
+	router.Use(OpenAPIValidator(b.Document(), validateResponses, log))
+	handler := func(c *gin.Context) {
+		var data interface{} = item{ID: 1, Name: "kettlebell"}
+		if raw := c.GetHeader("X-Data"); raw != "" {
+			json.Unmarshal([]byte(raw), &data)
+		}
+		c.JSON(http.StatusOK, models.APIResponse{Success: true, Message: "ok", Data: data})
+	}
+	router.PUT("/items/:id", handler)
+	router.PUT("/other/:id", handler)
+	return router
+}
+
+func TestOpenAPIValidatorRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		wantErr []string
+	}{
+		{name: "valid", path: "/items/1?limit=10", body: `{"name":"kettlebell","count":2}`},
+		{name: "optional query omitted", path: "/items/1", body: `{"name":"kettlebell"}`},
+		{name: "path parameter not a number", path: "/items/abc", body: `{"name":"kettlebell"}`, wantErr: []string{"path.id: must be of type integer"}},
+		{name: "path parameter below minimum", path: "/items/0", body: `{"name":"kettlebell"}`, wantErr: []string{"path.id: must be at least 1"}},
+		{name: "query not a number", path: "/items/1?limit=many", body: `{"name":"kettlebell"}`, wantErr: []string{"query.limit: must be of type integer"}},
+		{name: "query above maximum", path: "/items/1?limit=1000", body: `{"name":"kettlebell"}`, wantErr: []string{"query.limit: must be at most 100"}},
+		{name: "missing body", path: "/items/1", body: "", wantErr: []string{"body: is required"}},
+		{name: "malformed body", path: "/items/1", body: `{"name":`, wantErr: []string{"body: must be valid JSON"}},
+		{name: "missing field", path: "/items/1", body: `{"count":1}`, wantErr: []string{"body.name: is required"}},
+		{
+			name: "several problems", path: "/items/1?limit=0", body: `{"name":"k","count":-1}`,
+			wantErr: []string{"query.limit: must be at least 1", "body.count: must be at least 0", "body.name: must be at least 2 characters"},
+		},
+		{name: "undocumented route", path: "/other/abc", body: "not json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newValidatedRouter(false, zerolog.Nop())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if len(tt.wantErr) == 0 {
+				if w.Code != http.StatusOK {
+					t.Errorf("status = %d, want 200: %s", w.Code, w.Body)
+				}
+				return
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var res models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Success || res.Code != http.StatusBadRequest || !strings.HasPrefix(res.Error, "Request does not match the API specification: ") {
+				t.Errorf("response = %+v, want the error envelope", res)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(res.Error, want) {
+					t.Errorf("error %q does not mention %q", res.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidatorBodyLimit(t *testing.T) {
+	// name pads a body to size bytes
+	name := func(size int) string {
+		return `{"name":"` + strings.Repeat("a", size-len(`{"name":""}`)) + `"}`
+	}
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"at the limit", name(MaxValidatedBody), http.StatusOK},
+		{"over the limit", name(MaxValidatedBody + 1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newValidatedRouter(false, zerolog.Nop())
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/items/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %.200s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidatorResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		validate bool
+		wantLog  string
+	}{
+		{name: "matches", validate: true},
+		{name: "drift", data: `{"id":"one","name":"kettlebell"}`, validate: true, wantLog: "body.data.id"},
+		{name: "drift not validated", data: `{"id":"one","name":"kettlebell"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			router := newValidatedRouter(tt.validate, zerolog.New(&logs))
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/items/1", strings.NewReader(`{"name":"kettlebell"}`))
+			if tt.data != "" {
+				req.Header.Set("X-Data", tt.data)
+			}
+			router.ServeHTTP(w, req)
+
+			// The response goes out unchanged either way
+			if w.Code != http.StatusOK || (tt.data != "" && !strings.Contains(w.Body.String(), `"id":"one"`)) {
+				t.Errorf("response = %d %s", w.Code, w.Body)
+			}
+			if tt.wantLog == "" {
+				if logs.Len() > 0 {
+					t.Errorf("logged %s", logs.String())
+				}
+				return
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log %q: %v", logs.String(), err)
+			}
+			if entry["level"] != "warn" || entry["path"] != tt.wantLog || entry["route"] != "/items/:id" {
+				t.Errorf("log entry = %v, want a warning about %s", entry, tt.wantLog)
+			}
+		})
+	}
+}
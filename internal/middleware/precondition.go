@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"fitbyte/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch returns a gin.HandlerFunc that rejects PUT, PATCH and DELETE
+// requests without an If-Match header with 428 Precondition Required, so
+// clients cannot overwrite changes they have not seen. Handlers compare the
+// header against the resource's ETag. Nothing is enforced when required is false
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required || c.GetHeader("If-Match") != "" {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.AbortWithStatusJSON(http.StatusPreconditionRequired, models.ErrorResponse{
+				Success: false,
+				Error:   "If-Match header required; use the ETag returned by GET",
+				Code:    http.StatusPreconditionRequired,
+			})
+		default:
+			c.Next()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		method   string
+		ifMatch  string
+		want     int
+	}{
+		{"not required", false, http.MethodPut, "", http.StatusOK},
+		{"put", true, http.MethodPut, "", http.StatusPreconditionRequired},
+		{"patch", true, http.MethodPatch, "", http.StatusPreconditionRequired},
+		{"delete", true, http.MethodDelete, "", http.StatusPreconditionRequired},
+		{"with header", true, http.MethodPatch, `"1"`, http.StatusOK},
+		{"get", true, http.MethodGet, "", http.StatusOK},
+		{"post", true, http.MethodPost, "", http.StatusOK},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RequireIfMatch(tt.required))
+			router.Handle(tt.method, "/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
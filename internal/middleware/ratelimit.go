@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// ContextUserIDKey is the gin context key holding the authenticated user's ID
+const ContextUserIDKey = "userID"
+
+// Rate limit policy names used by the routes
+const (
+	RateLimitDefault = "default"
+	RateLimitLogin   = "login"
+	RateLimitUpload  = "upload"
+)
+
+// RateLimiter applies named token bucket policies to route groups
+type RateLimiter struct {
+	store    ratelimit.Store
+	policies map[string]ratelimit.Policy
+	log      zerolog.Logger
+}
+
+// NewRateLimiter creates a rate limiter backed by store, logging store
+// errors to log
+func NewRateLimiter(store ratelimit.Store, policies map[string]ratelimit.Policy, log zerolog.Logger) *RateLimiter {
+	return &RateLimiter{store: store, policies: policies, log: log}
+}
+
+// Limit returns a gin.HandlerFunc enforcing the named policy. Requests are
+// keyed by authenticated user ID when available, otherwise by client IP
+func (r *RateLimiter) Limit(name string) gin.HandlerFunc {
+	policy, ok := r.policies[name]
+	if !ok {
+		panic(fmt.Sprintf("middleware: unknown rate limit policy %q", name))
+	}
+
+	return func(c *gin.Context) {
+		key := name + ":ip:" + c.ClientIP()
+		if userID, ok := c.Get(ContextUserIDKey); ok {
+			key = fmt.Sprintf("%s:user:%v", name, userID)
+		}
+
+		res, err := r.store.Take(c.Request.Context(), key, policy)
+		if err != nil {
+			// Fail open: a broken limiter store should not take the API down
+			r.log.Error().Err(err).Str("policy", name).Msg("Rate limiter store failed")
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter),
+				Code:    http.StatusTooManyRequests,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// failingStore is a ratelimit.Store that is always down
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Policy) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+// newLimitedRouter serves GET / limited by policy, authenticating the
+// user named in the X-User header
+func newLimitedRouter(store ratelimit.Store, policy ratelimit.Policy, log zerolog.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(store, map[string]ratelimit.Policy{RateLimitDefault: policy}, log)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.Set(ContextUserIDKey, user)
+		}
+	}, limiter.Limit(RateLimitDefault))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestRateLimiter(t *testing.T) {
+	router := newLimitedRouter(ratelimit.NewMemoryStore(), ratelimit.Policy{Limit: 2, Period: time.Minute}, zerolog.Nop())
+
+	steps := []struct {
+		user          string
+		want          int
+		wantRemaining string
+	}{
+		{"", http.StatusOK, "1"},
+		{"", http.StatusOK, "0"},
+		{"", http.StatusTooManyRequests, "0"},
+		// Authenticated requests are counted per user, not per IP
+		{"7", http.StatusOK, "1"},
+	}
+	for i, step := range steps {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if step.user != "" {
+			req.Header.Set("X-User", step.user)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != step.want || w.Header().Get("RateLimit-Remaining") != step.wantRemaining {
+			t.Errorf("step %d: got %d with %s remaining, want %d with %s", i, w.Code, w.Header().Get("RateLimit-Remaining"), step.want, step.wantRemaining)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("step %d: RateLimit-Limit = %q, want 2", i, w.Header().Get("RateLimit-Limit"))
+		}
+		if step.want == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "30" {
+			t.Errorf("step %d: Retry-After = %q, want 30", i, w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	var logs bytes.Buffer
+	router := newLimitedRouter(failingStore{}, ratelimit.Policy{Limit: 1, Period: time.Minute}, zerolog.New(&logs))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 while the store is down", w.Code)
+	}
+	if !strings.Contains(logs.String(), `"level":"error"`) || !strings.Contains(logs.String(), "connection refused") {
+		t.Errorf("logged %q, want the store error", logs.String())
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Achievement is a badge a user has earned
+type Achievement struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_achievements_user_code;not null"`
+	Code   string `json:"code" gorm:"type:varchar(50);uniqueIndex:idx_achievements_user_code;not null"`
+	// EarnedAt is when the activity that met the badge's rule was done
+	EarnedAt  time.Time `json:"earned_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AchievementResponse represents the response payload for an earned badge
+type AchievementResponse struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EarnedAt    time.Time `json:"earnedAt" doc:"When the activity that earned the badge was done"`
+}
+
+// AchievementSummary represents a user's streaks and earned badges. Days
+// are counted in the user's time zone
+type AchievementSummary struct {
+	CurrentStreak int                   `json:"currentStreak" doc:"Consecutive active days up to today or yesterday"`
+	LongestStreak int                   `json:"longestStreak" doc:"Most consecutive active days ever"`
+	Achievements  []AchievementResponse `json:"achievements" doc:"Earned badges, oldest first"`
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"time"
+)
+
+// Activity types
+const (
+	ActivityWalking    = "Walking"
+	ActivityYoga       = "Yoga"
+	ActivityStretching = "Stretching"
+	ActivityCycling    = "Cycling"
+	ActivitySwimming   = "Swimming"
+	ActivityDancing    = "Dancing"
+	ActivityHiking     = "Hiking"
+	ActivityRunning    = "Running"
+	ActivityHIIT       = "HIIT"
+	ActivityJumpRope   = "JumpRope"
+)
+
+// CaloriesPerMinute is the calories burned per minute for each activity type
+var CaloriesPerMinute = map[string]int{
+	ActivityWalking:    4,
+	ActivityYoga:       4,
+	ActivityStretching: 4,
+	ActivityCycling:    8,
+	ActivitySwimming:   8,
+	ActivityDancing:    8,
+	ActivityHiking:     10,
+	ActivityRunning:    10,
+	ActivityHIIT:       10,
+	ActivityJumpRope:   10,
+}
+
+// METs is the metabolic equivalent of each activity type: the energy it
+// takes relative to resting, in kcal per kilogram of body weight per hour
+var METs = map[string]float64{
+	ActivityWalking:    3.5,
+	ActivityYoga:       2.5,
+	ActivityStretching: 2.3,
+	ActivityCycling:    7.5,
+	ActivitySwimming:   6,
+	ActivityDancing:    5,
+	ActivityHiking:     6,
+	ActivityRunning:    9.8,
+	ActivityHIIT:       8,
+	ActivityJumpRope:   11,
+}
+
+// Activity represents a workout logged by a user
+type Activity struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	UserID            uint      `json:"user_id" gorm:"index;not null"`
+	ActivityType      string    `json:"activityType" gorm:"type:varchar(20);not null"`
+	DoneAt            time.Time `json:"doneAt" gorm:"index;not null"`
+	DurationInMinutes int       `json:"durationInMinutes" gorm:"not null"`
+	CaloriesBurned    int       `json:"caloriesBurned" gorm:"not null"`
+	DistanceInMeters  *float64  `json:"distanceInMeters"`
+	// ElevationGainInMeters is the total climb, known for imported activities
+	ElevationGainInMeters *float64 `json:"elevationGainInMeters"`
+	// Heart rates are in beats per minute and power in watts
+	AverageHeartRate *int `json:"averageHeartRate"`
+	MaxHeartRate     *int `json:"maxHeartRate"`
+	AveragePower     *int `json:"averagePower"`
+	MaxPower         *int `json:"maxPower"`
+	// HasRoute is set when the activity has an ActivityTrack
+	HasRoute  bool      `json:"hasRoute" gorm:"not null;default:false"`
+	Version   uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateActivityRequest represents the request payload for logging an activity
+type CreateActivityRequest struct {
+	ActivityType          string              `json:"activityType" binding:"required,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	DoneAt                time.Time           `json:"doneAt" binding:"required"`
+	DurationInMinutes     int                 `json:"durationInMinutes" binding:"required,min=1"`
+	DistanceInMeters      *float64            `json:"distanceInMeters,omitempty" binding:"omitempty,gt=0"`
+	ElevationGainInMeters *float64            `json:"elevationGainInMeters,omitempty" binding:"omitempty,min=0"`
+	AverageHeartRate      *int                `json:"averageHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	MaxHeartRate          *int                `json:"maxHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	AveragePower          *int                `json:"averagePower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+	MaxPower              *int                `json:"maxPower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+	Track                 []TrackPointRequest `json:"track,omitempty" binding:"omitempty,dive" doc:"GPS track of at least two points; the distance and elevation gain are computed from it when not given"`
+}
+
+// ReplaceActivityRequest represents the request payload for replacing an activity
+type ReplaceActivityRequest struct {
+	ActivityType          string    `json:"activityType" binding:"required,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	DoneAt                time.Time `json:"doneAt" binding:"required"`
+	DurationInMinutes     int       `json:"durationInMinutes" binding:"required,min=1"`
+	DistanceInMeters      *float64  `json:"distanceInMeters,omitempty" binding:"omitempty,gt=0"`
+	ElevationGainInMeters *float64  `json:"elevationGainInMeters,omitempty" binding:"omitempty,min=0"`
+	AverageHeartRate      *int      `json:"averageHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	MaxHeartRate          *int      `json:"maxHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	AveragePower          *int      `json:"averagePower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+	MaxPower              *int      `json:"maxPower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+}
+
+// UpdateActivityRequest represents a JSON merge patch for an activity:
+// omitted fields are left unchanged
+type UpdateActivityRequest struct {
+	ActivityType          *string    `json:"activityType,omitempty" binding:"omitempty,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	DoneAt                *time.Time `json:"doneAt,omitempty"`
+	DurationInMinutes     *int       `json:"durationInMinutes,omitempty" binding:"omitempty,min=1"`
+	DistanceInMeters      *float64   `json:"distanceInMeters,omitempty" binding:"omitempty,gt=0"`
+	ElevationGainInMeters *float64   `json:"elevationGainInMeters,omitempty" binding:"omitempty,min=0"`
+	AverageHeartRate      *int       `json:"averageHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	MaxHeartRate          *int       `json:"maxHeartRate,omitempty" binding:"omitempty,min=1" doc:"Beats per minute"`
+	AveragePower          *int       `json:"averagePower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+	MaxPower              *int       `json:"maxPower,omitempty" binding:"omitempty,min=1" doc:"Watts"`
+}
+
+// ActivityQuery represents the query parameters for listing activities
+type ActivityQuery struct {
+	Limit             int        `form:"limit,default=5" binding:"min=1,max=100"`
+	Offset            int        `form:"offset,default=0" binding:"min=0"`
+	ActivityType      string     `form:"activityType" binding:"omitempty,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	DoneAtFrom        *time.Time `form:"doneAtFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	DoneAtTo          *time.Time `form:"doneAtTo" time_format:"2006-01-02T15:04:05Z07:00"`
+	CaloriesBurnedMin *int       `form:"caloriesBurnedMin" binding:"omitempty,min=0"`
+	CaloriesBurnedMax *int       `form:"caloriesBurnedMax" binding:"omitempty,min=0"`
+}
+
+// ActivityResponse represents the response payload for activity data
+type ActivityResponse struct {
+	ID                    uint      `json:"activityId"`
+	ActivityType          string    `json:"activityType"`
+	DoneAt                time.Time `json:"doneAt"`
+	DurationInMinutes     int       `json:"durationInMinutes"`
+	CaloriesBurned        int       `json:"caloriesBurned"`
+	DistanceInMeters      *float64  `json:"distanceInMeters"`
+	ElevationGainInMeters *float64  `json:"elevationGainInMeters"`
+	AverageHeartRate      *int      `json:"averageHeartRate" doc:"Beats per minute"`
+	MaxHeartRate          *int      `json:"maxHeartRate" doc:"Beats per minute"`
+	AveragePower          *int      `json:"averagePower" doc:"Watts"`
+	MaxPower              *int      `json:"maxPower" doc:"Watts"`
+	HasRoute              bool      `json:"hasRoute" doc:"Whether the activity has a GPS track, served by its route endpoint"`
+	CreatedAt             time.Time `json:"createdAt"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+// ToResponse converts an activity into its API representation
+func (a *Activity) ToResponse() ActivityResponse {
+	return ActivityResponse{
+		ID:                    a.ID,
+		ActivityType:          a.ActivityType,
+		DoneAt:                a.DoneAt,
+		DurationInMinutes:     a.DurationInMinutes,
+		CaloriesBurned:        a.CaloriesBurned,
+		DistanceInMeters:      a.DistanceInMeters,
+		ElevationGainInMeters: a.ElevationGainInMeters,
+		AverageHeartRate:      a.AverageHeartRate,
+		MaxHeartRate:          a.MaxHeartRate,
+		AveragePower:          a.AveragePower,
+		MaxPower:              a.MaxPower,
+		HasRoute:              a.HasRoute,
+		CreatedAt:             a.CreatedAt,
+		UpdatedAt:             a.UpdatedAt,
+	}
+}
+
+// ReplaceRequest returns the replacement payload describing the activity as
+// it is, which merge patches are applied to
+func (a *Activity) ReplaceRequest() ReplaceActivityRequest {
+	return ReplaceActivityRequest{
+		ActivityType:          a.ActivityType,
+		DoneAt:                a.DoneAt,
+		DurationInMinutes:     a.DurationInMinutes,
+		DistanceInMeters:      a.DistanceInMeters,
+		ElevationGainInMeters: a.ElevationGainInMeters,
+		AverageHeartRate:      a.AverageHeartRate,
+		MaxHeartRate:          a.MaxHeartRate,
+		AveragePower:          a.AveragePower,
+		MaxPower:              a.MaxPower,
+	}
+}
+
+// ActivityImportUpload documents the multipart form accepted by the import
+// endpoint
+type ActivityImportUpload struct {
+	File []byte `json:"file" binding:"required" format:"binary" doc:"GPX, TCX or FIT file"`
+}
+
+// ActivityImport represents the result of importing a file
+type ActivityImport struct {
+	Imported   []ActivityResponse `json:"imported"`
+	Duplicates []ActivityResponse `json:"duplicates" doc:"Activities already logged at the start of a workout in the file, which was skipped"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditImmutable is returned when an audit entry is changed or deleted
+var ErrAuditImmutable = errors.New("audit entries are append-only")
+
+// AuditEntry records who did what to which account
+type AuditEntry struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Action string `json:"action" gorm:"type:varchar(50);index;not null"`
+	// ActorID is the user who acted, nil for anonymous requests and
+	// background jobs
+	ActorID *uint `json:"actorId" gorm:"index"`
+	// UserID is the account the entry concerns
+	UserID    *uint                  `json:"userId" gorm:"index"`
+	IP        string                 `json:"ip" gorm:"type:varchar(45)"`
+	RequestID string                 `json:"requestId" gorm:"type:varchar(128);index"`
+	Changes   map[string]FieldChange `json:"changes,omitempty" gorm:"serializer:json;type:text"`
+	Details   map[string]string      `json:"details,omitempty" gorm:"serializer:json;type:text"`
+	CreatedAt time.Time              `json:"createdAt" gorm:"index"`
+}
+
+// FieldChange is the value of a field before and after an edit
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// BeforeUpdate keeps audit entries append-only
+func (AuditEntry) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+// BeforeDelete keeps audit entries append-only
+func (AuditEntry) BeforeDelete(*gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+// AuditQuery represents the query parameters for searching the audit log
+type AuditQuery struct {
+	Action    string     `form:"action"`
+	ActorID   *uint      `form:"actorId"`
+	UserID    *uint      `form:"userId"`
+	RequestID string     `form:"requestId"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" doc:"Only entries at or after this time"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" doc:"Only entries before this time"`
+	Page      int        `form:"page,default=1" binding:"min=1"`
+	Limit     int        `form:"limit,default=50" binding:"min=1,max=100"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RegisterRequest represents the request payload for registering an account
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=32"`
+}
+
+// LoginRequest represents the request payload for logging in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccountRequest confirms the deletion of the authenticated user's
+// account with their password
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// AuthResponse represents the response payload for register and login
+type AuthResponse struct {
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LockoutErrorResponse is returned when login attempts are locked out
+type LockoutErrorResponse struct {
+	Success     bool      `json:"success"`
+	Error       string    `json:"error"`
+	Code        int       `json:"code"`
+	LockedUntil time.Time `json:"locked_until"`
+}
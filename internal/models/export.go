@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Export statuses
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// Export is a requested archive of everything stored about a user
+type Export struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"index;not null"`
+	Status      string     `json:"status" gorm:"type:varchar(20);index;not null"`
+	Error       *string    `json:"error" gorm:"type:text"`
+	Size        int64      `json:"size"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ExportResponse represents the response payload for an export
+type ExportResponse struct {
+	ID                uint       `json:"exportId"`
+	Status            string     `json:"status" doc:"pending, running, completed or failed"`
+	Error             *string    `json:"error"`
+	Size              int64      `json:"size" doc:"Size of the archive in bytes"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	CompletedAt       *time.Time `json:"completedAt"`
+	ExpiresAt         *time.Time `json:"expiresAt" doc:"When the archive and this export are deleted"`
+	DownloadURL       *string    `json:"downloadUrl" doc:"Signed link to the ZIP archive, usable without a token"`
+	DownloadExpiresAt *time.Time `json:"downloadExpiresAt"`
+}
+
+// ToResponse converts an export into its API representation, without a
+// download link
+func (e *Export) ToResponse() ExportResponse {
+	return ExportResponse{
+		ID:          e.ID,
+		Status:      e.Status,
+		Error:       e.Error,
+		Size:        e.Size,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+// FileResponse represents the response payload for an uploaded file
+type FileResponse struct {
+	URI string `json:"uri"`
+}
+
+// FileUpload documents the multipart form accepted by the upload endpoint
+type FileUpload struct {
+	File []byte `json:"file" binding:"required" format:"binary"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+
+	"fitbyte/internal/units"
+)
+
+// Goal types
+const (
+	GoalTargetWeight   = "target_weight"
+	GoalWeeklyCalories = "weekly_calories"
+	GoalWeeklyMinutes  = "weekly_active_minutes"
+	GoalActivityCount  = "activity_count"
+)
+
+// Goal is a target a user works towards
+type Goal struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index;not null"`
+	Type   string `json:"type" gorm:"type:varchar(30);not null"`
+	// Target is in kilograms for weight goals, otherwise in calories,
+	// minutes or activities
+	Target float64 `json:"target" gorm:"not null"`
+	// StartWeight is the user's weight in kilograms when a weight goal was set
+	StartWeight *float64 `json:"start_weight"`
+	// ActivityType limits an activity count goal to one type of activity
+	ActivityType *string `json:"activity_type" gorm:"type:varchar(20)"`
+	// StartsAt and Deadline bound the activities an activity count goal
+	// counts; a weight goal must be reached by Deadline
+	StartsAt  time.Time  `json:"starts_at" gorm:"not null"`
+	Deadline  *time.Time `json:"deadline"`
+	Version   uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CreateGoalRequest represents the request payload for setting a goal.
+// Weight targets are in the unit of the user's preference
+type CreateGoalRequest struct {
+	Type         string     `json:"type" binding:"required,oneof=target_weight weekly_calories weekly_active_minutes activity_count"`
+	Target       float64    `json:"target" binding:"required,gt=0"`
+	ActivityType *string    `json:"activityType" binding:"omitempty,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	StartsAt     *time.Time `json:"startsAt" doc:"Start of an activity count goal, now when omitted"`
+	Deadline     *time.Time `json:"deadline" doc:"Required for target_weight goals"`
+}
+
+// ReplaceGoalRequest represents the request payload for replacing a goal;
+// omitted or null fields are cleared
+type ReplaceGoalRequest struct {
+	Type         string     `json:"type" binding:"required,oneof=target_weight weekly_calories weekly_active_minutes activity_count"`
+	Target       float64    `json:"target" binding:"required,gt=0"`
+	ActivityType *string    `json:"activityType" binding:"omitempty,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	StartsAt     *time.Time `json:"startsAt"`
+	Deadline     *time.Time `json:"deadline"`
+}
+
+// UpdateGoalRequest represents a JSON merge patch for a goal: omitted fields
+// are left unchanged and null fields are cleared
+type UpdateGoalRequest struct {
+	Type         *string    `json:"type,omitempty" binding:"omitempty,oneof=target_weight weekly_calories weekly_active_minutes activity_count"`
+	Target       *float64   `json:"target,omitempty" binding:"omitempty,gt=0"`
+	ActivityType *string    `json:"activityType,omitempty" binding:"omitempty,oneof=Walking Yoga Stretching Cycling Swimming Dancing Hiking Running HIIT JumpRope"`
+	StartsAt     *time.Time `json:"startsAt,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// GoalResponse represents the response payload for a goal
+type GoalResponse struct {
+	ID           uint       `json:"goalId"`
+	Type         string     `json:"type"`
+	Target       float64    `json:"target"`
+	Unit         string     `json:"unit" doc:"kg, lbs, kcal, min or activities"`
+	ActivityType *string    `json:"activityType"`
+	StartsAt     time.Time  `json:"startsAt"`
+	Deadline     *time.Time `json:"deadline"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// GoalProgress represents how far a user is towards a goal
+type GoalProgress struct {
+	GoalID uint   `json:"goalId"`
+	Type   string `json:"type"`
+	Unit   string `json:"unit"`
+	// Start is the weight a weight goal started from
+	Start   *float64 `json:"start"`
+	Current float64  `json:"current"`
+	Target  float64  `json:"target"`
+	Percent float64  `json:"percent" doc:"Completion from 0 to 100"`
+	// PeriodStart and PeriodEnd bound the activities counted
+	PeriodStart *time.Time `json:"periodStart"`
+	PeriodEnd   *time.Time `json:"periodEnd"`
+	Achieved    bool       `json:"achieved"`
+}
+
+// GoalUnit returns the unit a goal of goalType is shown in, given the
+// user's weight unit
+func GoalUnit(goalType, weightUnit string) string {
+	switch goalType {
+	case GoalTargetWeight:
+		return weightUnit
+	case GoalWeeklyCalories:
+		return "kcal"
+	case GoalWeeklyMinutes:
+		return "min"
+	default:
+		return "activities"
+	}
+}
+
+// DisplayTarget returns the goal's target in the unit it is shown in
+func (g *Goal) DisplayTarget(weightUnit string) float64 {
+	if g.Type == GoalTargetWeight {
+		return units.Round(units.FromKilograms(g.Target, weightUnit))
+	}
+	return g.Target
+}
+
+// ToResponse converts a goal into its API representation, showing weights
+// in weightUnit
+func (g *Goal) ToResponse(weightUnit string) GoalResponse {
+	return GoalResponse{
+		ID:           g.ID,
+		Type:         g.Type,
+		Target:       g.DisplayTarget(weightUnit),
+		Unit:         GoalUnit(g.Type, weightUnit),
+		ActivityType: g.ActivityType,
+		StartsAt:     g.StartsAt,
+		Deadline:     g.Deadline,
+		CreatedAt:    g.CreatedAt,
+	}
+}
+
+// ReplaceRequest returns the replacement payload describing the goal as it
+// is in weightUnit, which merge patches are applied to
+func (g *Goal) ReplaceRequest(weightUnit string) ReplaceGoalRequest {
+	target := g.Target
+	if g.Type == GoalTargetWeight {
+		// Unrounded, so a patch that leaves the target alone keeps it
+		target = units.FromKilograms(g.Target, weightUnit)
+	}
+	startsAt := g.StartsAt
+	return ReplaceGoalRequest{
+		Type:         g.Type,
+		Target:       target,
+		ActivityType: g.ActivityType,
+		StartsAt:     &startsAt,
+		Deadline:     g.Deadline,
+	}
+}
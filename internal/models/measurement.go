@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"fitbyte/internal/units"
+)
+
+// Measurement is one entry in a user's body measurement history. Weights
+// are stored in kilograms and lengths in centimeters
+type Measurement struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"index:idx_measurements_user_measured_at;not null"`
+	MeasuredAt time.Time `json:"measured_at" gorm:"index:idx_measurements_user_measured_at;not null"`
+	Weight     *float64  `json:"weight"`
+	BodyFat    *float64  `json:"body_fat"`
+	Waist      *float64  `json:"waist"`
+	Hips       *float64  `json:"hips"`
+	Chest      *float64  `json:"chest"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateMeasurementRequest represents the request payload for recording a
+// measurement. Weights and lengths are in the units of the user's
+// preference, and at least one value must be set
+type CreateMeasurementRequest struct {
+	MeasuredAt *time.Time `json:"measuredAt" doc:"Now when omitted; cannot be in the future"`
+	Weight     *float64   `json:"weight" binding:"omitempty,gt=0,max=1000"`
+	BodyFat    *float64   `json:"bodyFat" binding:"omitempty,gt=0,max=100" doc:"Body fat percentage"`
+	Waist      *float64   `json:"waist" binding:"omitempty,gt=0,max=500"`
+	Hips       *float64   `json:"hips" binding:"omitempty,gt=0,max=500"`
+	Chest      *float64   `json:"chest" binding:"omitempty,gt=0,max=500"`
+}
+
+// MeasurementQuery represents the query parameters for listing measurements
+type MeasurementQuery struct {
+	Limit  int        `form:"limit,default=30" binding:"min=1,max=366"`
+	Offset int        `form:"offset,default=0" binding:"min=0"`
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Window int        `form:"window,default=7" binding:"min=1,max=90" doc:"Days covered by the moving average"`
+}
+
+// MeasurementValues are the values of a measurement in display units
+type MeasurementValues struct {
+	Weight  *float64 `json:"weight"`
+	BodyFat *float64 `json:"bodyFat"`
+	Waist   *float64 `json:"waist"`
+	Hips    *float64 `json:"hips"`
+	Chest   *float64 `json:"chest"`
+}
+
+// MeasurementResponse represents the response payload for a measurement
+type MeasurementResponse struct {
+	ID         uint      `json:"measurementId"`
+	MeasuredAt time.Time `json:"measuredAt"`
+	MeasurementValues
+	WeightUnit string `json:"weightUnit"`
+	LengthUnit string `json:"lengthUnit"`
+	// Average smooths each value over the measurements in the window ending
+	// at this one
+	Average   *MeasurementValues `json:"average,omitempty" doc:"Moving average over the window ending at this measurement, in list responses"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// Values returns the measurement's values converted to the units of
+// preference
+func (m *Measurement) Values(preference *string) MeasurementValues {
+	weightUnit, lengthUnit := units.WeightUnit(preference), units.LengthUnit(preference)
+	return MeasurementValues{
+		Weight:  convert(m.Weight, func(v float64) float64 { return units.FromKilograms(v, weightUnit) }),
+		BodyFat: convert(m.BodyFat, func(v float64) float64 { return v }),
+		Waist:   convert(m.Waist, func(v float64) float64 { return units.FromCentimeters(v, lengthUnit) }),
+		Hips:    convert(m.Hips, func(v float64) float64 { return units.FromCentimeters(v, lengthUnit) }),
+		Chest:   convert(m.Chest, func(v float64) float64 { return units.FromCentimeters(v, lengthUnit) }),
+	}
+}
+
+// ToResponse converts a measurement into its API representation, in the
+// units of preference
+func (m *Measurement) ToResponse(preference *string) MeasurementResponse {
+	return MeasurementResponse{
+		ID:                m.ID,
+		MeasuredAt:        m.MeasuredAt,
+		MeasurementValues: m.Values(preference),
+		WeightUnit:        units.WeightUnit(preference),
+		LengthUnit:        units.LengthUnit(preference),
+		CreatedAt:         m.CreatedAt,
+	}
+}
+
+// convert applies fn to an optional value and rounds the result for display
+func convert(v *float64, fn func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	out := units.Round(fn(*v))
+	return &out
+}
@@ -0,0 +1,26 @@
+package models
+
+// MetricsQuery represents the query parameters for health metrics
+type MetricsQuery struct {
+	ActivityLevel string `form:"activityLevel" binding:"omitempty,oneof=sedentary lightly_active moderately_active very_active extra_active" doc:"Inferred from the activities logged in the last 28 days when omitted"`
+}
+
+// BMR is the basal metabolic rate in kcal per day by two equations
+type BMR struct {
+	MifflinStJeor  float64 `json:"mifflinStJeor"`
+	HarrisBenedict float64 `json:"harrisBenedict"`
+}
+
+// HealthMetrics represents the metrics derived from a user's profile and
+// logged activities. Metrics that need a missing profile field are null
+type HealthMetrics struct {
+	BMI                 *float64 `json:"bmi"`
+	BMICategory         *string  `json:"bmiCategory" doc:"underweight, normal, overweight or obese"`
+	Age                 *int     `json:"age"`
+	BMR                 *BMR     `json:"bmr"`
+	ActivityLevel       string   `json:"activityLevel"`
+	WeeklyActiveMinutes float64  `json:"weeklyActiveMinutes" doc:"Average over the last 28 days"`
+	TDEE                *float64 `json:"tdee" doc:"kcal per day from the Mifflin-St Jeor BMR and the activity level"`
+	TDEEFromActivities  *float64 `json:"tdeeFromActivities" doc:"kcal per day: sedentary expenditure plus the average burned in logged activities"`
+	Missing             []string `json:"missing" doc:"Profile fields the null metrics need"`
+}
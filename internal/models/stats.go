@@ -0,0 +1,48 @@
+package models
+
+// Summary periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// StatsQuery represents the query parameters for an activity summary. Dates
+// are calendar days in the time zone
+type StatsQuery struct {
+	Period   string `form:"period,default=day" binding:"oneof=day week month" doc:"Length of each bucket; weeks start on Monday"`
+	From     string `form:"from" binding:"omitempty,datetime=2006-01-02" format:"date" doc:"First day, 29 days, 11 weeks or 11 months before to when omitted"`
+	To       string `form:"to" binding:"omitempty,datetime=2006-01-02" format:"date" doc:"Last day, today when omitted"`
+	TimeZone string `form:"timeZone" doc:"IANA time zone the buckets follow, the profile timeZone when omitted"`
+}
+
+// ActivityTypeTotals sums up the activities of one type
+type ActivityTypeTotals struct {
+	ActivityType   string `json:"activityType"`
+	Count          int64  `json:"count"`
+	Minutes        int64  `json:"minutes"`
+	CaloriesBurned int64  `json:"caloriesBurned"`
+}
+
+// StatsBucket represents the activities of one period
+type StatsBucket struct {
+	Start          string `json:"start" format:"date" doc:"First day of the period"`
+	Count          int64  `json:"count"`
+	Minutes        int64  `json:"minutes"`
+	CaloriesBurned int64  `json:"caloriesBurned"`
+	// ActivityTypes only lists the types logged in the period
+	ActivityTypes []ActivityTypeTotals `json:"activityTypes"`
+}
+
+// StatsSummary represents activity totals bucketed by period. Periods
+// without activities are included with zero totals
+type StatsSummary struct {
+	Period         string        `json:"period"`
+	TimeZone       string        `json:"timeZone"`
+	From           string        `json:"from" format:"date"`
+	To             string        `json:"to" format:"date" doc:"Last day of the last period"`
+	Count          int64         `json:"count"`
+	Minutes        int64         `json:"minutes"`
+	CaloriesBurned int64         `json:"caloriesBurned"`
+	Buckets        []StatsBucket `json:"buckets"`
+}
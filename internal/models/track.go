@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"fitbyte/internal/geo"
+)
+
+// ActivityTrack is the GPS track of an activity. The positions are stored
+// as an encoded polyline, with the elevations and times encoded alongside
+type ActivityTrack struct {
+	ActivityID uint   `json:"activity_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID     uint   `json:"user_id" gorm:"index;not null"`
+	Polyline   string `json:"polyline" gorm:"type:text;not null"`
+	// Elevations are in decimeters and Times in Unix seconds. Each is empty
+	// unless every point has a value
+	Elevations string    `json:"elevations" gorm:"type:text;not null;default:''"`
+	Times      string    `json:"times" gorm:"type:text;not null;default:''"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewActivityTrack encodes points into a track
+func NewActivityTrack(points []geo.Point) ActivityTrack {
+	elevations := make([]int64, 0, len(points))
+	times := make([]int64, 0, len(points))
+	for _, p := range points {
+		if p.Elevation != nil {
+			elevations = append(elevations, int64(math.Round(*p.Elevation*10)))
+		}
+		if !p.Time.IsZero() {
+			times = append(times, p.Time.Unix())
+		}
+	}
+
+	track := ActivityTrack{Polyline: geo.EncodePolyline(points)}
+	if len(elevations) == len(points) {
+		track.Elevations = geo.EncodeSeries(elevations)
+	}
+	if len(times) == len(points) {
+		track.Times = geo.EncodeSeries(times)
+	}
+	return track
+}
+
+// Points decodes the track
+func (t *ActivityTrack) Points() ([]geo.Point, error) {
+	points, err := geo.DecodePolyline(t.Polyline)
+	if err != nil {
+		return nil, err
+	}
+	elevations, err := geo.DecodeSeries(t.Elevations)
+	if err != nil {
+		return nil, err
+	}
+	times, err := geo.DecodeSeries(t.Times)
+	if err != nil {
+		return nil, err
+	}
+	if (len(elevations) > 0 && len(elevations) != len(points)) || (len(times) > 0 && len(times) != len(points)) {
+		return nil, fmt.Errorf("track of activity %d: %w", t.ActivityID, geo.ErrInvalidPolyline)
+	}
+
+	for i := range points {
+		if len(elevations) > 0 {
+			e := float64(elevations[i]) / 10
+			points[i].Elevation = &e
+		}
+		if len(times) > 0 {
+			points[i].Time = time.Unix(times[i], 0).UTC()
+		}
+	}
+	return points, nil
+}
+
+// TrackPointRequest is a position of a GPS track sent with an activity
+type TrackPointRequest struct {
+	Lat       float64    `json:"lat" binding:"gte=-90,lte=90"`
+	Lon       float64    `json:"lon" binding:"gte=-180,lte=180"`
+	Elevation *float64   `json:"elevation,omitempty" doc:"Meters"`
+	Time      *time.Time `json:"time,omitempty"`
+}
+
+// ActivityRoute is the GPS track of an activity as a GeoJSON feature
+type ActivityRoute struct {
+	Type       string          `json:"type" doc:"Always Feature"`
+	Geometry   RouteGeometry   `json:"geometry"`
+	Properties RouteProperties `json:"properties"`
+}
+
+// RouteGeometry is a GeoJSON line string
+type RouteGeometry struct {
+	Type        string      `json:"type" doc:"Always LineString"`
+	Coordinates [][]float64 `json:"coordinates" doc:"Longitude, latitude and, when the track has elevations, elevation in meters"`
+}
+
+// NewRouteGeometry returns the line string through points
+func NewRouteGeometry(points []geo.Point) RouteGeometry {
+	coordinates := make([][]float64, len(points))
+	for i, p := range points {
+		coordinates[i] = []float64{p.Lon, p.Lat}
+		if p.Elevation != nil {
+			coordinates[i] = append(coordinates[i], *p.Elevation)
+		}
+	}
+	return RouteGeometry{Type: "LineString", Coordinates: coordinates}
+}
+
+// RouteProperties describes an activity's route
+type RouteProperties struct {
+	ActivityID            uint              `json:"activityId"`
+	DistanceInMeters      float64           `json:"distanceInMeters" doc:"Length of the track"`
+	ElevationGainInMeters *float64          `json:"elevationGainInMeters"`
+	SplitUnit             string            `json:"splitUnit" doc:"km or mi, following the user's unit preference"`
+	Splits                []RouteSplit      `json:"splits" doc:"Empty unless every point of the track has a time"`
+	ElevationProfile      []ElevationSample `json:"elevationProfile" doc:"Up to a few hundred samples, empty when the track has no elevations"`
+}
+
+// RouteSplit is one split of a route
+type RouteSplit struct {
+	Split                   int      `json:"split"`
+	Distance                float64  `json:"distance" doc:"In splitUnit; only the last split may be shorter than one"`
+	DurationInSeconds       int      `json:"durationInSeconds"`
+	PaceInSeconds           int      `json:"paceInSeconds" doc:"Seconds per splitUnit"`
+	ElevationChangeInMeters *float64 `json:"elevationChangeInMeters"`
+}
+
+// ElevationSample is the elevation at a distance along a route
+type ElevationSample struct {
+	DistanceInMeters  float64 `json:"distanceInMeters"`
+	ElevationInMeters float64 `json:"elevationInMeters"`
+}
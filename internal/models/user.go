@@ -2,21 +2,42 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles a user can have
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
 )
 
 // User represents a user in the system
 type User struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	Email      string    `json:"email" gorm:"uniqueIndex;not null"`
-	Name       *string   `json:"name" gorm:"type:varchar(255)"`
-	Preference *string   `json:"preference" gorm:"type:varchar(255)"`
-	WeightUnit *string   `json:"weightUnit" gorm:"type:varchar(10)"`
-	HeightUnit *string   `json:"heightUnit" gorm:"type:varchar(10)"`
-	Weight     *float64  `json:"weight" gorm:"type:decimal(5,2)"`
-	Height     *float64  `json:"height" gorm:"type:decimal(5,2)"`
-	ImageURI   *string   `json:"imageUri" gorm:"type:text"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Email        string     `json:"email" gorm:"uniqueIndex:idx_users_active_email,where:deleted_at IS NULL;not null"`
+	PasswordHash string     `json:"-" gorm:"not null"`
+	Role         string     `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	Name         *string    `json:"name" gorm:"type:varchar(255)"`
+	Preference   *string    `json:"preference" gorm:"type:varchar(255)"`
+	WeightUnit   *string    `json:"weightUnit" gorm:"type:varchar(10)"`
+	HeightUnit   *string    `json:"heightUnit" gorm:"type:varchar(10)"`
+	Weight       *float64   `json:"weight" gorm:"type:decimal(5,2)"`
+	Height       *float64   `json:"height" gorm:"type:decimal(5,2)"`
+	BirthDate    *string    `json:"birthDate" gorm:"type:varchar(10)"`
+	Sex          *string    `json:"sex" gorm:"type:varchar(10)"`
+	TimeZone     *string    `json:"timeZone" gorm:"type:varchar(64)"`
+	Locale       *string    `json:"locale" gorm:"type:varchar(35)"`
+	ImageURI     *string    `json:"imageUri" gorm:"type:text"`
+	DisabledAt   *time.Time `json:"disabled_at"`
+	Version      uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	// DeletedAt marks a soft deleted user, which queries skip until it is
+	// restored or purged. Emails are only unique among users that are not
+	// deleted, so an address can be registered again meanwhile
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 // CreateUserRequest represents the request payload for creating a user
@@ -26,32 +47,117 @@ type CreateUserRequest struct {
 	Preference *string  `json:"preference"`
 	WeightUnit *string  `json:"weightUnit"`
 	HeightUnit *string  `json:"heightUnit"`
-	Weight     *float64 `json:"weight"`
-	Height     *float64 `json:"height"`
+	Weight     *float64 `json:"weight" binding:"omitempty,gt=0"`
+	Height     *float64 `json:"height" binding:"omitempty,gt=0"`
+	BirthDate  *string  `json:"birthDate" binding:"omitempty,datetime=2006-01-02" format:"date"`
+	Sex        *string  `json:"sex" binding:"omitempty,oneof=male female"`
+	TimeZone   *string  `json:"timeZone" doc:"IANA time zone, such as Europe/Berlin"`
+	Locale     *string  `json:"locale" doc:"BCP 47 language tag, such as en-US"`
 	ImageURI   *string  `json:"imageUri"`
 }
 
-// UpdateUserRequest represents the request payload for updating a user
+// ReplaceUserRequest represents the request payload for replacing a user;
+// omitted or null fields are cleared
+type ReplaceUserRequest struct {
+	Email      string   `json:"email" binding:"required,email"`
+	Name       *string  `json:"name"`
+	Preference *string  `json:"preference"`
+	WeightUnit *string  `json:"weightUnit"`
+	HeightUnit *string  `json:"heightUnit"`
+	Weight     *float64 `json:"weight" binding:"omitempty,gt=0"`
+	Height     *float64 `json:"height" binding:"omitempty,gt=0"`
+	BirthDate  *string  `json:"birthDate" binding:"omitempty,datetime=2006-01-02" format:"date"`
+	Sex        *string  `json:"sex" binding:"omitempty,oneof=male female"`
+	TimeZone   *string  `json:"timeZone" doc:"IANA time zone, such as Europe/Berlin"`
+	Locale     *string  `json:"locale" doc:"BCP 47 language tag, such as en-US"`
+	ImageURI   *string  `json:"imageUri"`
+}
+
+// UpdateUserRequest represents a JSON merge patch for a user: omitted fields
+// are left unchanged and null fields are cleared
 type UpdateUserRequest struct {
 	Email      *string  `json:"email,omitempty" binding:"omitempty,email"`
 	Name       *string  `json:"name,omitempty"`
 	Preference *string  `json:"preference,omitempty"`
 	WeightUnit *string  `json:"weightUnit,omitempty"`
 	HeightUnit *string  `json:"heightUnit,omitempty"`
-	Weight     *float64 `json:"weight,omitempty"`
-	Height     *float64 `json:"height,omitempty"`
+	Weight     *float64 `json:"weight,omitempty" binding:"omitempty,gt=0"`
+	Height     *float64 `json:"height,omitempty" binding:"omitempty,gt=0"`
+	BirthDate  *string  `json:"birthDate,omitempty" binding:"omitempty,datetime=2006-01-02" format:"date"`
+	Sex        *string  `json:"sex,omitempty" binding:"omitempty,oneof=male female"`
+	TimeZone   *string  `json:"timeZone,omitempty" doc:"IANA time zone, such as Europe/Berlin"`
+	Locale     *string  `json:"locale,omitempty" doc:"BCP 47 language tag, such as en-US"`
 	ImageURI   *string  `json:"imageUri,omitempty"`
 }
 
+// SetRoleRequest represents the request payload for changing a user's role
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user coach admin"`
+}
+
 // UserResponse represents the response payload for user data
 type UserResponse struct {
 	ID         uint     `json:"id"`
 	Email      string   `json:"email"`
+	Role       string   `json:"role"`
 	Name       *string  `json:"name"`
 	Preference *string  `json:"preference"`
 	WeightUnit *string  `json:"weightUnit"`
 	HeightUnit *string  `json:"heightUnit"`
 	Weight     *float64 `json:"weight"`
 	Height     *float64 `json:"height"`
+	BirthDate  *string  `json:"birthDate" format:"date"`
+	Sex        *string  `json:"sex"`
+	TimeZone   *string  `json:"timeZone"`
+	Locale     *string  `json:"locale"`
 	ImageURI   *string  `json:"imageUri"`
 }
+
+// ToResponse converts a user into its API representation
+func (u *User) ToResponse() UserResponse {
+	return UserResponse{
+		ID:         u.ID,
+		Email:      u.Email,
+		Role:       u.Role,
+		Name:       u.Name,
+		Preference: u.Preference,
+		WeightUnit: u.WeightUnit,
+		HeightUnit: u.HeightUnit,
+		Weight:     u.Weight,
+		Height:     u.Height,
+		BirthDate:  u.BirthDate,
+		Sex:        u.Sex,
+		TimeZone:   u.TimeZone,
+		Locale:     u.Locale,
+		ImageURI:   u.ImageURI,
+	}
+}
+
+// ReplaceRequest returns the replacement payload describing the user as it
+// is, which merge patches are applied to
+func (u *User) ReplaceRequest() ReplaceUserRequest {
+	return ReplaceUserRequest{
+		Email:      u.Email,
+		Name:       u.Name,
+		Preference: u.Preference,
+		WeightUnit: u.WeightUnit,
+		HeightUnit: u.HeightUnit,
+		Weight:     u.Weight,
+		Height:     u.Height,
+		BirthDate:  u.BirthDate,
+		Sex:        u.Sex,
+		TimeZone:   u.TimeZone,
+		Locale:     u.Locale,
+		ImageURI:   u.ImageURI,
+	}
+}
+
+// Location returns the user's time zone, UTC when it is not set
+func (u *User) Location() *time.Location {
+	if u.TimeZone != nil {
+		if loc, err := time.LoadLocation(*u.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
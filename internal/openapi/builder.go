@@ -0,0 +1,288 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"fitbyte/internal/models"
+)
+
+// Security requirements for routes
+const (
+	SecurityNone   = ""
+	SecurityBearer = "bearerAuth"
+)
+
+// Route documents one registered route. Body, Query and Response are sample
+// values of the Go types handled by the route; their schemas are generated
+// by reflection so the document follows the models package
+type Route struct {
+	Method   string
+	Path     string // gin style, e.g. /api/v1/users/:id
+	Summary  string
+	Tag      string
+	Security string
+	// Roles restricts the route to users with one of these roles
+	Roles []string
+
+	Query  interface{}
+	Params []Parameter
+	Body   interface{}
+	// RequestContentType overrides application/json for the request body
+	RequestContentType string
+
+	// Status is the success status, http.StatusOK when zero
+	Status int
+	// Response is the type of the "data" field of the success envelope.
+	// It is omitted from the envelope when nil
+	Response interface{}
+	// Paginated wraps Response in a PaginatedResponse envelope
+	Paginated bool
+	// Raw documents Response as the whole body instead of inside an envelope
+	Raw bool
+	// ContentType overrides application/json for the success response
+	ContentType string
+	// Errors lists the error statuses the route can return
+	Errors []int
+	// Conditional documents ETag handling: If-None-Match and 304 on GET,
+	// If-Match with 412 and 428 on writes
+	Conditional bool
+}
+
+// Builder assembles a Document from routes
+type Builder struct {
+	doc *Document
+}
+
+// NewBuilder creates a builder for an API
+func NewBuilder(info Info) *Builder {
+	return &Builder{doc: &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				SecurityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}}
+}
+
+// Add documents a route
+func (b *Builder) Add(r Route) {
+	path := Path(r.Path)
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	op := &Operation{
+		OperationID: operationID(r.Method, r.Path),
+		Summary:     r.Summary,
+		Responses:   map[string]Response{},
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Security != SecurityNone {
+		op.Security = []map[string][]string{{r.Security: {}}}
+	}
+	if len(r.Roles) > 0 {
+		op.Description = "Requires the " + strings.Join(r.Roles, " or ") + " role."
+	}
+
+	op.Parameters = append(op.Parameters, pathParams(r.Path)...)
+	if r.Query != nil {
+		op.Parameters = append(op.Parameters, b.queryParams(reflect.TypeOf(r.Query))...)
+	}
+	op.Parameters = append(op.Parameters, r.Params...)
+	if r.Conditional {
+		op.Parameters = append(op.Parameters, conditionalParam(r.Method))
+	}
+
+	if r.Body != nil {
+		requestContentType := r.RequestContentType
+		if requestContentType == "" {
+			requestContentType = "application/json"
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{requestContentType: {Schema: b.schemaFor(reflect.TypeOf(r.Body))}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	op.Responses[strconv.Itoa(status)] = Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{contentType: {Schema: b.successSchema(r)}},
+	}
+
+	errs := append([]int{}, r.Errors...)
+	if r.Body != nil || r.Query != nil || strings.Contains(r.Path, ":") {
+		errs = append(errs, http.StatusBadRequest)
+	}
+	if r.Security != SecurityNone {
+		errs = append(errs, http.StatusUnauthorized)
+	}
+	if len(r.Roles) > 0 {
+		errs = append(errs, http.StatusForbidden)
+	}
+	if r.Conditional && r.Method == http.MethodGet {
+		op.Responses[strconv.Itoa(http.StatusNotModified)] = Response{Description: http.StatusText(http.StatusNotModified)}
+	} else if r.Conditional {
+		errs = append(errs, http.StatusPreconditionFailed, http.StatusPreconditionRequired)
+	}
+	for _, code := range errs {
+		op.Responses[strconv.Itoa(code)] = Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(models.ErrorResponse{}))}},
+		}
+	}
+
+	(*item)[strings.ToLower(r.Method)] = op
+}
+
+// conditionalParam documents the precondition header of a conditional route
+func conditionalParam(method string) Parameter {
+	if method == http.MethodGet {
+		return Parameter{
+			Name:        "If-None-Match",
+			In:          "header",
+			Description: "ETag of a cached representation; 304 is returned if it is current",
+			Schema:      &Schema{Type: "string"},
+		}
+	}
+	return Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Description: "ETag the change is based on; 412 is returned if the resource has changed since",
+		Schema:      &Schema{Type: "string"},
+	}
+}
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) successSchema(r Route) *Schema {
+	var data *Schema
+	if r.Response != nil {
+		data = b.schemaFor(reflect.TypeOf(r.Response))
+	}
+	if r.Raw {
+		if data == nil {
+			return &Schema{}
+		}
+		return data
+	}
+
+	envelope := reflect.TypeOf(models.APIResponse{})
+	if r.Paginated {
+		envelope = reflect.TypeOf(models.PaginatedResponse{})
+	}
+	s := b.structSchema(envelope)
+	if data != nil {
+		s.Properties["data"] = data
+		s.Required = appendUnique(s.Required, "data")
+	} else {
+		delete(s.Properties, "data")
+	}
+	return s
+}
+
+// queryParams turns the form tags of a query struct into parameters
+func (b *Builder) queryParams(t reflect.Type) []Parameter {
+	t = derefType(t)
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		s := b.schemaFor(derefType(f.Type))
+		required := applyBinding(s, f.Tag.Get("binding"))
+		if format := f.Tag.Get("format"); format != "" {
+			s.Format = format
+		}
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Required:    required,
+			Schema:      s,
+			Description: f.Tag.Get("doc"),
+		})
+	}
+	return params
+}
+
+// Path converts a gin route path to an OpenAPI path
+func Path(ginPath string) string {
+	parts := strings.Split(ginPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func pathParams(ginPath string) []Parameter {
+	var params []Parameter
+	for _, part := range strings.Split(ginPath, "/") {
+		if strings.HasPrefix(part, "*") {
+			params = append(params, Parameter{
+				Name:     part[1:],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+		if strings.HasPrefix(part, ":") {
+			min := 1.0
+			params = append(params, Parameter{
+				Name:     part[1:],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "integer", Minimum: &min},
+			})
+		}
+	}
+	return params
+}
+
+func operationID(method, ginPath string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.Split(strings.TrimPrefix(ginPath, "/api/v1"), "/") {
+		part = strings.TrimLeft(part, ":*")
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	if b.Len() == len(method) {
+		b.WriteString("Root")
+	}
+	return b.String()
+}
+
+func appendUnique(list []string, v string) []string {
+	for _, item := range list {
+		if item == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
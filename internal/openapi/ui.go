@@ -0,0 +1,13 @@
+package openapi
+
+import _ "embed"
+
+// swaggerUI is an HTML page rendering /openapi.json with Swagger UI
+//
+//go:embed swagger.html
+var swaggerUI []byte
+
+// SwaggerUI returns the interactive documentation page
+func SwaggerUI() []byte {
+	return swaggerUI
+}
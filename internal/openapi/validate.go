@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ValidationError describes one value that does not match its schema
+type ValidationError struct {
+	// Path locates the value, e.g. "body.weight" or "query.limit"
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json into an
+// interface{}) against s. References are resolved against d
+func (d *Document) Validate(s *Schema, value interface{}, path string) []ValidationError {
+	var errs []ValidationError
+	d.validate(s, value, path, &errs)
+	return errs
+}
+
+func (d *Document) validate(s *Schema, value interface{}, path string, errs *[]ValidationError) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		ref, ok := d.Components.Schemas[name]
+		if !ok {
+			fail("unresolved reference %s", s.Ref)
+			return
+		}
+		d.validate(ref, value, path, errs)
+		return
+	}
+
+	if len(s.AnyOf) > 0 {
+		for _, option := range s.AnyOf {
+			var optionErrs []ValidationError
+			d.validate(option, value, path, &optionErrs)
+			if len(optionErrs) == 0 {
+				return
+			}
+		}
+		fail("does not match any allowed schema")
+		return
+	}
+
+	if value == nil {
+		if s.Type != "" && s.Type != "null" && !s.Nullable {
+			fail("must not be null")
+		}
+		return
+	}
+
+	if !matchesType(s.Type, value) {
+		fail("must be of type %s", s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && len([]rune(v)) > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		switch s.Format {
+		case "email":
+			if _, err := mail.ParseAddress(v); err != nil {
+				fail("must be an email address")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("must be an RFC 3339 date-time")
+			}
+		case "date":
+			if _, err := time.Parse(time.DateOnly, v); err != nil {
+				fail("must be a date (YYYY-MM-DD)")
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		for i, item := range v {
+			d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: join(path, name), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				d.validate(prop, v[k], join(path, k), errs)
+			} else if s.AdditionalProperties != nil {
+				d.validate(s.AdditionalProperties, v[k], join(path, k), errs)
+			}
+		}
+	}
+}
+
+func matchesType(typ string, value interface{}) bool {
+	switch typ {
+	case "":
+		return true
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if e == value {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
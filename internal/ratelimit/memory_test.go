@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source that tests move forward by hand
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestMemoryStoreTake(t *testing.T) {
+	clk := &clock{t: time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clk.now
+	// One token a second, up to 3 at once
+	policy := Policy{Limit: 60, Period: time.Minute, Burst: 3}
+
+	steps := []struct {
+		advance       time.Duration
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{0, "a", true, 2, 0},
+		{0, "a", true, 1, 0},
+		{0, "a", true, 0, 0},
+		{0, "a", false, 0, time.Second},
+		// Other keys have buckets of their own
+		{0, "b", true, 2, 0},
+		{400 * time.Millisecond, "a", false, 0, 600 * time.Millisecond},
+		{600 * time.Millisecond, "a", true, 0, 0},
+		// Refills stop at the burst
+		{time.Hour, "a", true, 2, 0},
+	}
+	for i, step := range steps {
+		clk.advance(step.advance)
+		res, err := store.Take(context.Background(), step.key, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != step.wantAllowed || res.Remaining != step.wantRemaining || res.RetryAfter != step.wantRetry {
+			t.Errorf("step %d: got allowed %v, remaining %d, retry after %v; want %v, %d, %v",
+				i, res.Allowed, res.Remaining, res.RetryAfter, step.wantAllowed, step.wantRemaining, step.wantRetry)
+		}
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	clk := &clock{t: time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clk.now
+	// One token every 5 minutes, up to 2 at once
+	policy := Policy{Limit: 2, Period: 10 * time.Minute}
+
+	for _, key := range []string{"a", "b"} {
+		if _, err := store.Take(context.Background(), key, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a is taken from again, so that only b is full at the next sweep
+	clk.advance(4 * time.Minute)
+	if _, err := store.Take(context.Background(), "a", policy); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(2 * time.Minute)
+	if _, err := store.Take(context.Background(), "c", policy); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.buckets["b"]; ok {
+		t.Error("full bucket b was kept")
+	}
+	if _, ok := store.buckets["a"]; !ok {
+		t.Error("bucket a was dropped before it refilled")
+	}
+}
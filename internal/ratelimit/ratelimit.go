@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitbyte/internal/redis"
+)
+
+// Policy describes a token bucket: Limit requests are refilled evenly over
+// Period, and up to Burst requests may be made back to back
+type Policy struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps token bucket state for rate limit keys
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// ParsePolicy parses a policy in the form "<limit>/<period>", e.g. "100/1m".
+// An optional burst may be appended as "100/1m/20"; it defaults to the limit
+func ParsePolicy(spec string) (Policy, error) {
+	parts := strings.Split(strings.TrimSpace(spec), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: expected <limit>/<period>[/<burst>]", spec)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: limit must be a positive integer", spec)
+	}
+
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", spec)
+	}
+
+	burst := limit
+	if len(parts) == 3 {
+		burst, err = strconv.Atoi(parts[2])
+		if err != nil || burst <= 0 {
+			return Policy{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", spec)
+		}
+	}
+
+	return Policy{Limit: limit, Period: period, Burst: burst}, nil
+}
+
+// capacity returns the maximum number of tokens a bucket can hold
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return float64(p.Limit)
+}
+
+// rate returns the number of tokens refilled per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// result builds a Result from the number of tokens left after a take
+func (p Policy) result(allowed bool, tokens float64) Result {
+	rate := p.rate()
+	res := Result{
+		Allowed:    allowed,
+		Limit:      p.Limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((p.capacity() - tokens) / rate),
+	}
+	if !allowed {
+		res.Remaining = 0
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// NewStore creates the store named by kind ("memory" or "redis")
+func NewStore(kind, redisURL string) (Store, error) {
+	switch kind {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		client, err := redis.NewClient(redisURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisStore(client, "fitbyte:ratelimit:"), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", kind)
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Policy
+		wantErr bool
+	}{
+		{"100/1m", Policy{Limit: 100, Period: time.Minute, Burst: 100}, false},
+		{" 5/1s/10 ", Policy{Limit: 5, Period: time.Second, Burst: 10}, false},
+		{"100", Policy{}, true},
+		{"0/1m", Policy{}, true},
+		{"x/1m", Policy{}, true},
+		{"10/0s", Policy{}, true},
+		{"10/soon", Policy{}, true},
+		{"10/1m/0", Policy{}, true},
+		{"10/1m/5/1", Policy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePolicy(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePolicy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyResult(t *testing.T) {
+	// One token every 6 seconds, up to 5 at once
+	policy := Policy{Limit: 10, Period: time.Minute, Burst: 5}
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{"full", true, 4, Result{Allowed: true, Limit: 10, Remaining: 4, ResetAfter: 6 * time.Second}},
+		{"partial token", true, 2.5, Result{Allowed: true, Limit: 10, Remaining: 2, ResetAfter: 15 * time.Second}},
+		{"empty", false, 0, Result{Allowed: false, Limit: 10, Remaining: 0, ResetAfter: 30 * time.Second, RetryAfter: 6 * time.Second}},
+		{"refilling", false, 0.5, Result{Allowed: false, Limit: 10, Remaining: 0, ResetAfter: 27 * time.Second, RetryAfter: 3 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.result(tt.allowed, tt.tokens); got != tt.want {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/redis"
+)
+
+// takeScript refills and takes from a bucket atomically. Tokens are stored
+// in thousandths so the script only deals with integers
+const takeScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(capacity, tokens + (now - ts) * rate)
+end
+local allowed = 0
+if tokens >= 1000 then
+  tokens = tokens - 1000
+  allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tokens}
+`
+
+// RedisStore keeps buckets in Redis (or any server speaking its protocol) so
+// that limits are shared between API instances
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a store that namespaces its keys with prefix
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+// Take removes one token from the bucket identified by key
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	capacity := policy.capacity() * 1000
+	ratePerMs := policy.rate()
+	now := s.now().UnixMilli()
+
+	reply, err := s.client.Do(ctx, "EVAL", takeScript, "1", s.prefix+key,
+		strconv.FormatFloat(capacity, 'f', -1, 64),
+		strconv.FormatFloat(ratePerMs, 'f', -1, 64),
+		strconv.FormatInt(now, 10),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+	}
+	allowed, err := redis.Int(values[0], nil)
+	if err != nil {
+		return Result{}, err
+	}
+	tokens, err := redis.Int(values[1], nil)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return policy.result(allowed == 1, float64(tokens)/1000), nil
+}
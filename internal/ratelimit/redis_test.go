@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"fitbyte/internal/redis"
+	"fitbyte/internal/redis/redistest"
+)
+
+func TestRedisStoreTake(t *testing.T) {
+	var reply string
+	srv := redistest.NewServer(t, func(args []string) string { return reply })
+	client, err := redis.NewClient(srv.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	store := NewRedisStore(client, "test:")
+	now := time.UnixMilli(1700000000123)
+	store.now = func() time.Time { return now }
+	// One token a second, up to 5 at once
+	policy := Policy{Limit: 60, Period: time.Minute, Burst: 5}
+
+	tests := []struct {
+		name    string
+		reply   string
+		want    Result
+		wantErr bool
+	}{
+		{"allowed", redistest.Array(redistest.Int(1), redistest.Int(2500)), Result{Allowed: true, Limit: 60, Remaining: 2, ResetAfter: 2500 * time.Millisecond}, false},
+		{"denied", redistest.Array(redistest.Int(0), redistest.Int(250)), Result{Limit: 60, ResetAfter: 4750 * time.Millisecond, RetryAfter: 750 * time.Millisecond}, false},
+		{"script error", "-ERR script failed\r\n", Result{}, true},
+		{"short reply", redistest.Array(redistest.Int(1)), Result{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply = tt.reply
+			got, err := store.Take(context.Background(), "login:ip:1.2.3.4", policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Take error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Take = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Tokens are sent in thousandths and time in milliseconds
+	want := []string{"EVAL", takeScript, "1", "test:login:ip:1.2.3.4", "5000", "1", "1700000000123"}
+	if got := srv.Commands()[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+// TestRedisStoreScript runs the script on a real server, given by
+// REDIS_TEST_URL, such as redis://localhost:6379/15
+func TestRedisStoreScript(t *testing.T) {
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL is not set")
+	}
+	client, err := redis.NewClient(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	clk := &clock{t: time.Now()}
+	store := NewRedisStore(client, fmt.Sprintf("fitbyte:test:%d:", time.Now().UnixNano()))
+	store.now = clk.now
+	// One token a second, up to 3 at once
+	policy := Policy{Limit: 60, Period: time.Minute, Burst: 3}
+
+	steps := []struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{0, true, 2},
+		{0, true, 1},
+		{0, true, 0},
+		{0, false, 0},
+		{400 * time.Millisecond, false, 0},
+		{600 * time.Millisecond, true, 0},
+		{time.Hour, true, 2},
+	}
+	for i, step := range steps {
+		clk.advance(step.advance)
+		res, err := store.Take(context.Background(), "key", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != step.wantAllowed || res.Remaining != step.wantRemaining {
+			t.Errorf("step %d: got allowed %v, remaining %d; want %v, %d", i, res.Allowed, res.Remaining, step.wantAllowed, step.wantRemaining)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package redistest runs a fake server speaking the Redis protocol, for
+// tests of code built on the redis package
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Handler answers a command with a raw RESP reply, such as "+OK\r\n"
+type Handler func(args []string) string
+
+// Server is a fake Redis server. Each connection is served in order, one
+// command at a time
+type Server struct {
+	listener net.Listener
+	handler  Handler
+
+	mu       sync.Mutex
+	commands [][]string
+	conns    int
+}
+
+// NewServer starts a server answering with handler. It is closed when the
+// test ends
+func NewServer(t testing.TB, handler Handler) *Server {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{listener: l, handler: handler}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+// URL returns the redis:// URL of the server
+func (s *Server) URL() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+// Commands returns the commands received so far
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...)
+}
+
+// Connections returns how many connections were accepted
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+		if _, err := io.WriteString(conn, s.handler(args)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	n, err := readLength(rd, '*')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := readLength(rd, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLength(rd *bufio.Reader, kind byte) (int, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != kind {
+		return 0, fmt.Errorf("redistest: unexpected line %q", line)
+	}
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
+
+// Bulk encodes a bulk string reply
+func Bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// Array encodes an array reply of already encoded elements
+func Array(elements ...string) string {
+	return "*" + strconv.Itoa(len(elements)) + "\r\n" + strings.Join(elements, "")
+}
+
+// Int encodes an integer reply
+func Int(n int64) string {
+	return ":" + strconv.FormatInt(n, 10) + "\r\n"
+}
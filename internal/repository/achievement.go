@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AchievementRepository persists earned badges. All lookups are scoped to a
+// user
+type AchievementRepository interface {
+	List(ctx context.Context, userID uint) ([]models.Achievement, error)
+	Sync(ctx context.Context, userID uint, earned []models.Achievement) error
+}
+
+// achievementRepository is a gorm backed AchievementRepository
+type achievementRepository struct {
+	db *gorm.DB
+}
+
+// NewAchievementRepository creates a new achievement repository
+func NewAchievementRepository(db *gorm.DB) AchievementRepository {
+	return &achievementRepository{db: db}
+}
+
+// List returns the user's badges, earliest earned first
+func (r *achievementRepository) List(ctx context.Context, userID uint) ([]models.Achievement, error) {
+	achievements := []models.Achievement{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("earned_at, id").Find(&achievements).Error
+	return achievements, err
+}
+
+// Sync makes earned the user's badges: new ones are stored, the earning time
+// of kept ones is corrected and the rest are removed
+func (r *achievementRepository) Sync(ctx context.Context, userID uint, earned []models.Achievement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored []models.Achievement
+		if err := tx.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+			return err
+		}
+		byCode := make(map[string]models.Achievement, len(stored))
+		for _, a := range stored {
+			byCode[a.Code] = a
+		}
+
+		for _, a := range earned {
+			existing, ok := byCode[a.Code]
+			delete(byCode, a.Code)
+			switch {
+			case !ok:
+				a.ID, a.UserID = 0, userID
+				if err := tx.Create(&a).Error; err != nil {
+					return err
+				}
+			case !existing.EarnedAt.Equal(a.EarnedAt):
+				if err := tx.Model(&existing).Update("earned_at", a.EarnedAt).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, a := range byCode {
+			if err := tx.Delete(&a).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ActivityFilter narrows down the activities returned by List
+type ActivityFilter struct {
+	UserID       uint
+	ActivityType string
+	DoneAtFrom   *time.Time
+	DoneAtTo     *time.Time
+	// DoneAtBefore is an exclusive upper bound, for consecutive periods
+	DoneAtBefore      *time.Time
+	CaloriesBurnedMin *int
+	CaloriesBurnedMax *int
+	Offset            int
+	Limit             int
+}
+
+// ActivityTotals sums up a set of activities
+type ActivityTotals struct {
+	Count          int64
+	Minutes        int64
+	CaloriesBurned int64
+}
+
+// ActivityBucketTotals sums up the activities of one type in one bucket of
+// TotalsByBucket
+type ActivityBucketTotals struct {
+	Bucket       int
+	ActivityType string
+	ActivityTotals
+}
+
+// ActivityTypeTotals sums up the activities of one type, see TotalsByType
+type ActivityTypeTotals struct {
+	ActivityType string
+	ActivityTotals
+	// LongestDistance is the longest distance in meters of one activity
+	LongestDistance float64
+}
+
+// ActivityRepository persists activities. All lookups are scoped to a user
+type ActivityRepository interface {
+	Create(ctx context.Context, activity *models.Activity) error
+	CreateWithTrack(ctx context.Context, activity *models.Activity, track *models.ActivityTrack) error
+	CreateBatch(ctx context.Context, activities []models.Activity) error
+	GetByID(ctx context.Context, userID, id uint) (*models.Activity, error)
+	List(ctx context.Context, filter ActivityFilter) ([]models.Activity, error)
+	Totals(ctx context.Context, filter ActivityFilter) (ActivityTotals, error)
+	TotalsByBucket(ctx context.Context, userID uint, bounds []time.Time) ([]ActivityBucketTotals, error)
+	TotalsByType(ctx context.Context, filter ActivityFilter) ([]ActivityTypeTotals, error)
+	Update(ctx context.Context, activity *models.Activity) error
+	Delete(ctx context.Context, userID, id, version uint) error
+	GetTrack(ctx context.Context, userID, activityID uint) (*models.ActivityTrack, error)
+	ListTracks(ctx context.Context, userID uint) ([]models.ActivityTrack, error)
+}
+
+// activityRepository is a gorm backed ActivityRepository
+type activityRepository struct {
+	db *gorm.DB
+}
+
+// NewActivityRepository creates a new activity repository
+func NewActivityRepository(db *gorm.DB) ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+// Create stores a new activity and assigns its ID. Like every write, it
+// stores DoneAt in UTC
+func (r *activityRepository) Create(ctx context.Context, activity *models.Activity) error {
+	activity.Version = 1
+	activity.DoneAt = activity.DoneAt.UTC()
+	return r.db.WithContext(ctx).Create(activity).Error
+}
+
+// CreateWithTrack stores a new activity together with its GPS track
+func (r *activityRepository) CreateWithTrack(ctx context.Context, activity *models.Activity, track *models.ActivityTrack) error {
+	activity.Version = 1
+	activity.DoneAt = activity.DoneAt.UTC()
+	activity.HasRoute = true
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(activity).Error; err != nil {
+			return err
+		}
+		track.ActivityID = activity.ID
+		track.UserID = activity.UserID
+		return tx.Create(track).Error
+	})
+}
+
+// CreateBatch stores several activities in batches
+func (r *activityRepository) CreateBatch(ctx context.Context, activities []models.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	for i := range activities {
+		activities[i].Version = 1
+		activities[i].DoneAt = activities[i].DoneAt.UTC()
+	}
+	return r.db.WithContext(ctx).CreateInBatches(activities, 500).Error
+}
+
+// GetByID returns one of the user's activities
+func (r *activityRepository) GetByID(ctx context.Context, userID, id uint) (*models.Activity, error) {
+	var activity models.Activity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&activity, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &activity, nil
+}
+
+// List returns the user's activities matching filter, most recent first
+func (r *activityRepository) List(ctx context.Context, filter ActivityFilter) ([]models.Activity, error) {
+	db := r.filter(ctx, filter)
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+
+	activities := []models.Activity{}
+	err := db.Order("done_at DESC, id DESC").Offset(filter.Offset).Find(&activities).Error
+	return activities, err
+}
+
+// Totals sums up the user's activities matching filter, ignoring its
+// offset and limit
+func (r *activityRepository) Totals(ctx context.Context, filter ActivityFilter) (ActivityTotals, error) {
+	var totals ActivityTotals
+	err := r.filter(ctx, filter).
+		Select("COUNT(*) AS count, COALESCE(SUM(duration_in_minutes), 0) AS minutes, " +
+			"COALESCE(SUM(calories_burned), 0) AS calories_burned").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// TotalsByBucket sums up the user's activities per type in consecutive
+// buckets, where bucket i holds those done from bounds[i] up to but not
+// including bounds[i+1]. Buckets without activities are left out. The
+// bounds are compared in the database, so it works with any calendar
+func (r *activityRepository) TotalsByBucket(ctx context.Context, userID uint, bounds []time.Time) ([]ActivityBucketTotals, error) {
+	totals := []ActivityBucketTotals{}
+	if len(bounds) < 2 {
+		return totals, nil
+	}
+
+	var bucket strings.Builder
+	args := make([]interface{}, 0, len(bounds)-2)
+	bucket.WriteString("CASE")
+	for i, bound := range bounds[1 : len(bounds)-1] {
+		fmt.Fprintf(&bucket, " WHEN done_at < ? THEN %d", i)
+		args = append(args, bound.UTC())
+	}
+	fmt.Fprintf(&bucket, " ELSE %d END", len(bounds)-2)
+
+	from, before := bounds[0].UTC(), bounds[len(bounds)-1].UTC()
+	err := r.filter(ctx, ActivityFilter{UserID: userID, DoneAtFrom: &from, DoneAtBefore: &before}).
+		Select(bucket.String()+" AS bucket, activity_type, COUNT(*) AS count, "+
+			"COALESCE(SUM(duration_in_minutes), 0) AS minutes, COALESCE(SUM(calories_burned), 0) AS calories_burned", args...).
+		Group("bucket, activity_type").Order("bucket, activity_type").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// TotalsByType sums up the user's activities matching filter per type,
+// ignoring its offset and limit
+func (r *activityRepository) TotalsByType(ctx context.Context, filter ActivityFilter) ([]ActivityTypeTotals, error) {
+	var totals []ActivityTypeTotals
+	err := r.filter(ctx, filter).
+		Select("activity_type, COUNT(*) AS count, COALESCE(SUM(duration_in_minutes), 0) AS minutes, " +
+			"COALESCE(SUM(calories_burned), 0) AS calories_burned, COALESCE(MAX(distance_in_meters), 0) AS longest_distance").
+		Group("activity_type").Order("activity_type").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// filter selects the user's activities matching filter. Times are stored in
+// UTC, and SQLite compares them as text, so the bounds are converted too
+func (r *activityRepository) filter(ctx context.Context, filter ActivityFilter) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&models.Activity{}).Where("user_id = ?", filter.UserID)
+	if filter.ActivityType != "" {
+		db = db.Where("activity_type = ?", filter.ActivityType)
+	}
+	if filter.DoneAtFrom != nil {
+		db = db.Where("done_at >= ?", filter.DoneAtFrom.UTC())
+	}
+	if filter.DoneAtTo != nil {
+		db = db.Where("done_at <= ?", filter.DoneAtTo.UTC())
+	}
+	if filter.DoneAtBefore != nil {
+		db = db.Where("done_at < ?", filter.DoneAtBefore.UTC())
+	}
+	if filter.CaloriesBurnedMin != nil {
+		db = db.Where("calories_burned >= ?", *filter.CaloriesBurnedMin)
+	}
+	if filter.CaloriesBurnedMax != nil {
+		db = db.Where("calories_burned <= ?", *filter.CaloriesBurnedMax)
+	}
+	return db
+}
+
+// Update replaces a stored activity if it is still at activity.Version, and
+// increments the version
+func (r *activityRepository) Update(ctx context.Context, activity *models.Activity) error {
+	version := activity.Version
+	activity.Version++
+	activity.DoneAt = activity.DoneAt.UTC()
+	res := r.db.WithContext(ctx).Model(activity).
+		Where("user_id = ? AND version = ?", activity.UserID, version).
+		Select("*").Omit("CreatedAt").Updates(activity)
+	if res.Error != nil || res.RowsAffected == 0 {
+		activity.Version = version
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return staleOrMissing(r.scope(ctx, activity.UserID, activity.ID))
+	}
+	return nil
+}
+
+// Delete removes one of the user's activities, and its track, if it is
+// still at version
+func (r *activityRepository) Delete(ctx context.Context, userID, id, version uint) error {
+	var deleted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND version = ?", userID, version).Delete(&models.Activity{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if deleted = res.RowsAffected > 0; !deleted {
+			return nil
+		}
+		return tx.Where("activity_id = ?", id).Delete(&models.ActivityTrack{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return staleOrMissing(r.scope(ctx, userID, id))
+	}
+	return nil
+}
+
+// GetTrack returns the GPS track of one of the user's activities
+func (r *activityRepository) GetTrack(ctx context.Context, userID, activityID uint) (*models.ActivityTrack, error) {
+	var track models.ActivityTrack
+	err := r.db.WithContext(ctx).Where("user_id = ? AND activity_id = ?", userID, activityID).First(&track).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &track, nil
+}
+
+// ListTracks returns the GPS tracks of all the user's activities
+func (r *activityRepository) ListTracks(ctx context.Context, userID uint) ([]models.ActivityTrack, error) {
+	tracks := []models.ActivityTrack{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("activity_id").Find(&tracks).Error
+	return tracks, err
+}
+
+// scope selects one of the user's activities
+func (r *activityRepository) scope(ctx context.Context, userID, id uint) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&models.Activity{}).Where("user_id = ? AND id = ?", userID, id)
+}
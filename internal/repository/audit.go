@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter selects audit entries
+type AuditFilter struct {
+	Action    string
+	ActorID   *uint
+	UserID    *uint
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Offset    int
+	Limit     int
+}
+
+// AuditRepository persists the audit log. Entries can only be appended
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, int64, error)
+}
+
+// auditRepository is a gorm backed AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Create appends an entry to the audit log
+func (r *auditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// List returns the entries matching filter, newest first, and how many
+// entries match in total
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.AuditEntry{})
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		db = db.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.UserID != nil {
+		db = db.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	// Entries are stored in UTC, and SQLite compares times as text
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", filter.To.UTC())
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []models.AuditEntry{}
+	err := db.Order("id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&entries).Error
+	return entries, total, err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ExportRepository persists personal data export jobs
+type ExportRepository interface {
+	Create(ctx context.Context, export *models.Export) error
+	GetByID(ctx context.Context, id uint) (*models.Export, error)
+	GetActive(ctx context.Context, userID uint) (*models.Export, error)
+	ListPending(ctx context.Context, limit int) ([]models.Export, error)
+	Claim(ctx context.Context, id uint) (bool, error)
+	ResetRunning(ctx context.Context) error
+	Update(ctx context.Context, export *models.Export) error
+	ListExpired(ctx context.Context, before time.Time) ([]models.Export, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.Export, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// exportRepository is a gorm backed ExportRepository
+type exportRepository struct {
+	db *gorm.DB
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+// Create stores a new export and assigns its ID
+func (r *exportRepository) Create(ctx context.Context, export *models.Export) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+// GetByID returns an export
+func (r *exportRepository) GetByID(ctx context.Context, id uint) (*models.Export, error) {
+	var export models.Export
+	if err := r.db.WithContext(ctx).First(&export, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &export, nil
+}
+
+// GetActive returns the user's pending or running export
+func (r *exportRepository) GetActive(ctx context.Context, userID uint) (*models.Export, error) {
+	var export models.Export
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userID, []string{models.ExportPending, models.ExportRunning}).
+		First(&export).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &export, nil
+}
+
+// ListPending returns up to limit pending exports, oldest first
+func (r *exportRepository) ListPending(ctx context.Context, limit int) ([]models.Export, error) {
+	exports := []models.Export{}
+	err := r.db.WithContext(ctx).Where("status = ?", models.ExportPending).
+		Order("id").Limit(limit).Find(&exports).Error
+	return exports, err
+}
+
+// Claim marks a pending export as running and reports whether it was still
+// pending, so that only one worker builds it
+func (r *exportRepository) Claim(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.Export{}).
+		Where("id = ? AND status = ?", id, models.ExportPending).
+		Update("status", models.ExportRunning)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ResetRunning marks running exports as pending again, for exports whose
+// worker stopped before finishing them
+func (r *exportRepository) ResetRunning(ctx context.Context) error {
+	return r.db.WithContext(ctx).Model(&models.Export{}).
+		Where("status = ?", models.ExportRunning).
+		Update("status", models.ExportPending).Error
+}
+
+// Update replaces a stored export
+func (r *exportRepository) Update(ctx context.Context, export *models.Export) error {
+	return r.db.WithContext(ctx).Model(export).Select("*").Omit("CreatedAt").Updates(export).Error
+}
+
+// ListExpired returns the exports that expired before the given time
+func (r *exportRepository) ListExpired(ctx context.Context, before time.Time) ([]models.Export, error) {
+	exports := []models.Export{}
+	err := r.db.WithContext(ctx).Where("expires_at < ?", before).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+// ListByUser returns all exports of a user
+func (r *exportRepository) ListByUser(ctx context.Context, userID uint) ([]models.Export, error) {
+	exports := []models.Export{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+// Delete removes an export
+func (r *exportRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Export{}, id).Error
+}
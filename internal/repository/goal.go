@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GoalRepository persists goals. All lookups are scoped to a user
+type GoalRepository interface {
+	Create(ctx context.Context, goal *models.Goal) error
+	GetByID(ctx context.Context, userID, id uint) (*models.Goal, error)
+	List(ctx context.Context, userID uint) ([]models.Goal, error)
+	Update(ctx context.Context, goal *models.Goal) error
+	Delete(ctx context.Context, userID, id, version uint) error
+}
+
+// goalRepository is a gorm backed GoalRepository
+type goalRepository struct {
+	db *gorm.DB
+}
+
+// NewGoalRepository creates a new goal repository
+func NewGoalRepository(db *gorm.DB) GoalRepository {
+	return &goalRepository{db: db}
+}
+
+// Create stores a new goal and assigns its ID
+func (r *goalRepository) Create(ctx context.Context, goal *models.Goal) error {
+	goal.Version = 1
+	return r.db.WithContext(ctx).Create(goal).Error
+}
+
+// GetByID returns one of the user's goals
+func (r *goalRepository) GetByID(ctx context.Context, userID, id uint) (*models.Goal, error) {
+	var goal models.Goal
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&goal, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &goal, nil
+}
+
+// List returns all of the user's goals, oldest first
+func (r *goalRepository) List(ctx context.Context, userID uint) ([]models.Goal, error) {
+	goals := []models.Goal{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&goals).Error
+	return goals, err
+}
+
+// Update replaces a stored goal if it is still at goal.Version, and
+// increments the version
+func (r *goalRepository) Update(ctx context.Context, goal *models.Goal) error {
+	version := goal.Version
+	goal.Version++
+	res := r.db.WithContext(ctx).Model(goal).
+		Where("user_id = ? AND version = ?", goal.UserID, version).
+		Select("*").Omit("CreatedAt").Updates(goal)
+	if res.Error != nil || res.RowsAffected == 0 {
+		goal.Version = version
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return staleOrMissing(r.scope(ctx, goal.UserID, goal.ID))
+	}
+	return nil
+}
+
+// Delete removes one of the user's goals if it is still at version
+func (r *goalRepository) Delete(ctx context.Context, userID, id, version uint) error {
+	res := r.db.WithContext(ctx).Where("user_id = ? AND version = ?", userID, version).Delete(&models.Goal{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return staleOrMissing(r.scope(ctx, userID, id))
+	}
+	return nil
+}
+
+// scope selects one of the user's goals
+func (r *goalRepository) scope(ctx context.Context, userID, id uint) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&models.Goal{}).Where("user_id = ? AND id = ?", userID, id)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MeasurementFilter narrows down the measurements returned by List
+type MeasurementFilter struct {
+	UserID uint
+	From   *time.Time
+	To     *time.Time
+	Offset int
+	// Limit is ignored when zero
+	Limit int
+}
+
+// MeasurementRepository persists body measurements. All lookups are scoped
+// to a user
+type MeasurementRepository interface {
+	Create(ctx context.Context, measurement *models.Measurement) error
+	List(ctx context.Context, filter MeasurementFilter) ([]models.Measurement, error)
+	LatestWeight(ctx context.Context, userID uint) (*models.Measurement, error)
+}
+
+// measurementRepository is a gorm backed MeasurementRepository
+type measurementRepository struct {
+	db *gorm.DB
+}
+
+// NewMeasurementRepository creates a new measurement repository
+func NewMeasurementRepository(db *gorm.DB) MeasurementRepository {
+	return &measurementRepository{db: db}
+}
+
+// Create stores a new measurement and assigns its ID. MeasuredAt is stored
+// in UTC, so that it sorts and compares correctly as text in SQLite
+func (r *measurementRepository) Create(ctx context.Context, measurement *models.Measurement) error {
+	measurement.MeasuredAt = measurement.MeasuredAt.UTC()
+	return r.db.WithContext(ctx).Create(measurement).Error
+}
+
+// List returns the user's measurements matching filter, newest first
+func (r *measurementRepository) List(ctx context.Context, filter MeasurementFilter) ([]models.Measurement, error) {
+	db := r.db.WithContext(ctx).Where("user_id = ?", filter.UserID)
+	if filter.From != nil {
+		db = db.Where("measured_at >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		db = db.Where("measured_at <= ?", filter.To.UTC())
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+
+	measurements := []models.Measurement{}
+	err := db.Order("measured_at DESC, id DESC").Offset(filter.Offset).Find(&measurements).Error
+	return measurements, err
+}
+
+// LatestWeight returns the user's most recent measurement with a weight
+func (r *measurementRepository) LatestWeight(ctx context.Context, userID uint) (*models.Measurement, error) {
+	var measurement models.Measurement
+	err := r.db.WithContext(ctx).Where("user_id = ? AND weight IS NOT NULL", userID).
+		Order("measured_at DESC, id DESC").First(&measurement).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &measurement, nil
+}
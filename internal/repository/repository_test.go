@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"fitbyte/internal/database"
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestDB returns a migrated SQLite database of the test's own
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestUser stores a user with email
+func newTestUser(t *testing.T, users UserRepository, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email, PasswordHash: "x"}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
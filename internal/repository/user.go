@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"fitbyte/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist
+	ErrNotFound = errors.New("record not found")
+	// ErrDuplicateEmail is returned when an email is already registered
+	ErrDuplicateEmail = errors.New("email already registered")
+	// ErrVersionConflict is returned when a record was changed since it was read
+	ErrVersionConflict = errors.New("record has been modified")
+)
+
+// UserRepository persists users
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context, offset, limit int) ([]models.User, int64, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id, version uint) error
+	GetDeleted(ctx context.Context, id uint) (*models.User, error)
+	ListDeleted(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	Restore(ctx context.Context, id uint) error
+	Purge(ctx context.Context, id uint) error
+}
+
+// userRepository is a gorm backed UserRepository. Emails are stored in
+// lower case so lookups can use the unique index. Writes are conditional on
+// the version read, so concurrent writers cannot overwrite each other
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+// Create stores a new user and assigns its ID
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	user.Email = strings.ToLower(user.Email)
+	user.Version = 1
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	return translateError(r.db.WithContext(ctx).Create(user).Error)
+}
+
+// GetByID returns the user with the given ID
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// GetByEmail returns the user with the given email, compared case-insensitively
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", strings.ToLower(email)).First(&user).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// List returns a page of users ordered by ID and the total number of users
+func (r *userRepository) List(ctx context.Context, offset, limit int) ([]models.User, int64, error) {
+	var total int64
+	db := r.db.WithContext(ctx).Model(&models.User{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	if err := db.Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Update replaces a stored user if it is still at user.Version, and
+// increments the version
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	user.Email = strings.ToLower(user.Email)
+	version := user.Version
+	user.Version++
+	res := r.db.WithContext(ctx).Model(user).Where("version = ?", version).
+		Select("*").Omit("CreatedAt").Updates(user)
+	if res.Error != nil || res.RowsAffected == 0 {
+		user.Version = version
+	}
+	if res.Error != nil {
+		return translateError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return staleOrMissing(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID))
+	}
+	return nil
+}
+
+// Delete soft deletes a user if it is still at version
+func (r *userRepository) Delete(ctx context.Context, id, version uint) error {
+	res := r.db.WithContext(ctx).Where("version = ?", version).Delete(&models.User{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return staleOrMissing(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id))
+	}
+	return nil
+}
+
+// GetDeleted returns a soft deleted user
+func (r *userRepository) GetDeleted(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// ListDeleted returns up to limit users soft deleted before the given time
+func (r *userRepository) ListDeleted(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	users := []models.User{}
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Restore undoes the soft delete of a user
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if res.Error != nil {
+		return translateError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge permanently deletes a user with their activities and tracks, goals,
+// measurements and achievements
+func (r *userRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Activity{}, &models.ActivityTrack{}, &models.Goal{}, &models.Measurement{}, &models.Achievement{}} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&models.User{}, id).Error
+	})
+}
+
+// staleOrMissing explains why a conditional write matched no rows:
+// ErrVersionConflict when query still finds the record, ErrNotFound otherwise
+func staleOrMissing(query *gorm.DB) error {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// translateError maps gorm errors to repository errors
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateEmail
+	default:
+		return err
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fitbyte/internal/database"
+	"fitbyte/internal/models"
+)
+
+func TestUserSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+	user := newTestUser(t, users, "Runner@Example.com")
+
+	if err := users.Delete(ctx, user.ID, user.Version+1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("Delete() at a stale version = %v, want ErrVersionConflict", err)
+	}
+	if err := users.Delete(ctx, user.ID, user.Version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetByID(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID() of a deleted user = %v, want ErrNotFound", err)
+	}
+	if _, err := users.GetByEmail(ctx, "runner@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByEmail() of a deleted user = %v, want ErrNotFound", err)
+	}
+	if _, total, err := users.List(ctx, 0, 10); err != nil || total != 0 {
+		t.Errorf("List() total = %d, %v, want 0", total, err)
+	}
+	if err := users.Delete(ctx, user.ID, user.Version); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() twice = %v, want ErrNotFound", err)
+	}
+
+	deleted, err := users.GetDeleted(ctx, user.ID)
+	if err != nil || !deleted.DeletedAt.Valid {
+		t.Fatalf("GetDeleted() = %+v, %v", deleted, err)
+	}
+	listed, err := users.ListDeleted(ctx, time.Now().Add(time.Minute), 10)
+	if err != nil || len(listed) != 1 || listed[0].ID != user.ID {
+		t.Errorf("ListDeleted() = %v, %v, want the deleted user", listed, err)
+	}
+	if listed, _ := users.ListDeleted(ctx, time.Now().Add(-time.Hour), 10); len(listed) != 0 {
+		t.Errorf("ListDeleted() before the deletion = %v", listed)
+	}
+
+	if err := users.Restore(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := users.GetByEmail(ctx, "runner@example.com")
+	if err != nil || restored.Version != user.Version+1 {
+		t.Fatalf("GetByEmail() after Restore() = %+v, %v, want version %d", restored, err, user.Version+1)
+	}
+	if err := users.Restore(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore() of a user that is not deleted = %v, want ErrNotFound", err)
+	}
+	if _, err := users.GetDeleted(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDeleted() of a restored user = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUserEmailReuse(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+	old := newTestUser(t, users, "runner@example.com")
+
+	// The email is taken until the user is deleted
+	if err := users.Create(ctx, &models.User{Email: "RUNNER@example.com", PasswordHash: "x"}); !errors.Is(err, ErrDuplicateEmail) {
+		t.Fatalf("Create() with a taken email = %v, want ErrDuplicateEmail", err)
+	}
+	if err := users.Delete(ctx, old.ID, old.Version); err != nil {
+		t.Fatal(err)
+	}
+	current := newTestUser(t, users, "runner@example.com")
+	if found, err := users.GetByEmail(ctx, "runner@example.com"); err != nil || found.ID != current.ID {
+		t.Errorf("GetByEmail() = %+v, %v, want the new user", found, err)
+	}
+
+	// The deleted user cannot come back while the email is in use again
+	if err := users.Restore(ctx, old.ID); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("Restore() = %v, want ErrDuplicateEmail", err)
+	}
+	if _, err := users.GetDeleted(ctx, old.ID); err != nil {
+		t.Errorf("GetDeleted() after a failed Restore() = %v", err)
+	}
+
+	// A second deleted user with the same email does not clash either
+	if err := users.Delete(ctx, current.ID, current.Version); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Purge(ctx, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.GetDeleted(ctx, old.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDeleted() after Purge() = %v, want ErrNotFound", err)
+	}
+	if err := users.Restore(ctx, current.ID); err != nil {
+		t.Errorf("Restore() once the email is free = %v", err)
+	}
+}
+
+func TestUserPurge(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := NewUserRepository(db)
+	activities := NewActivityRepository(db)
+	user := newTestUser(t, users, "runner@example.com")
+	other := newTestUser(t, users, "other@example.com")
+	for _, id := range []uint{user.ID, other.ID} {
+		activity := &models.Activity{UserID: id, ActivityType: models.ActivityRunning, DoneAt: time.Now(), DurationInMinutes: 30}
+		if err := activities.Create(ctx, activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := users.Purge(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("purged user rows = %d, %v, want 0", count, err)
+	}
+	if list, err := activities.List(ctx, ActivityFilter{UserID: user.ID, Limit: 10}); err != nil || len(list) != 0 {
+		t.Errorf("activities of the purged user = %v, %v", list, err)
+	}
+	if list, err := activities.List(ctx, ActivityFilter{UserID: other.ID, Limit: 10}); err != nil || len(list) != 1 {
+		t.Errorf("activities of another user = %v, %v, want 1", list, err)
+	}
+}
+
+func TestMigrateDropsGlobalEmailIndex(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	// Databases created before emails could be reused have a unique index
+	// covering deleted users too
+	if err := db.Exec("CREATE UNIQUE INDEX idx_users_email ON users (email)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasIndex(&models.User{}, "idx_users_email") {
+		t.Fatal("Migrate() kept idx_users_email")
+	}
+
+	users := NewUserRepository(db)
+	old := newTestUser(t, users, "runner@example.com")
+	if err := users.Delete(ctx, old.ID, old.Version); err != nil {
+		t.Fatal(err)
+	}
+	newTestUser(t, users, "runner@example.com")
+}
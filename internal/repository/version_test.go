@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+func TestActivityVersions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, NewUserRepository(db), "runner@example.com")
+	activities := NewActivityRepository(db)
+
+	activity := &models.Activity{UserID: user.ID, ActivityType: models.ActivityRunning, DoneAt: time.Now(), DurationInMinutes: 30}
+	if err := activities.Create(ctx, activity); err != nil {
+		t.Fatal(err)
+	}
+	if activity.Version != 1 {
+		t.Fatalf("created at version %d, want 1", activity.Version)
+	}
+
+	// Two writers read version 1; only the first write wins
+	first, second := *activity, *activity
+	first.DurationInMinutes = 40
+	if err := activities.Update(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != 2 {
+		t.Errorf("updated to version %d, want 2", first.Version)
+	}
+	second.DurationInMinutes = 50
+	if err := activities.Update(ctx, &second); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale update error = %v, want ErrVersionConflict", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("failed update left version %d, want 1", second.Version)
+	}
+	stored, err := activities.GetByID(ctx, user.ID, activity.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.DurationInMinutes != 40 || stored.Version != 2 {
+		t.Errorf("stored %d minutes at version %d, want 40 at version 2", stored.DurationInMinutes, stored.Version)
+	}
+
+	tests := []struct {
+		name    string
+		userID  uint
+		id      uint
+		version uint
+		want    error
+	}{
+		{"stale", user.ID, activity.ID, 1, ErrVersionConflict},
+		{"other user", user.ID + 1, activity.ID, 2, ErrNotFound},
+		{"missing", user.ID, activity.ID + 1, 1, ErrNotFound},
+		{"current", user.ID, activity.ID, 2, nil},
+		{"deleted", user.ID, activity.ID, 2, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := activities.Delete(ctx, tt.userID, tt.id, tt.version); !errors.Is(err, tt.want) {
+				t.Errorf("Delete error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserVersions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	users := NewUserRepository(db)
+	user := newTestUser(t, users, "Someone@Example.com")
+
+	first, second := *user, *user
+	name := "First"
+	first.Name = &name
+	if err := users.Update(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Update(ctx, &second); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale update error = %v, want ErrVersionConflict", err)
+	}
+	missing := models.User{ID: user.ID + 1, Email: "nobody@example.com", Version: 1}
+	if err := users.Update(ctx, &missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("update of a missing user error = %v, want ErrNotFound", err)
+	}
+	if err := users.Delete(ctx, user.ID, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale delete error = %v, want ErrVersionConflict", err)
+	}
+	if err := users.Delete(ctx, user.ID, 2); err != nil {
+		t.Errorf("Delete error = %v", err)
+	}
+}
+
+func TestGoalVersions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, NewUserRepository(db), "goals@example.com")
+	goals := NewGoalRepository(db)
+
+	goal := &models.Goal{UserID: user.ID, Type: models.GoalWeeklyMinutes, Target: 150, StartsAt: time.Now()}
+	if err := goals.Create(ctx, goal); err != nil {
+		t.Fatal(err)
+	}
+	first, second := *goal, *goal
+	first.Target = 200
+	if err := goals.Update(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := goals.Update(ctx, &second); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale update error = %v, want ErrVersionConflict", err)
+	}
+	if err := goals.Delete(ctx, user.ID, goal.ID, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("stale delete error = %v, want ErrVersionConflict", err)
+	}
+	if err := goals.Delete(ctx, user.ID, goal.ID, 2); err != nil {
+		t.Errorf("Delete error = %v", err)
+	}
+}
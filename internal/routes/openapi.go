@@ -0,0 +1,202 @@
+package routes
+
+import (
+	"net/http"
+
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/mergepatch"
+	"fitbyte/internal/models"
+	"fitbyte/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec returns the OpenAPI document for the routes registered by
+// SetupRoutes. Every route added there must be documented here
+func Spec() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "FitByte API",
+		Version:     "1.0.0",
+		Description: "Track workouts, body measurements and fitness goals.",
+	})
+
+	for _, r := range specRoutes() {
+		b.Add(r)
+	}
+	return b.Document()
+}
+
+func specRoutes() []openapi.Route {
+	notFound := []int{http.StatusNotFound}
+	adminOnly := []string{models.RoleAdmin}
+	staff := []string{models.RoleAdmin, models.RoleCoach}
+	maxKeyLength := 255
+	idempotencyKey := openapi.Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Unique key for the request; retries with the same key and body replay the first response",
+		Schema:      &openapi.Schema{Type: "string", MaxLength: &maxKeyLength},
+	}
+
+	return []openapi.Route{
+		// Docs
+		{Method: http.MethodGet, Path: "/", Summary: "API information", Tag: "Meta", Response: gin.H{}, Raw: true},
+		{Method: http.MethodGet, Path: "/openapi.json", Summary: "OpenAPI document", Tag: "Meta", Response: map[string]interface{}{}, Raw: true},
+		{Method: http.MethodGet, Path: "/docs", Summary: "Interactive API documentation", Tag: "Meta", Raw: true, ContentType: "text/html"},
+
+		{Method: http.MethodGet, Path: "/uploads/*filepath", Summary: "Download an uploaded file", Tag: "Files", Raw: true, ContentType: "application/octet-stream", Errors: notFound},
+		{Method: http.MethodHead, Path: "/uploads/*filepath", Summary: "Check an uploaded file", Tag: "Files", Raw: true, ContentType: "application/octet-stream", Errors: notFound},
+
+		// Health
+		{Method: http.MethodGet, Path: "/api/v1/health/", Summary: "Health status", Tag: "Health", Response: gin.H{}},
+		{Method: http.MethodGet, Path: "/api/v1/health/ready", Summary: "Readiness check", Tag: "Health", Response: gin.H{}, Errors: []int{http.StatusServiceUnavailable}},
+
+		// Auth
+		{Method: http.MethodPost, Path: "/api/v1/register", Summary: "Register an account", Tag: "Auth",
+			Body: models.RegisterRequest{}, Status: http.StatusCreated, Response: models.AuthResponse{},
+			Errors: []int{http.StatusConflict, http.StatusTooManyRequests}},
+		{Method: http.MethodPost, Path: "/api/v1/login", Summary: "Log in", Tag: "Auth",
+			Body: models.LoginRequest{}, Response: models.AuthResponse{},
+			Errors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests}},
+
+		// Admin
+		{Method: http.MethodGet, Path: "/api/v1/admin/lockouts", Summary: "List login failures and lockouts", Tag: "Admin",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Response: []lockout.Entry{},
+			Params: []openapi.Parameter{{Name: "locked", In: "query", Description: "Only return active locks", Schema: &openapi.Schema{Type: "boolean"}}}},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/lockouts", Summary: "Clear login failures for an email or IP", Tag: "Admin",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Errors: []int{http.StatusBadRequest},
+			Params: []openapi.Parameter{
+				{Name: "email", In: "query", Schema: &openapi.Schema{Type: "string", Format: "email"}},
+				{Name: "ip", In: "query", Schema: &openapi.Schema{Type: "string"}},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/audit", Summary: "Search the audit log", Tag: "Admin",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Query: models.AuditQuery{},
+			Response: []models.AuditEntry{}, Paginated: true},
+
+		// Users
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/", Summary: "List users", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: staff, Response: []models.UserResponse{}, Paginated: true,
+			Params: []openapi.Parameter{
+				{Name: "page", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+				{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/users/:id", Summary: "Get a user", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: staff, Response: models.UserResponse{}, Errors: notFound, Conditional: true},
+		{Method: http.MethodPost, Path: "/api/v1/admin/users/", Summary: "Create a user", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly,
+			Body: models.CreateUserRequest{}, Status: http.StatusCreated, Response: models.UserResponse{},
+			Params: []openapi.Parameter{idempotencyKey}, Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+		{Method: http.MethodPut, Path: "/api/v1/admin/users/:id", Summary: "Replace a user", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Body: models.ReplaceUserRequest{}, Response: models.UserResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict}, Conditional: true},
+		{Method: http.MethodPatch, Path: "/api/v1/admin/users/:id", Summary: "Update a user with a JSON merge patch", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly, RequestContentType: mergepatch.ContentType,
+			Body: models.UpdateUserRequest{}, Response: models.UserResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType}, Conditional: true},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/users/:id", Summary: "Delete a user", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Errors: notFound, Conditional: true},
+		{Method: http.MethodPut, Path: "/api/v1/admin/users/:id/role", Summary: "Change a user's role", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Body: models.SetRoleRequest{}, Response: models.UserResponse{},
+			Errors: notFound, Conditional: true},
+		{Method: http.MethodPost, Path: "/api/v1/admin/users/:id/restore", Summary: "Restore a deleted user", Tag: "Users",
+			Security: openapi.SecurityBearer, Roles: adminOnly, Response: models.UserResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusGone}},
+
+		// Profile
+		{Method: http.MethodGet, Path: "/api/v1/user", Summary: "Get the current user's profile", Tag: "Profile",
+			Security: openapi.SecurityBearer, Response: models.UserResponse{}, Errors: notFound, Conditional: true},
+		{Method: http.MethodPut, Path: "/api/v1/user", Summary: "Replace the current user's profile", Tag: "Profile",
+			Security: openapi.SecurityBearer, Body: models.ReplaceUserRequest{}, Response: models.UserResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict}, Conditional: true},
+		{Method: http.MethodPatch, Path: "/api/v1/user", Summary: "Update the current user's profile with a JSON merge patch", Tag: "Profile",
+			Security: openapi.SecurityBearer, RequestContentType: mergepatch.ContentType,
+			Body: models.UpdateUserRequest{}, Response: models.UserResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusUnsupportedMediaType}, Conditional: true},
+		{Method: http.MethodDelete, Path: "/api/v1/user", Summary: "Permanently delete your account", Tag: "Profile",
+			Security: openapi.SecurityBearer, Body: models.DeleteAccountRequest{},
+			Errors: []int{http.StatusForbidden, http.StatusNotFound, http.StatusTooManyRequests}, Conditional: true},
+		{Method: http.MethodGet, Path: "/api/v1/user/metrics", Summary: "Get BMI, BMR and TDEE", Tag: "Profile",
+			Security: openapi.SecurityBearer, Query: models.MetricsQuery{}, Response: models.HealthMetrics{}, Errors: notFound},
+
+		{Method: http.MethodPost, Path: "/api/v1/user/export", Summary: "Request an export of all your data", Tag: "Profile",
+			Security: openapi.SecurityBearer, Status: http.StatusAccepted, Response: models.ExportResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/user/export/:id", Summary: "Get the status of a data export", Tag: "Profile",
+			Security: openapi.SecurityBearer, Response: models.ExportResponse{}, Errors: notFound},
+		{Method: http.MethodGet, Path: "/api/v1/user/export/:id/download", Summary: "Download a data export with a signed link", Tag: "Profile",
+			Raw: true, ContentType: "application/zip",
+			Params: []openapi.Parameter{
+				{Name: "expires", In: "query", Required: true, Schema: &openapi.Schema{Type: "integer"}},
+				{Name: "signature", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			},
+			Errors: []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusGone}},
+
+		// Files
+		{Method: http.MethodPost, Path: "/api/v1/file", Summary: "Upload a JPEG or PNG image", Tag: "Files",
+			Security: openapi.SecurityBearer, Response: models.FileResponse{},
+			RequestContentType: "multipart/form-data", Body: models.FileUpload{},
+			Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests}},
+
+		// Activities
+		{Method: http.MethodGet, Path: "/api/v1/activity/", Summary: "List activities", Tag: "Activities",
+			Security: openapi.SecurityBearer, Query: models.ActivityQuery{}, Response: []models.ActivityResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/activity/:id", Summary: "Get an activity", Tag: "Activities",
+			Security: openapi.SecurityBearer, Response: models.ActivityResponse{}, Errors: notFound, Conditional: true},
+		{Method: http.MethodGet, Path: "/api/v1/activity/:id/route", Summary: "Get the GPS route of an activity as GeoJSON", Tag: "Activities",
+			Security: openapi.SecurityBearer, Response: models.ActivityRoute{}, Raw: true, ContentType: "application/geo+json",
+			Errors: notFound},
+		{Method: http.MethodPost, Path: "/api/v1/activity/", Summary: "Log an activity", Tag: "Activities",
+			Security: openapi.SecurityBearer, Body: models.CreateActivityRequest{}, Status: http.StatusCreated,
+			Response: models.ActivityResponse{}, Params: []openapi.Parameter{idempotencyKey},
+			Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+		{Method: http.MethodPut, Path: "/api/v1/activity/:id", Summary: "Replace an activity", Tag: "Activities",
+			Security: openapi.SecurityBearer, Body: models.ReplaceActivityRequest{}, Response: models.ActivityResponse{},
+			Errors: notFound, Conditional: true},
+		{Method: http.MethodPatch, Path: "/api/v1/activity/:id", Summary: "Update an activity with a JSON merge patch", Tag: "Activities",
+			Security: openapi.SecurityBearer, RequestContentType: mergepatch.ContentType,
+			Body: models.UpdateActivityRequest{}, Response: models.ActivityResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusUnsupportedMediaType}, Conditional: true},
+		{Method: http.MethodDelete, Path: "/api/v1/activity/:id", Summary: "Delete an activity", Tag: "Activities",
+			Security: openapi.SecurityBearer, Errors: notFound, Conditional: true},
+		{Method: http.MethodPost, Path: "/api/v1/activity/import", Summary: "Import activities from a GPX, TCX or FIT file", Tag: "Activities",
+			Security: openapi.SecurityBearer, Response: models.ActivityImport{},
+			RequestContentType: "multipart/form-data", Body: models.ActivityImportUpload{},
+			Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests}},
+
+		// Goals
+		{Method: http.MethodGet, Path: "/api/v1/goals/", Summary: "List goals", Tag: "Goals",
+			Security: openapi.SecurityBearer, Response: []models.GoalResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/goals/:id", Summary: "Get a goal", Tag: "Goals",
+			Security: openapi.SecurityBearer, Response: models.GoalResponse{}, Errors: notFound, Conditional: true},
+		{Method: http.MethodPost, Path: "/api/v1/goals/", Summary: "Set a goal", Tag: "Goals",
+			Security: openapi.SecurityBearer, Body: models.CreateGoalRequest{}, Status: http.StatusCreated,
+			Response: models.GoalResponse{}, Params: []openapi.Parameter{idempotencyKey},
+			Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+		{Method: http.MethodPut, Path: "/api/v1/goals/:id", Summary: "Replace a goal", Tag: "Goals",
+			Security: openapi.SecurityBearer, Body: models.ReplaceGoalRequest{}, Response: models.GoalResponse{},
+			Errors: notFound, Conditional: true},
+		{Method: http.MethodPatch, Path: "/api/v1/goals/:id", Summary: "Update a goal with a JSON merge patch", Tag: "Goals",
+			Security: openapi.SecurityBearer, RequestContentType: mergepatch.ContentType,
+			Body: models.UpdateGoalRequest{}, Response: models.GoalResponse{},
+			Errors: []int{http.StatusNotFound, http.StatusUnsupportedMediaType}, Conditional: true},
+		{Method: http.MethodDelete, Path: "/api/v1/goals/:id", Summary: "Delete a goal", Tag: "Goals",
+			Security: openapi.SecurityBearer, Errors: notFound, Conditional: true},
+		{Method: http.MethodGet, Path: "/api/v1/goals/:id/progress", Summary: "Get progress towards a goal", Tag: "Goals",
+			Security: openapi.SecurityBearer, Response: models.GoalProgress{}, Errors: notFound},
+
+		// Stats
+		{Method: http.MethodGet, Path: "/api/v1/stats/summary", Summary: "Summarise activities per day, week or month", Tag: "Stats",
+			Security: openapi.SecurityBearer, Query: models.StatsQuery{}, Response: models.StatsSummary{}},
+
+		// Achievements
+		{Method: http.MethodGet, Path: "/api/v1/achievements", Summary: "Get activity streaks and earned badges", Tag: "Achievements",
+			Security: openapi.SecurityBearer, Response: models.AchievementSummary{}},
+
+		// Measurements
+		{Method: http.MethodGet, Path: "/api/v1/measurements", Summary: "List body measurements", Tag: "Measurements",
+			Security: openapi.SecurityBearer, Query: models.MeasurementQuery{}, Response: []models.MeasurementResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/measurements", Summary: "Record a body measurement", Tag: "Measurements",
+			Security: openapi.SecurityBearer, Body: models.CreateMeasurementRequest{}, Status: http.StatusCreated,
+			Response: models.MeasurementResponse{}, Params: []openapi.Parameter{idempotencyKey},
+			Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity}},
+	}
+}
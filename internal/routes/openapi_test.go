@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"fitbyte/internal/middleware"
+	"fitbyte/internal/openapi"
+	"fitbyte/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	policy := ratelimit.Policy{Limit: 1, Period: 1}
+	SetupRoutes(router, Handlers{}, Middleware{
+		RateLimiter: middleware.NewRateLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Policy{
+			middleware.RateLimitDefault: policy,
+			middleware.RateLimitLogin:   policy,
+			middleware.RateLimitUpload:  policy,
+		}, zerolog.Nop()),
+	})
+	return router
+}
+
+func TestSpecDocumentsEveryRoute(t *testing.T) {
+	doc := Spec()
+	for _, route := range newTestRouter().Routes() {
+		if _, ok := doc.Operation(route.Method, openapi.Path(route.Path)); !ok {
+			t.Errorf("%s %s is registered but missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+}
+
+func TestSpecOnlyDocumentsRegisteredRoutes(t *testing.T) {
+	registered := map[string]bool{}
+	for _, route := range newTestRouter().Routes() {
+		registered[route.Method+" "+openapi.Path(route.Path)] = true
+	}
+
+	for path, item := range Spec().Paths {
+		for method := range *item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestSpecMarshals(t *testing.T) {
+	data, err := json.Marshal(Spec())
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal spec: %v", err)
+	}
+	if decoded["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v, want 3.1.0", decoded["openapi"])
+	}
+}
@@ -1,40 +1,163 @@
 package routes
 
 import (
+	"net/http"
+
 	"fitbyte/internal/handlers"
+	"fitbyte/internal/middleware"
+	"fitbyte/internal/models"
+	"fitbyte/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Handlers groups the handlers served by the router
+type Handlers struct {
+	Health      *handlers.HealthHandler
+	User        *handlers.UserHandler
+	Auth        *handlers.AuthHandler
+	Lockout     *handlers.LockoutHandler
+	Audit       *handlers.AuditHandler
+	Activity    *handlers.ActivityHandler
+	Achievement *handlers.AchievementHandler
+	Docs        *handlers.DocsHandler
+	File        *handlers.FileHandler
+	Export      *handlers.ExportHandler
+	Goal        *handlers.GoalHandler
+	Measurement *handlers.MeasurementHandler
+	Metrics     *handlers.MetricsHandler
+	Stats       *handlers.StatsHandler
+
+	// Uploads serves files saved by local storage
+	Uploads http.FileSystem
+}
+
+// Middleware groups the route-level middleware used by the router
+type Middleware struct {
+	RateLimiter *middleware.RateLimiter
+	Auth        gin.HandlerFunc
+	// IfMatch guards writes to versioned resources
+	IfMatch gin.HandlerFunc
+	// Idempotency replays responses to retried creates
+	Idempotency gin.HandlerFunc
+	// Validate checks requests against the OpenAPI document once they are
+	// authenticated and within their rate limit; nil skips the check
+	Validate gin.HandlerFunc
+}
+
 // SetupRoutes configures all the routes for the application
-func SetupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, userHandler *handlers.UserHandler) {
+func SetupRoutes(router *gin.Engine, h Handlers, mw Middleware) {
+	limit := mw.RateLimiter.Limit
+	validate := mw.Validate
+	if validate == nil {
+		validate = func(c *gin.Context) { c.Next() }
+	}
+
 	// API version 1
 	v1 := router.Group("/api/v1")
 	{
 		// Health check routes
-		health := v1.Group("/health")
+		health := v1.Group("/health", validate)
+		{
+			health.GET("/", h.Health.Health)
+			health.GET("/ready", h.Health.Ready)
+		}
+
+		// Auth routes
+		v1.POST("/register", limit(middleware.RateLimitDefault), validate, h.Auth.Register)
+		v1.POST("/login", limit(middleware.RateLimitLogin), validate, h.Auth.Login)
+
+		// Admin routes, validated after the role check
+		requireAdmin := middleware.RequireRole(models.RoleAdmin)
+		requireStaff := middleware.RequireRole(models.RoleAdmin, models.RoleCoach)
+		admin := v1.Group("/admin", mw.Auth, limit(middleware.RateLimitDefault))
+		{
+			admin.GET("/lockouts", requireAdmin, validate, h.Lockout.GetLockouts)
+			admin.DELETE("/lockouts", requireAdmin, validate, h.Lockout.ClearLockout)
+			admin.GET("/audit", requireAdmin, validate, h.Audit.GetAuditLog)
+
+			// Coaches can look users up; only admins can change them
+			users := admin.Group("/users", mw.IfMatch)
+			users.GET("/", requireStaff, validate, h.User.GetUsers)
+			users.GET("/:id", requireStaff, validate, h.User.GetUser)
+			users.POST("/", requireAdmin, validate, mw.Idempotency, h.User.CreateUser)
+			users.PUT("/:id", requireAdmin, validate, h.User.ReplaceUser)
+			users.PATCH("/:id", requireAdmin, validate, h.User.UpdateUser)
+			users.DELETE("/:id", requireAdmin, validate, h.User.DeleteUser)
+			users.PUT("/:id/role", requireAdmin, validate, h.User.SetUserRole)
+			users.POST("/:id/restore", requireAdmin, validate, h.User.RestoreUser)
+		}
+
+		// Profile routes for the authenticated user
+		profile := v1.Group("/user", mw.Auth, limit(middleware.RateLimitDefault), mw.IfMatch, validate)
+		{
+			profile.GET("", h.User.GetProfile)
+			profile.PUT("", h.User.ReplaceProfile)
+			profile.PATCH("", h.User.UpdateProfile)
+			profile.DELETE("", h.User.DeleteProfile)
+			profile.GET("/metrics", h.Metrics.GetMetrics)
+			profile.POST("/export", h.Export.RequestExport)
+			profile.GET("/export/:id", h.Export.GetExport)
+		}
+
+		// Export downloads are authorized by the signed link instead of a token
+		v1.GET("/user/export/:id/download", limit(middleware.RateLimitDefault), validate, h.Export.Download)
+
+		// File uploads
+		v1.POST("/file", mw.Auth, limit(middleware.RateLimitUpload), validate, h.File.Upload)
+		v1.POST("/activity/import", mw.Auth, limit(middleware.RateLimitUpload), validate, h.Activity.ImportActivities)
+
+		// Activity routes for the authenticated user
+		activity := v1.Group("/activity", mw.Auth, limit(middleware.RateLimitDefault), mw.IfMatch, validate)
 		{
-			health.GET("/", healthHandler.Health)
-			health.GET("/ready", healthHandler.Ready)
+			activity.GET("/", h.Activity.GetActivities)
+			activity.GET("/:id", h.Activity.GetActivity)
+			activity.GET("/:id/route", h.Activity.GetActivityRoute)
+			activity.POST("/", mw.Idempotency, h.Activity.CreateActivity)
+			activity.PUT("/:id", h.Activity.ReplaceActivity)
+			activity.PATCH("/:id", h.Activity.UpdateActivity)
+			activity.DELETE("/:id", h.Activity.DeleteActivity)
 		}
 
-		// User routes
-		users := v1.Group("/users")
+		// Goal routes for the authenticated user
+		goals := v1.Group("/goals", mw.Auth, limit(middleware.RateLimitDefault), mw.IfMatch, validate)
 		{
-			users.GET("/", userHandler.GetUsers)
-			users.GET("/:id", userHandler.GetUser)
-			users.POST("/", userHandler.CreateUser)
-			users.PUT("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			goals.GET("/", h.Goal.GetGoals)
+			goals.GET("/:id", h.Goal.GetGoal)
+			goals.POST("/", mw.Idempotency, h.Goal.CreateGoal)
+			goals.PUT("/:id", h.Goal.ReplaceGoal)
+			goals.PATCH("/:id", h.Goal.UpdateGoal)
+			goals.DELETE("/:id", h.Goal.DeleteGoal)
+			goals.GET("/:id/progress", h.Goal.GetGoalProgress)
+		}
+
+		// Activity statistics for the authenticated user
+		v1.GET("/stats/summary", mw.Auth, limit(middleware.RateLimitDefault), validate, h.Stats.GetSummary)
+
+		// Streaks and earned badges of the authenticated user
+		v1.GET("/achievements", mw.Auth, limit(middleware.RateLimitDefault), validate, h.Achievement.GetAchievements)
+
+		// Body measurement routes for the authenticated user
+		measurements := v1.Group("/measurements", mw.Auth, limit(middleware.RateLimitDefault), validate)
+		{
+			measurements.GET("", h.Measurement.GetMeasurements)
+			measurements.POST("", mw.Idempotency, h.Measurement.CreateMeasurement)
 		}
 	}
 
+	// Uploaded files
+	router.StaticFS(storage.URLPath, h.Uploads)
+
+	// API documentation
+	router.GET("/openapi.json", h.Docs.Spec)
+	router.GET("/docs", h.Docs.UI)
+
 	// Root route
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Welcome to FitByte API",
 			"version": "1.0.0",
-			"docs":    "/api/v1/health",
+			"docs":    "/docs",
 		})
 	})
 }
@@ -0,0 +1,145 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+// Options controls what the generator produces. The same options always
+// produce the same data
+type Options struct {
+	// Seed makes the output reproducible
+	Seed uint64
+	// Users is the number of users to generate
+	Users int
+	// Months is how many months of activities to generate per user
+	Months int
+	// Until is the last day activities are generated for
+	Until time.Time
+}
+
+// DefaultUntil is the last day generated when no other is given. It is
+// fixed rather than today, so that the same seed always produces the same
+// data
+var DefaultUntil = time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// Account is a generated user together with their activities
+type Account struct {
+	User       models.User
+	Activities []models.Activity
+}
+
+var firstNames = []string{
+	"Aisyah", "Budi", "Citra", "Dimas", "Eka", "Fajar", "Gita", "Hendra",
+	"Intan", "Joko", "Kartika", "Lukas", "Maya", "Nadia", "Oscar", "Putri",
+	"Rizky", "Sari", "Tomas", "Umar", "Vina", "Wulan", "Yusuf", "Zahra",
+}
+
+var lastNames = []string{
+	"Pratama", "Santoso", "Wijaya", "Halim", "Nugroho", "Saputra", "Kusuma",
+	"Hidayat", "Lestari", "Gunawan", "Siregar", "Tanaka", "Miller", "Garcia",
+}
+
+// persona biases which activities a user logs and how often
+type persona struct {
+	activeDays float64
+	types      []string
+	minMinutes int
+	maxMinutes int
+}
+
+var personas = []persona{
+	{activeDays: 0.7, types: []string{models.ActivityRunning, models.ActivityCycling, models.ActivityHIIT, models.ActivityStretching}, minMinutes: 25, maxMinutes: 75},
+	{activeDays: 0.5, types: []string{models.ActivityYoga, models.ActivityStretching, models.ActivityWalking, models.ActivityDancing}, minMinutes: 20, maxMinutes: 60},
+	{activeDays: 0.35, types: []string{models.ActivityWalking, models.ActivityHiking, models.ActivitySwimming}, minMinutes: 30, maxMinutes: 120},
+	{activeDays: 0.6, types: []string{models.ActivityJumpRope, models.ActivityHIIT, models.ActivityRunning, models.ActivitySwimming, models.ActivityCycling}, minMinutes: 10, maxMinutes: 45},
+}
+
+// Email returns the email address of the i-th generated user
+func Email(i int) string {
+	return fmt.Sprintf("user%04d@seed.fitbyte.local", i+1)
+}
+
+// Generate returns the i-th account. Each account has its own random stream,
+// so account i is the same regardless of how many accounts are generated
+func Generate(opts Options, i int) Account {
+	r := rand.New(rand.NewPCG(opts.Seed, uint64(i)))
+
+	user := generateUser(r, i)
+	p := personas[r.IntN(len(personas))]
+
+	until := time.Date(opts.Until.Year(), opts.Until.Month(), opts.Until.Day(), 0, 0, 0, 0, time.UTC)
+	from := until.AddDate(0, -opts.Months, 0)
+
+	var activities []models.Activity
+	for day := from; !day.After(until); day = day.AddDate(0, 0, 1) {
+		if r.Float64() >= p.activeDays {
+			continue
+		}
+		sessions := 1
+		if r.Float64() < 0.15 {
+			sessions = 2
+		}
+		for s := 0; s < sessions; s++ {
+			activityType := p.types[r.IntN(len(p.types))]
+			minutes := p.minMinutes + r.IntN(p.maxMinutes-p.minMinutes+1)
+			// Round to 5 minutes like people usually log
+			minutes = int(math.Max(5, math.Round(float64(minutes)/5)*5))
+			doneAt := day.Add(time.Duration(6*60+r.IntN(15*60)) * time.Minute)
+
+			activities = append(activities, models.Activity{
+				ActivityType:      activityType,
+				DoneAt:            doneAt,
+				DurationInMinutes: minutes,
+				CaloriesBurned:    models.CaloriesPerMinute[activityType] * minutes,
+				CreatedAt:         doneAt,
+				UpdatedAt:         doneAt,
+			})
+		}
+	}
+
+	return Account{User: user, Activities: activities}
+}
+
+func generateUser(r *rand.Rand, i int) models.User {
+	name := firstNames[r.IntN(len(firstNames))] + " " + lastNames[r.IntN(len(lastNames))]
+	imageURI := fmt.Sprintf("https://i.pravatar.cc/300?img=%d", 1+r.IntN(70))
+
+	// Roughly a third of users prefer imperial units
+	preference, weightUnit, heightUnit := "metric", "kg", "cm"
+	weightKg := 50 + r.Float64()*55
+	heightCm := 150 + r.Float64()*45
+	weight, height := weightKg, heightCm
+	if r.IntN(3) == 0 {
+		preference, weightUnit, heightUnit = "imperial", "lbs", "in"
+		weight = weightKg * 2.20462
+		height = heightCm / 2.54
+	}
+	weight = math.Round(weight*10) / 10
+	height = math.Round(height*10) / 10
+
+	user := models.User{
+		Email:      Email(i),
+		Name:       &name,
+		Preference: &preference,
+		WeightUnit: &weightUnit,
+		HeightUnit: &heightUnit,
+		Weight:     &weight,
+		Height:     &height,
+	}
+	// Not everyone uploads a profile picture
+	if r.IntN(4) != 0 {
+		user.ImageURI = &imageURI
+	}
+	// Most users share their sex and birth date, which health metrics need
+	if r.IntN(5) != 0 {
+		sex := []string{"male", "female"}[r.IntN(2)]
+		birthDate := time.Date(1955+r.IntN(50), time.Month(1+r.IntN(12)), 1+r.IntN(28), 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+		user.Sex, user.BirthDate = &sex, &birthDate
+	}
+	return user
+}
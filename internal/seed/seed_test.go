@@ -0,0 +1,55 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Seed: 42, Users: 3, Months: 2, Until: DefaultUntil}
+	for i := range opts.Users {
+		a, b := Generate(opts, i), Generate(opts, i)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("account %d differs between runs", i)
+		}
+	}
+
+	// An account does not depend on how many are generated
+	more := opts
+	more.Users = 10
+	if !reflect.DeepEqual(Generate(opts, 1), Generate(more, 1)) {
+		t.Error("account 1 depends on the number of users")
+	}
+
+	other := opts
+	other.Seed = 43
+	if reflect.DeepEqual(Generate(opts, 0), Generate(other, 0)) {
+		t.Error("seeds 42 and 43 generated the same account")
+	}
+}
+
+func TestGenerateRange(t *testing.T) {
+	until := time.Date(2024, time.March, 31, 18, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+	opts := Options{Seed: 7, Users: 5, Months: 1, Until: until}
+	from := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range opts.Users {
+		account := Generate(opts, i)
+		if account.User.Email != Email(i) {
+			t.Errorf("account %d email = %s, want %s", i, account.User.Email, Email(i))
+		}
+		if len(account.Activities) == 0 {
+			t.Errorf("account %d has no activities", i)
+		}
+		for _, a := range account.Activities {
+			if a.DoneAt.Before(from) || !a.DoneAt.Before(end) {
+				t.Errorf("account %d activity at %v, want within %v and %v", i, a.DoneAt, from, end)
+			}
+			if a.DurationInMinutes%5 != 0 || a.CaloriesBurned <= 0 {
+				t.Errorf("account %d activity %+v", i, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/achievements"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+// AchievementService awards the badges defined in achievements.Rules and
+// tracks streaks of active days. Days are counted in the user's time zone
+type AchievementService struct {
+	achievements repository.AchievementRepository
+	activities   repository.ActivityRepository
+	users        repository.UserRepository
+}
+
+// NewAchievementService creates a new achievement service
+func NewAchievementService(achievementRepo repository.AchievementRepository, activities repository.ActivityRepository, users repository.UserRepository) *AchievementService {
+	return &AchievementService{achievements: achievementRepo, activities: activities, users: users}
+}
+
+// Evaluate replays all of the user's activities against every rule and
+// stores the badges they earn. Badges whose activities were deleted are taken
+// back
+func (s *AchievementService) Evaluate(ctx context.Context, userID uint) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	activities, err := s.activities.List(ctx, repository.ActivityFilter{UserID: userID})
+	if err != nil {
+		return err
+	}
+	return s.sync(ctx, userID, achievements.Earned(achievements.Rules, activities, user.Location()))
+}
+
+// EvaluateSince brings the user's badges up to date after activities done at
+// or after since were logged, changed or deleted. Badges earned before then
+// stand, and the others are found by replaying only the activities from
+// since on, starting from the totals of the earlier ones
+func (s *AchievementService) EvaluateSince(ctx context.Context, userID uint, since time.Time) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	stored, err := s.achievements.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	earnedAt := make(map[string]time.Time)
+	for _, a := range stored {
+		if a.EarnedAt.Before(since) {
+			earnedAt[a.Code] = a.EarnedAt
+		}
+	}
+	var rules []achievements.Rule
+	for _, rule := range achievements.Rules {
+		if _, ok := earnedAt[rule.Code]; !ok {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	loc := user.Location()
+	from, err := s.progress(ctx, userID, rules, since, loc)
+	if err != nil {
+		return err
+	}
+	activities, err := s.activities.List(ctx, repository.ActivityFilter{UserID: userID, DoneAtFrom: &since})
+	if err != nil {
+		return err
+	}
+	for code, at := range achievements.EarnedFrom(rules, from, activities, loc) {
+		if at.IsZero() {
+			// Met before since without being stored, as happens when a rule
+			// is added, so when it was earned takes a full replay
+			return s.Evaluate(ctx, userID)
+		}
+		earnedAt[code] = at
+	}
+	return s.sync(ctx, userID, earnedAt)
+}
+
+// progress returns the user's progress towards rules from the activities
+// done before since
+func (s *AchievementService) progress(ctx context.Context, userID uint, rules []achievements.Rule, since time.Time, loc *time.Location) (achievements.Progress, error) {
+	var from achievements.Progress
+	totals, err := s.activities.TotalsByType(ctx, repository.ActivityFilter{UserID: userID, DoneAtBefore: &since})
+	if err != nil {
+		return from, err
+	}
+	for _, t := range totals {
+		from.Totals = append(from.Totals, achievements.Totals{
+			ActivityType: t.ActivityType,
+			Workouts:     float64(t.Count),
+			Calories:     float64(t.CaloriesBurned),
+			Minutes:      float64(t.Minutes),
+			Distance:     t.LongestDistance,
+		})
+	}
+
+	if days := achievements.StreakDays(rules); days > 0 {
+		local := since.In(loc)
+		start := time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, loc)
+		from.Recent, err = s.activities.List(ctx, repository.ActivityFilter{UserID: userID, DoneAtFrom: &start, DoneAtBefore: &since})
+	}
+	return from, err
+}
+
+// sync stores the badges in earnedAt, in the order of achievements.Rules
+func (s *AchievementService) sync(ctx context.Context, userID uint, earnedAt map[string]time.Time) error {
+	earned := make([]models.Achievement, 0, len(earnedAt))
+	for _, rule := range achievements.Rules {
+		if at, ok := earnedAt[rule.Code]; ok {
+			earned = append(earned, models.Achievement{UserID: userID, Code: rule.Code, EarnedAt: at})
+		}
+	}
+	return s.achievements.Sync(ctx, userID, earned)
+}
+
+// Summary returns the user's streaks at now and the badges they have earned
+func (s *AchievementService) Summary(ctx context.Context, user *models.User, now time.Time) (*models.AchievementSummary, error) {
+	stored, err := s.achievements.List(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	activities, err := s.activities.List(ctx, repository.ActivityFilter{UserID: user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	loc := user.Location()
+	summary := &models.AchievementSummary{Achievements: []models.AchievementResponse{}}
+	summary.CurrentStreak, summary.LongestStreak = achievements.Streaks(activities, loc, now)
+	for _, a := range stored {
+		if badge, ok := achievements.Describe(a); ok {
+			badge.EarnedAt = badge.EarnedAt.In(loc)
+			summary.Achievements = append(summary.Achievements, badge)
+		}
+	}
+	return summary, nil
+}
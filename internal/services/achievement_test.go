@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// countingActivities counts the activities listed from the repository
+type countingActivities struct {
+	repository.ActivityRepository
+	listed int
+}
+
+func (r *countingActivities) List(ctx context.Context, filter repository.ActivityFilter) ([]models.Activity, error) {
+	activities, err := r.ActivityRepository.List(ctx, filter)
+	r.listed += len(activities)
+	return activities, err
+}
+
+// storedBadges returns when each of the user's stored badges was earned
+func storedBadges(t *testing.T, service *AchievementService, userID uint) map[string]time.Time {
+	t.Helper()
+	stored, err := service.achievements.List(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badges := make(map[string]time.Time, len(stored))
+	for _, a := range stored {
+		badges[a.Code] = a.EarnedAt
+	}
+	return badges
+}
+
+func TestEvaluateSinceMatchesFullReplay(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	service := newTestActivityService(db)
+	user := newTestUser(t, db, "Asia/Jakarta")
+	day := time.Date(2024, time.May, 1, 7, 0, 0, 0, time.UTC)
+
+	var logged []*models.Activity
+	create := func(days int, activityType string, minutes int, distance float64) {
+		req := models.CreateActivityRequest{ActivityType: activityType, DoneAt: day.AddDate(0, 0, days), DurationInMinutes: minutes}
+		if distance > 0 {
+			req.DistanceInMeters = &distance
+		}
+		activity, err := service.Create(ctx, user, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logged = append(logged, activity)
+	}
+	replace := func(i, days int, activityType string, minutes int, distance float64) {
+		req := models.ReplaceActivityRequest{ActivityType: activityType, DoneAt: day.AddDate(0, 0, days), DurationInMinutes: minutes}
+		if distance > 0 {
+			req.DistanceInMeters = &distance
+		}
+		activity, err := service.Replace(ctx, user, logged[i], req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logged[i] = activity
+	}
+
+	tests := []struct {
+		name string
+		op   func()
+	}{
+		{"first activity", func() { create(0, models.ActivityWalking, 30, 0) }},
+		{"streak", func() {
+			create(1, models.ActivityRunning, 40, 6000)
+			create(2, models.ActivityCycling, 120, 30000)
+		}},
+		{"backdated", func() { create(-1, models.ActivityRunning, 60, 10500) }},
+		{"distance shortened", func() { replace(3, -1, models.ActivityRunning, 60, 4000) }},
+		{"moved earlier", func() { replace(2, -5, models.ActivityCycling, 120, 30000) }},
+		{"streak broken", func() {
+			if err := service.Delete(ctx, logged[1]); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"many workouts", func() {
+			for i := 0; i < 10; i++ {
+				create(3+i, models.ActivityHIIT, 45, 0)
+			}
+		}},
+		{"oldest deleted", func() {
+			if err := service.Delete(ctx, logged[2]); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.op()
+			got := storedBadges(t, service.achievements, user.ID)
+			if err := service.achievements.Evaluate(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+			want := storedBadges(t, service.achievements, user.ID)
+			if len(got) != len(want) {
+				t.Fatalf("badges = %v, a full replay gives %v", got, want)
+			}
+			for code, at := range want {
+				if !got[code].Equal(at) {
+					t.Errorf("%s earned at %v, a full replay gives %v", code, got[code], at)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateSinceLoadsRecentActivities(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	activities := &countingActivities{ActivityRepository: repository.NewActivityRepository(db)}
+	achievements := NewAchievementService(repository.NewAchievementRepository(db), activities, repository.NewUserRepository(db))
+	service := NewActivityService(activities, achievements, audit.NewLog(repository.NewAuditRepository(db)), zerolog.Nop())
+	user := newTestUser(t, db, "")
+
+	// 150 days in a row earn every streak badge
+	day := time.Date(2024, time.January, 1, 7, 0, 0, 0, time.UTC)
+	history := make([]models.Activity, 150)
+	for i := range history {
+		history[i] = models.Activity{UserID: user.ID, ActivityType: models.ActivityWalking, DoneAt: day.AddDate(0, 0, i), DurationInMinutes: 30, CaloriesBurned: 120}
+	}
+	if err := activities.CreateBatch(ctx, history); err != nil {
+		t.Fatal(err)
+	}
+	if err := achievements.Evaluate(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	activities.listed = 0
+	if _, err := service.Create(ctx, user, models.CreateActivityRequest{
+		ActivityType: models.ActivityWalking, DoneAt: day.AddDate(0, 0, 150), DurationInMinutes: 30,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if activities.listed != 1 {
+		t.Errorf("evaluating a new activity listed %d activities, want only it", activities.listed)
+	}
+}
+
+// brokenAchievements is an AchievementRepository that cannot store badges
+type brokenAchievements struct {
+	repository.AchievementRepository
+}
+
+func (brokenAchievements) Sync(context.Context, uint, []models.Achievement) error {
+	return errors.New("database unavailable")
+}
+
+func TestActivityWritesSurviveEvaluationFailure(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	var logs bytes.Buffer
+	activities, users := repository.NewActivityRepository(db), repository.NewUserRepository(db)
+	achievements := NewAchievementService(brokenAchievements{repository.NewAchievementRepository(db)}, activities, users)
+	service := NewActivityService(activities, achievements, audit.NewLog(repository.NewAuditRepository(db)), zerolog.New(&logs))
+	user := newTestUser(t, db, "")
+	doneAt := time.Date(2024, time.July, 1, 7, 0, 0, 0, time.UTC)
+
+	// Failing a stored write would make the client retry and log it twice
+	activity, err := service.Create(ctx, user, models.CreateActivityRequest{ActivityType: models.ActivityRunning, DoneAt: doneAt, DurationInMinutes: 30})
+	if err != nil {
+		t.Fatalf("Create error = %v, want the stored activity", err)
+	}
+	activity, err = service.Replace(ctx, user, activity, models.ReplaceActivityRequest{ActivityType: models.ActivityRunning, DoneAt: doneAt, DurationInMinutes: 40})
+	if err != nil {
+		t.Fatalf("Replace error = %v, want the stored activity", err)
+	}
+	if err := service.Delete(ctx, activity); err != nil {
+		t.Fatalf("Delete error = %v, want the activity deleted", err)
+	}
+	if _, err := service.Get(ctx, user.ID, activity.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+
+	if n := strings.Count(logs.String(), "Evaluating achievements failed"); n != 3 {
+		t.Errorf("logged %d evaluation failures, want 3:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"fitbyte/internal/activityfile"
+	"fitbyte/internal/audit"
+	"fitbyte/internal/geo"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrInvalidTrack is returned for GPS tracks that pass validation but cannot
+// be stored
+var ErrInvalidTrack = errors.New("invalid track")
+
+// ActivityService handles logging and querying activities. Achievements are
+// evaluated again whenever an activity is logged, changed or deleted
+type ActivityService struct {
+	activities   repository.ActivityRepository
+	achievements *AchievementService
+	audit        audit.Recorder
+	logger       zerolog.Logger
+}
+
+// NewActivityService creates a new activity service
+func NewActivityService(activities repository.ActivityRepository, achievements *AchievementService, recorder audit.Recorder, logger zerolog.Logger) *ActivityService {
+	return &ActivityService{activities: activities, achievements: achievements, audit: recorder, logger: logger}
+}
+
+// duplicateWindow is how close to the start of an imported workout an
+// activity must have been logged for the workout to count as a duplicate
+const duplicateWindow = 2 * time.Minute
+
+// profileSamples is about how many samples a route's elevation profile has
+const profileSamples = 200
+
+// CaloriesBurned returns the calories burned for an activity type and duration
+func CaloriesBurned(activityType string, durationInMinutes int) int {
+	return models.CaloriesPerMinute[activityType] * durationInMinutes
+}
+
+// List returns the user's activities matching query
+func (s *ActivityService) List(ctx context.Context, userID uint, query models.ActivityQuery) ([]models.Activity, error) {
+	return s.activities.List(ctx, repository.ActivityFilter{
+		UserID:            userID,
+		ActivityType:      query.ActivityType,
+		DoneAtFrom:        query.DoneAtFrom,
+		DoneAtTo:          query.DoneAtTo,
+		CaloriesBurnedMin: query.CaloriesBurnedMin,
+		CaloriesBurnedMax: query.CaloriesBurnedMax,
+		Offset:            query.Offset,
+		Limit:             query.Limit,
+	})
+}
+
+// Create logs an activity for user. Calories are estimated the way Replace
+// and Import do, from the user's weight when it is known
+func (s *ActivityService) Create(ctx context.Context, user *models.User, req models.CreateActivityRequest) (*models.Activity, error) {
+	activity := &models.Activity{
+		UserID:                user.ID,
+		ActivityType:          req.ActivityType,
+		DoneAt:                req.DoneAt,
+		DurationInMinutes:     req.DurationInMinutes,
+		CaloriesBurned:        estimateCalories(user, req.ActivityType, req.DurationInMinutes),
+		DistanceInMeters:      req.DistanceInMeters,
+		ElevationGainInMeters: req.ElevationGainInMeters,
+		AverageHeartRate:      req.AverageHeartRate,
+		MaxHeartRate:          req.MaxHeartRate,
+		AveragePower:          req.AveragePower,
+		MaxPower:              req.MaxPower,
+	}
+
+	var points []geo.Point
+	if len(req.Track) > 0 {
+		if len(req.Track) < 2 {
+			return nil, fmt.Errorf("%w: a track needs at least two points", ErrInvalidTrack)
+		}
+		var elevations []float64
+		for _, p := range req.Track {
+			point := geo.Point{Lat: p.Lat, Lon: p.Lon, Elevation: p.Elevation}
+			if p.Time != nil {
+				point.Time = *p.Time
+			}
+			if p.Elevation != nil {
+				elevations = append(elevations, *p.Elevation)
+			}
+			points = append(points, point)
+		}
+		if distance := geo.Distance(points); activity.DistanceInMeters == nil && distance > 0 {
+			activity.DistanceInMeters = &distance
+		}
+		if gain := geo.ElevationGain(elevations); activity.ElevationGainInMeters == nil && len(elevations) > 0 {
+			activity.ElevationGainInMeters = &gain
+		}
+	}
+	if err := s.create(ctx, activity, points); err != nil {
+		return nil, err
+	}
+	s.evaluate(ctx, user.ID, activity.DoneAt)
+	return activity, nil
+}
+
+// Import logs the workouts in a GPX, TCX or FIT file for user. Workouts starting
+// within a couple of minutes of an activity already logged are skipped, so
+// a file can be imported again, or in another format, without duplicates.
+// Calories are estimated from the user's weight when it is known, and GPS
+// tracks are kept for the route endpoint
+func (s *ActivityService) Import(ctx context.Context, user *models.User, data []byte) (*models.ActivityImport, error) {
+	workouts, err := activityfile.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ActivityImport{Imported: []models.ActivityResponse{}, Duplicates: []models.ActivityResponse{}}
+	var earliest time.Time
+	for _, w := range workouts {
+		from, to := w.Start.Add(-duplicateWindow), w.Start.Add(duplicateWindow)
+		existing, err := s.activities.List(ctx, repository.ActivityFilter{UserID: user.ID, DoneAtFrom: &from, DoneAtTo: &to, Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 {
+			result.Duplicates = append(result.Duplicates, existing[0].ToResponse())
+			continue
+		}
+
+		activityType := activityfile.InferType(w)
+		minutes := max(1, int(math.Round(w.Duration.Minutes())))
+		activity := &models.Activity{
+			UserID:                user.ID,
+			ActivityType:          activityType,
+			DoneAt:                w.Start,
+			DurationInMinutes:     minutes,
+			CaloriesBurned:        estimateCalories(user, activityType, minutes),
+			ElevationGainInMeters: w.ElevationGain,
+			AverageHeartRate:      w.AverageHeartRate,
+			MaxHeartRate:          w.MaxHeartRate,
+			AveragePower:          w.AveragePower,
+			MaxPower:              w.MaxPower,
+		}
+		if w.Distance > 0 {
+			distance := w.Distance
+			activity.DistanceInMeters = &distance
+		}
+		if err := s.create(ctx, activity, w.Points); err != nil {
+			return nil, err
+		}
+		result.Imported = append(result.Imported, activity.ToResponse())
+		if earliest.IsZero() || activity.DoneAt.Before(earliest) {
+			earliest = activity.DoneAt
+		}
+	}
+
+	if len(result.Imported) > 0 {
+		s.evaluate(ctx, user.ID, earliest)
+	}
+	return result, nil
+}
+
+// evaluate brings the user's badges up to date after activities done at or
+// after since were written. The write is already stored, so a failure is
+// logged rather than returned: an error would make clients retry a write
+// that succeeded
+func (s *ActivityService) evaluate(ctx context.Context, userID uint, since time.Time) {
+	if err := s.achievements.EvaluateSince(ctx, userID, since); err != nil {
+		s.logger.Error().Err(err).Uint("user_id", userID).Time("since", since).Msg("Evaluating achievements failed")
+	}
+}
+
+// create stores activity, with a track through points when there are
+// enough of them to make one
+func (s *ActivityService) create(ctx context.Context, activity *models.Activity, points []geo.Point) error {
+	if len(points) < 2 {
+		return s.activities.Create(ctx, activity)
+	}
+	track := models.NewActivityTrack(points)
+	return s.activities.CreateWithTrack(ctx, activity, &track)
+}
+
+// estimateCalories estimates the calories burned from the MET value of the
+// activity type and the user's weight, falling back to the flat rate per
+// minute when the weight is unknown
+func estimateCalories(user *models.User, activityType string, minutes int) int {
+	weight, ok := weightKilograms(user)
+	if !ok {
+		return CaloriesBurned(activityType, minutes)
+	}
+	return int(math.Round(models.METs[activityType] * weight * float64(minutes) / 60))
+}
+
+// Get returns one of the user's activities
+func (s *ActivityService) Get(ctx context.Context, userID, id uint) (*models.Activity, error) {
+	return s.activities.GetByID(ctx, userID, id)
+}
+
+// Route returns the GPS track of one of the user's activities as a GeoJSON
+// feature, with its splits in the user's preferred distance unit and its
+// elevation profile
+func (s *ActivityService) Route(ctx context.Context, user *models.User, id uint) (*models.ActivityRoute, error) {
+	track, err := s.activities.GetTrack(ctx, user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	points, err := track.Points()
+	if err != nil {
+		return nil, err
+	}
+
+	unit := units.DistanceUnit(user.Preference)
+	properties := models.RouteProperties{
+		ActivityID:       id,
+		DistanceInMeters: units.Round(geo.Distance(points)),
+		SplitUnit:        unit,
+		Splits:           []models.RouteSplit{},
+		ElevationProfile: []models.ElevationSample{},
+	}
+	for i, split := range geo.Splits(points, units.ToMeters(1, unit)) {
+		distance := units.FromMeters(split.Distance, unit)
+		seconds := split.Duration.Seconds()
+		properties.Splits = append(properties.Splits, models.RouteSplit{
+			Split:                   i + 1,
+			Distance:                units.Round(distance),
+			DurationInSeconds:       int(math.Round(seconds)),
+			PaceInSeconds:           int(math.Round(seconds / distance)),
+			ElevationChangeInMeters: roundedValue(split.ElevationChange),
+		})
+	}
+	for _, p := range geo.ElevationProfile(points, profileSamples) {
+		properties.ElevationProfile = append(properties.ElevationProfile, models.ElevationSample{
+			DistanceInMeters:  units.Round(p.Distance),
+			ElevationInMeters: units.Round(p.Elevation),
+		})
+	}
+	if len(properties.ElevationProfile) > 0 {
+		var elevations []float64
+		for _, p := range points {
+			if p.Elevation != nil {
+				elevations = append(elevations, *p.Elevation)
+			}
+		}
+		gain := units.Round(geo.ElevationGain(elevations))
+		properties.ElevationGainInMeters = &gain
+	}
+
+	return &models.ActivityRoute{Type: "Feature", Geometry: models.NewRouteGeometry(points), Properties: properties}, nil
+}
+
+// roundedValue rounds v for display, keeping nil
+func roundedValue(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	r := units.Round(*v)
+	return &r
+}
+
+// Replace overwrites activity, which belongs to user, with req. Calories
+// are estimated again only when the type or duration changes. It fails with
+// repository.ErrVersionConflict if the activity changed since it was read
+func (s *ActivityService) Replace(ctx context.Context, user *models.User, activity *models.Activity, req models.ReplaceActivityRequest) (*models.Activity, error) {
+	if req.ActivityType != activity.ActivityType || req.DurationInMinutes != activity.DurationInMinutes {
+		activity.CaloriesBurned = estimateCalories(user, req.ActivityType, req.DurationInMinutes)
+	}
+	since := activity.DoneAt
+	if req.DoneAt.Before(since) {
+		since = req.DoneAt
+	}
+	activity.ActivityType = req.ActivityType
+	activity.DoneAt = req.DoneAt
+	activity.DurationInMinutes = req.DurationInMinutes
+	activity.DistanceInMeters = req.DistanceInMeters
+	activity.ElevationGainInMeters = req.ElevationGainInMeters
+	activity.AverageHeartRate = req.AverageHeartRate
+	activity.MaxHeartRate = req.MaxHeartRate
+	activity.AveragePower = req.AveragePower
+	activity.MaxPower = req.MaxPower
+
+	if err := s.activities.Update(ctx, activity); err != nil {
+		return nil, err
+	}
+	s.evaluate(ctx, activity.UserID, since)
+	return activity, nil
+}
+
+// Delete removes activity, which belongs to a user, if it is still at its
+// version
+func (s *ActivityService) Delete(ctx context.Context, activity *models.Activity) error {
+	if err := s.activities.Delete(ctx, activity.UserID, activity.ID, activity.Version); err != nil {
+		return err
+	}
+	s.evaluate(ctx, activity.UserID, activity.DoneAt)
+	return s.audit.Record(ctx, audit.Event{
+		Action:  audit.ActionActivityDeleted,
+		UserID:  activity.UserID,
+		Details: map[string]string{"activityId": strconv.FormatUint(uint64(activity.ID), 10)},
+	})
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+func TestActivityReplaceCalories(t *testing.T) {
+	db := newTestDB(t)
+	service := newTestActivityService(db)
+	doneAt := time.Date(2024, time.July, 1, 7, 0, 0, 0, time.UTC)
+	distance := 5000.0
+
+	tests := []struct {
+		name   string
+		weight *float64
+		req    models.ReplaceActivityRequest
+		want   int
+	}{
+		{"unchanged", value(70), models.ReplaceActivityRequest{ActivityType: models.ActivityRunning, DoneAt: doneAt.Add(time.Hour), DurationInMinutes: 30, DistanceInMeters: &distance}, 123},
+		// 9.8 MET for 70 kg over 40 minutes
+		{"duration changed", value(70), models.ReplaceActivityRequest{ActivityType: models.ActivityRunning, DoneAt: doneAt, DurationInMinutes: 40}, 457},
+		// 7.5 MET for 70 kg over 30 minutes
+		{"type changed", value(70), models.ReplaceActivityRequest{ActivityType: models.ActivityCycling, DoneAt: doneAt, DurationInMinutes: 30}, 263},
+		// 4 kcal a minute without a weight
+		{"weight unknown", nil, models.ReplaceActivityRequest{ActivityType: models.ActivityWalking, DoneAt: doneAt, DurationInMinutes: 30}, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, db, "")
+			user.Weight = tt.weight
+			// Stored with a made up value, so that a recomputation shows
+			activity := &models.Activity{UserID: user.ID, ActivityType: models.ActivityRunning, DoneAt: doneAt, DurationInMinutes: 30, CaloriesBurned: 123}
+			if err := service.activities.Create(context.Background(), activity); err != nil {
+				t.Fatal(err)
+			}
+
+			replaced, err := service.Replace(context.Background(), user, activity, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replaced.CaloriesBurned != tt.want {
+				t.Errorf("CaloriesBurned = %d, want %d", replaced.CaloriesBurned, tt.want)
+			}
+			stored, err := service.Get(context.Background(), user.ID, activity.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.CaloriesBurned != tt.want || stored.Version != 2 {
+				t.Errorf("stored %d calories at version %d, want %d at version 2", stored.CaloriesBurned, stored.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivityCreateThenReplaceCalories(t *testing.T) {
+	db := newTestDB(t)
+	service := newTestActivityService(db)
+	doneAt := time.Date(2024, time.July, 1, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		weight       *float64
+		activityType string
+		minutes      int
+		newMinutes   int
+	}{
+		{"with weight", value(70), models.ActivityRunning, 30, 31},
+		{"heavier", value(95), models.ActivityCycling, 60, 45},
+		{"weight unknown", nil, models.ActivityWalking, 30, 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, db, "")
+			user.Weight = tt.weight
+
+			created, err := service.Create(context.Background(), user, models.CreateActivityRequest{
+				ActivityType: tt.activityType, DoneAt: doneAt, DurationInMinutes: tt.minutes,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := estimateCalories(user, tt.activityType, tt.minutes); created.CaloriesBurned != want {
+				t.Errorf("created with %d calories, want %d", created.CaloriesBurned, want)
+			}
+			before := created.CaloriesBurned
+
+			// Only the duration changes, so the calories scale with it
+			replaced, err := service.Replace(context.Background(), user, created, models.ReplaceActivityRequest{
+				ActivityType: tt.activityType, DoneAt: doneAt, DurationInMinutes: tt.newMinutes,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := float64(before) * float64(tt.newMinutes) / float64(tt.minutes)
+			if diff := float64(replaced.CaloriesBurned) - want; diff < -1 || diff > 1 {
+				t.Errorf("%d calories for %d minutes became %d for %d, want about %.0f", before, tt.minutes, replaced.CaloriesBurned, tt.newMinutes, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+var (
+	// ErrInvalidCredentials is returned when an email and password do not match
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrAccountDisabled is returned when a disabled user tries to log in
+	ErrAccountDisabled = errors.New("account is disabled")
+	// ErrWrongPassword is returned when a signed in user fails to confirm
+	// their password
+	ErrWrongPassword = errors.New("password is incorrect")
+)
+
+// dummyHash is compared against when the email is unknown, so that missing
+// accounts take as long to reject as wrong passwords
+const dummyHash = "$2a$10$h3eNkC5v.25mns3f/VlA8.OClX3HRAzeeXwfeYqx/ryzWwp3PTcn2"
+
+// AuthService handles registration and login
+type AuthService struct {
+	users  repository.UserRepository
+	tokens *auth.TokenManager
+	guard  *lockout.Guard
+	audit  audit.Recorder
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(users repository.UserRepository, tokens *auth.TokenManager, guard *lockout.Guard, recorder audit.Recorder) *AuthService {
+	return &AuthService{users: users, tokens: tokens, guard: guard, audit: recorder, sleep: sleep}
+}
+
+// Register creates an account and returns a token for it
+func (s *AuthService) Register(ctx context.Context, req models.RegisterRequest) (*models.AuthResponse, error) {
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        normalizeEmail(req.Email),
+		PasswordHash: hash,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.issue(user)
+}
+
+// Login verifies credentials and returns a token. Failed attempts are
+// tracked per email and client IP; once either is locked out a
+// *lockout.LockedError is returned without checking the password. Every
+// attempt is written to the audit log
+func (s *AuthService) Login(ctx context.Context, req models.LoginRequest, clientIP string) (*models.AuthResponse, error) {
+	res, user, err := s.login(ctx, normalizeEmail(req.Email), req.Password, clientIP)
+	event := audit.Event{Action: audit.ActionLoginSucceeded}
+	if user != nil {
+		event.UserID, event.ActorID = user.ID, user.ID
+	}
+	if err != nil {
+		var locked *lockout.LockedError
+		switch {
+		case errors.As(err, &locked):
+			event.Details = map[string]string{"reason": "locked"}
+		case errors.Is(err, ErrInvalidCredentials):
+			event.Details = map[string]string{"reason": "invalid_credentials"}
+		case errors.Is(err, ErrAccountDisabled):
+			event.Details = map[string]string{"reason": "disabled"}
+		default:
+			return nil, err
+		}
+		event.Action, event.ActorID = audit.ActionLoginFailed, 0
+		event.Details["email"] = normalizeEmail(req.Email)
+	}
+
+	if auditErr := s.audit.Record(ctx, event); auditErr != nil {
+		return nil, auditErr
+	}
+	return res, err
+}
+
+// login checks credentials and returns the user they belong to, if any
+func (s *AuthService) login(ctx context.Context, email, password, clientIP string) (*models.AuthResponse, *models.User, error) {
+	if err := s.guard.Check(ctx, email, clientIP); err != nil {
+		return nil, nil, err
+	}
+
+	delay, err := s.guard.Delay(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.sleep(ctx, delay); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	hash := dummyHash
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, password) || user == nil {
+		if err := s.guard.RecordFailure(ctx, email, clientIP); err != nil {
+			return nil, user, err
+		}
+		return nil, user, ErrInvalidCredentials
+	}
+
+	if err := s.guard.RecordSuccess(ctx, email); err != nil {
+		return nil, user, err
+	}
+	if user.DisabledAt != nil {
+		return nil, user, ErrAccountDisabled
+	}
+	res, err := s.issue(user)
+	return res, user, err
+}
+
+// Authenticate verifies a bearer token and returns the user it was issued
+// for. Tokens stop working as soon as their user is deleted or disabled
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	claims, err := s.tokens.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, auth.ErrRevokedToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Token times have second precision. A token issued before the user was
+	// created belongs to an earlier account with the same ID
+	if user.DisabledAt != nil || claims.IssuedAt.Before(user.CreatedAt.Truncate(time.Second)) {
+		return nil, auth.ErrRevokedToken
+	}
+	return user, nil
+}
+
+// ConfirmPassword checks the password of a signed in user before a
+// sensitive change. Failures count towards the same lockout as logins
+func (s *AuthService) ConfirmPassword(ctx context.Context, user *models.User, password, clientIP string) error {
+	if err := s.guard.Check(ctx, user.Email, clientIP); err != nil {
+		return err
+	}
+	if !auth.CheckPassword(user.PasswordHash, password) {
+		if err := s.guard.RecordFailure(ctx, user.Email, clientIP); err != nil {
+			return err
+		}
+		return ErrWrongPassword
+	}
+	return s.guard.RecordSuccess(ctx, user.Email)
+}
+
+func (s *AuthService) issue(user *models.User) (*models.AuthResponse, error) {
+	token, expiresAt, err := s.tokens.Generate(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthResponse{
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
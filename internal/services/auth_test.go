@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := repository.NewUserRepository(db)
+	entries := repository.NewAuditRepository(db)
+
+	hash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "runner@example.com", PasswordHash: hash}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), lockout.Policy{
+		MaxEmailFailures: 4,
+		MaxIPFailures:    100,
+		Window:           time.Hour,
+		LockoutDuration:  time.Hour,
+		BaseDelay:        time.Second,
+		MaxDelay:         2 * time.Second,
+	})
+	s := NewAuthService(users, auth.NewTokenManager("secret", time.Hour), guard, audit.NewLog(entries))
+	var delays []time.Duration
+	s.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	login := func(password string) error {
+		_, err := s.Login(ctx, models.LoginRequest{Email: "Runner@example.com", Password: password}, "10.0.0.1")
+		return err
+	}
+
+	// A success clears the failures before it
+	if err := login("wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() = %v, want ErrInvalidCredentials", err)
+	}
+	if err := login("correct horse"); err != nil {
+		t.Fatalf("Login() = %v", err)
+	}
+
+	for i := 1; i < 4; i++ {
+		if err := login("wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d: Login() = %v, want ErrInvalidCredentials", i, err)
+		}
+	}
+	var locked *lockout.LockedError
+	if err := login("wrong"); !errors.As(err, &locked) {
+		t.Fatalf("fourth failure: Login() = %v, want *lockout.LockedError", err)
+	}
+	// Once locked even the right password is refused, without a delay
+	if err := login("correct horse"); !errors.As(err, &locked) {
+		t.Fatalf("Login() while locked = %v, want *lockout.LockedError", err)
+	}
+
+	want := []time.Duration{0, time.Second, 0, time.Second, 2 * time.Second, 2 * time.Second}
+	if len(delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("delays = %v, want %v", delays, want)
+			break
+		}
+	}
+
+	logged, total, err := entries.List(ctx, repository.AuditFilter{Action: audit.ActionLoginFailed, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 6 {
+		t.Fatalf("logged %d failed logins, want 6", total)
+	}
+	reasons := map[string]int{}
+	for _, e := range logged {
+		reasons[e.Details["reason"]]++
+		if e.Details["email"] != "runner@example.com" || e.ActorID != nil {
+			t.Errorf("entry %+v, want the normalized email and no actor", e)
+		}
+	}
+	if reasons["invalid_credentials"] != 4 || reasons["locked"] != 2 {
+		t.Errorf("reasons = %v, want 4 invalid_credentials and 2 locked", reasons)
+	}
+}
+
+func TestLoginSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleep() = %v, want context.Canceled", err)
+	}
+	if err := sleep(ctx, 0); err != nil {
+		t.Errorf("sleep(0) = %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/export"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/storage"
+)
+
+// ErrExportNotReady is returned when downloading an export that has not completed
+var ErrExportNotReady = errors.New("export is not ready")
+
+// exportBatchSize is how many pending exports ProcessPending loads at a time
+const exportBatchSize = 10
+
+// ExportConfig configures where archives are written and how long they live
+type ExportConfig struct {
+	// Dir holds the archives, which are not served publicly
+	Dir string
+	// TTL is how long an archive is kept after it is built
+	TTL time.Duration
+	// LinkTTL is how long a download link stays valid
+	LinkTTL time.Duration
+	// PublicURL is the base URL download links point to
+	PublicURL string
+}
+
+// ExportService builds archives of everything stored about a user. Requests
+// are queued and built in the background by ProcessPending
+type ExportService struct {
+	exports      repository.ExportRepository
+	users        repository.UserRepository
+	activities   repository.ActivityRepository
+	goals        repository.GoalRepository
+	measurements repository.MeasurementRepository
+	achievements repository.AchievementRepository
+	files        storage.Storage
+	signer       *auth.URLSigner
+	audit        audit.Recorder
+	cfg          ExportConfig
+	pending      chan struct{}
+}
+
+// NewExportService creates a new export service
+func NewExportService(exports repository.ExportRepository, users repository.UserRepository, activities repository.ActivityRepository,
+	goals repository.GoalRepository, measurements repository.MeasurementRepository, achievements repository.AchievementRepository, files storage.Storage, signer *auth.URLSigner, recorder audit.Recorder, cfg ExportConfig) (*ExportService, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create export directory: %w", err)
+	}
+	return &ExportService{
+		exports:      exports,
+		users:        users,
+		activities:   activities,
+		goals:        goals,
+		measurements: measurements,
+		achievements: achievements,
+		files:        files,
+		signer:       signer,
+		audit:        recorder,
+		cfg:          cfg,
+		pending:      make(chan struct{}, 1),
+	}, nil
+}
+
+// Request queues an export for the user. If one is already queued or
+// running, that export is returned instead
+func (s *ExportService) Request(ctx context.Context, userID uint) (*models.Export, error) {
+	active, err := s.exports.GetActive(ctx, userID)
+	if err == nil {
+		return active, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	e := &models.Export{UserID: userID, Status: models.ExportPending}
+	if err := s.exports.Create(ctx, e); err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(ctx, s.event(audit.ActionExportRequested, e)); err != nil {
+		return nil, err
+	}
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+	return e, nil
+}
+
+// Get returns one of the user's exports
+func (s *ExportService) Get(ctx context.Context, userID, id uint) (*models.Export, error) {
+	e, err := s.exports.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return e, nil
+}
+
+// Response converts an export into its API representation, with a signed
+// download link once the archive is built
+func (s *ExportService) Response(e *models.Export) models.ExportResponse {
+	res := e.ToResponse()
+	if e.Status != models.ExportCompleted || e.ExpiresAt == nil {
+		return res
+	}
+
+	expires := time.Now().Add(s.cfg.LinkTTL)
+	if e.ExpiresAt.Before(expires) {
+		expires = *e.ExpiresAt
+	}
+	link := strings.TrimSuffix(s.cfg.PublicURL, "/") + s.signer.Sign(DownloadPath(e.ID), expires)
+	res.DownloadURL = &link
+	res.DownloadExpiresAt = &expires
+	return res
+}
+
+// DownloadPath is the path of the download endpoint of an export
+func DownloadPath(id uint) string {
+	return "/api/v1/user/export/" + strconv.FormatUint(uint64(id), 10) + "/download"
+}
+
+// Open verifies a signed download link and returns the path of the archive
+func (s *ExportService) Open(ctx context.Context, id uint, query url.Values) (string, error) {
+	if err := s.signer.Verify(DownloadPath(id), query); err != nil {
+		return "", err
+	}
+
+	e, err := s.exports.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if e.Status != models.ExportCompleted {
+		return "", ErrExportNotReady
+	}
+	// The link must stop working when the account is deleted
+	if _, err := s.users.GetByID(ctx, e.UserID); err != nil {
+		return "", err
+	}
+
+	path := s.path(id)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return "", repository.ErrNotFound
+	}
+	if err := s.audit.Record(ctx, s.event(audit.ActionExportDownload, e)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// event describes an action on an export for the audit log
+func (s *ExportService) event(action string, e *models.Export) audit.Event {
+	return audit.Event{
+		Action:  action,
+		UserID:  e.UserID,
+		Details: map[string]string{"exportId": strconv.FormatUint(uint64(e.ID), 10)},
+	}
+}
+
+// Pending receives a value whenever an export is requested
+func (s *ExportService) Pending() <-chan struct{} {
+	return s.pending
+}
+
+// Recover requeues exports left running by a worker that stopped. It must
+// only be called while no worker is running
+func (s *ExportService) Recover(ctx context.Context) error {
+	return s.exports.ResetRunning(ctx)
+}
+
+// ProcessPending builds every pending export and returns how many it built.
+// Exports that cannot be built are marked as failed, and their errors are
+// returned together once every export was processed
+func (s *ExportService) ProcessPending(ctx context.Context) (int, error) {
+	built := 0
+	var failures []error
+	for {
+		exports, err := s.exports.ListPending(ctx, exportBatchSize)
+		if err != nil {
+			return built, errors.Join(append(failures, err)...)
+		}
+		for i := range exports {
+			e := &exports[i]
+			claimed, err := s.exports.Claim(ctx, e.ID)
+			if err != nil {
+				return built, errors.Join(append(failures, err)...)
+			}
+			if !claimed {
+				continue
+			}
+
+			buildErr := s.build(ctx, e)
+			if buildErr != nil {
+				failures = append(failures, fmt.Errorf("export %d: %w", e.ID, buildErr))
+			}
+			if err := s.finish(ctx, e, buildErr); err != nil {
+				return built, errors.Join(append(failures, err)...)
+			}
+			if buildErr == nil {
+				built++
+			}
+		}
+		if len(exports) < exportBatchSize {
+			return built, errors.Join(failures...)
+		}
+	}
+}
+
+// DeleteExpired removes expired exports and their archives and returns how
+// many it removed
+func (s *ExportService) DeleteExpired(ctx context.Context) (int, error) {
+	exports, err := s.exports.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return s.remove(ctx, exports)
+}
+
+// DeleteForUser removes all exports of a user and their archives
+func (s *ExportService) DeleteForUser(ctx context.Context, userID uint) error {
+	exports, err := s.exports.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.remove(ctx, exports)
+	return err
+}
+
+// remove deletes exports and their archives and returns how many it removed
+func (s *ExportService) remove(ctx context.Context, exports []models.Export) (int, error) {
+	for i, e := range exports {
+		if err := os.Remove(s.path(e.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return i, err
+		}
+		if err := s.exports.Delete(ctx, e.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(exports), nil
+}
+
+// build writes the archive of an export
+func (s *ExportService) build(ctx context.Context, e *models.Export) error {
+	user, err := s.users.GetByID(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	activities, err := s.activities.List(ctx, repository.ActivityFilter{UserID: e.UserID})
+	if err != nil {
+		return err
+	}
+	goals, err := s.goals.List(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	measurements, err := s.measurements.List(ctx, repository.MeasurementFilter{UserID: e.UserID})
+	if err != nil {
+		return err
+	}
+	achievements, err := s.achievements.List(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	tracks, err := s.activities.ListTracks(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	files, err := s.files.List(ctx, storage.UserPrefix(e.UserID))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.cfg.Dir, ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	data := export.Data{User: *user, Activities: activities, Goals: goals, Measurements: measurements, Achievements: achievements, Tracks: tracks, Files: files}
+	if err := export.WriteZip(ctx, f, data, s.files); err != nil {
+		f.Close()
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	e.Size = info.Size()
+	return os.Rename(f.Name(), s.path(e.ID))
+}
+
+// finish records the outcome of building an export
+func (s *ExportService) finish(ctx context.Context, e *models.Export, buildErr error) error {
+	now := time.Now()
+	expires := now.Add(s.cfg.TTL)
+	e.Status = models.ExportCompleted
+	e.CompletedAt = &now
+	e.ExpiresAt = &expires
+	if buildErr != nil {
+		// The cause is returned by ProcessPending; users get a generic message
+		msg := "The export could not be created; please request a new one"
+		e.Status = models.ExportFailed
+		e.Error = &msg
+		e.Size = 0
+	}
+	return s.exports.Update(ctx, e)
+}
+
+func (s *ExportService) path(id uint) string {
+	return filepath.Join(s.cfg.Dir, strconv.FormatUint(uint64(id), 10)+".zip")
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fitbyte/internal/auth"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/storage"
+)
+
+// newExportUser stores a user with email
+func newExportUser(t *testing.T, s *ExportService, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email, PasswordHash: "x", Role: models.RoleUser}
+	if err := s.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newTestExport requests an export for user
+func newTestExport(t *testing.T, s *ExportService, user *models.User) *models.Export {
+	t.Helper()
+	e, err := s.Request(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestExportProcessPending(t *testing.T) {
+	ctx := context.Background()
+	s := newTestExportService(t, newTestDB(t))
+
+	done := newExportUser(t, s, "done@example.com")
+	if _, err := s.files.Save(ctx, storage.UserPrefix(done.ID)+"/avatar.png", strings.NewReader("png")); err != nil {
+		t.Fatal(err)
+	}
+	gone, claimed := newExportUser(t, s, "gone@example.com"), newExportUser(t, s, "claimed@example.com")
+	doneExport, goneExport, claimedExport := newTestExport(t, s, done), newTestExport(t, s, gone), newTestExport(t, s, claimed)
+
+	// Another worker is building one export, and one user is deleted before
+	// theirs is built
+	if ok, err := s.exports.Claim(ctx, claimedExport.ID); err != nil || !ok {
+		t.Fatalf("Claim = %v, %v", ok, err)
+	}
+	if err := s.users.Delete(ctx, gone.ID, gone.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	built, err := s.ProcessPending(ctx)
+	if built != 1 {
+		t.Errorf("built %d exports, want 1", built)
+	}
+	if err == nil || !strings.Contains(err.Error(), "export "+strconv.FormatUint(uint64(goneExport.ID), 10)) {
+		t.Errorf("ProcessPending error = %v, want the failed export", err)
+	}
+
+	status := func(id uint) *models.Export {
+		t.Helper()
+		e, err := s.exports.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	if e := status(doneExport.ID); e.Status != models.ExportCompleted || e.Size == 0 || e.ExpiresAt == nil {
+		t.Errorf("built export = %+v, want it completed with a size and expiry", e)
+	}
+	if _, err := os.Stat(s.path(doneExport.ID)); err != nil {
+		t.Errorf("archive missing: %v", err)
+	}
+	if e := status(goneExport.ID); e.Status != models.ExportFailed || e.Error == nil || e.Size != 0 {
+		t.Errorf("failed export = %+v, want it failed with a message", e)
+	}
+	if e := status(claimedExport.ID); e.Status != models.ExportRunning {
+		t.Errorf("claimed export status = %s, want it left to its worker", e.Status)
+	}
+
+	// The worker stopped, so the export is built once it is requeued
+	if err := s.Recover(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if built, err := s.ProcessPending(ctx); built != 1 || err != nil {
+		t.Errorf("ProcessPending after Recover = %d, %v, want 1 built", built, err)
+	}
+	if e := status(claimedExport.ID); e.Status != models.ExportCompleted {
+		t.Errorf("requeued export status = %s, want completed", e.Status)
+	}
+
+	// Expired exports are removed with their archives
+	expired := status(doneExport.ID)
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+	if err := s.exports.Update(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := s.DeleteExpired(ctx); removed != 1 || err != nil {
+		t.Errorf("DeleteExpired = %d, %v, want 1 removed", removed, err)
+	}
+	if _, err := s.exports.GetByID(ctx, doneExport.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expired export lookup error = %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(s.path(doneExport.ID)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expired archive stat error = %v, want it removed", err)
+	}
+}
+
+func TestExportOpen(t *testing.T) {
+	ctx := context.Background()
+	s := newTestExportService(t, newTestDB(t))
+
+	owner, deleted := newExportUser(t, s, "owner@example.com"), newExportUser(t, s, "deleted@example.com")
+	completed, orphaned := newTestExport(t, s, owner), newTestExport(t, s, deleted)
+	if _, err := s.ProcessPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.Delete(ctx, deleted.ID, deleted.Version); err != nil {
+		t.Fatal(err)
+	}
+	pending := newTestExport(t, s, owner)
+	// Requesting again while one is pending returns that one
+	if again := newTestExport(t, s, owner); again.ID != pending.ID {
+		t.Errorf("second request created export %d, want %d", again.ID, pending.ID)
+	}
+
+	// link returns the query of the download link of export id
+	link := func(id uint) url.Values {
+		e, err := s.exports.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := s.Response(e)
+		if res.DownloadURL == nil {
+			return signed(s, id, time.Now().Add(time.Hour))
+		}
+		u, err := url.Parse(*res.DownloadURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query()
+	}
+	tampered := link(completed.ID)
+	tampered.Set("expires", strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10))
+
+	tests := []struct {
+		name  string
+		id    uint
+		query url.Values
+		want  error
+	}{
+		{"completed", completed.ID, link(completed.ID), nil},
+		{"link of another export", pending.ID, link(completed.ID), auth.ErrInvalidSignature},
+		{"tampered", completed.ID, tampered, auth.ErrInvalidSignature},
+		{"expired", completed.ID, signed(s, completed.ID, time.Now().Add(-time.Minute)), auth.ErrLinkExpired},
+		{"not completed", pending.ID, link(pending.ID), ErrExportNotReady},
+		{"account deleted", orphaned.ID, link(orphaned.ID), repository.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := s.Open(ctx, tt.id, tt.query)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Open error = %v, want %v", err, tt.want)
+			}
+			if err == nil && path != s.path(tt.id) {
+				t.Errorf("Open = %s, want %s", path, s.path(tt.id))
+			}
+		})
+	}
+}
+
+// signed returns the query of a download link of export id expiring at
+// expires
+func signed(s *ExportService, id uint, expires time.Time) url.Values {
+	u, _ := url.Parse(s.signer.Sign(DownloadPath(id), expires))
+	return u.Query()
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+)
+
+var (
+	// ErrInvalidGoal is returned for goals that are well formed but make no
+	// sense, such as a weight goal without a deadline
+	ErrInvalidGoal = errors.New("invalid goal")
+	// ErrWeightUnknown is returned when a weight goal is set for a user
+	// whose weight is not recorded
+	ErrWeightUnknown = errors.New("set your weight before setting a weight goal")
+)
+
+// GoalService handles fitness goals and progress towards them. Weights are
+// stored in kilograms and converted to the unit of the user's preference
+type GoalService struct {
+	goals        repository.GoalRepository
+	activities   repository.ActivityRepository
+	measurements repository.MeasurementRepository
+}
+
+// NewGoalService creates a new goal service
+func NewGoalService(goals repository.GoalRepository, activities repository.ActivityRepository, measurements repository.MeasurementRepository) *GoalService {
+	return &GoalService{goals: goals, activities: activities, measurements: measurements}
+}
+
+// List returns the user's goals
+func (s *GoalService) List(ctx context.Context, userID uint) ([]models.Goal, error) {
+	return s.goals.List(ctx, userID)
+}
+
+// Get returns one of the user's goals
+func (s *GoalService) Get(ctx context.Context, userID, id uint) (*models.Goal, error) {
+	return s.goals.GetByID(ctx, userID, id)
+}
+
+// Create sets a goal for user
+func (s *GoalService) Create(ctx context.Context, user *models.User, req models.CreateGoalRequest) (*models.Goal, error) {
+	goal := &models.Goal{UserID: user.ID, StartsAt: time.Now().UTC()}
+	if err := s.apply(user, goal, models.ReplaceGoalRequest(req)); err != nil {
+		return nil, err
+	}
+	if err := s.goals.Create(ctx, goal); err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+// Replace overwrites every editable field of goal with req. It fails with
+// repository.ErrVersionConflict if the goal changed since it was read
+func (s *GoalService) Replace(ctx context.Context, user *models.User, goal *models.Goal, req models.ReplaceGoalRequest) (*models.Goal, error) {
+	if err := s.apply(user, goal, req); err != nil {
+		return nil, err
+	}
+	if err := s.goals.Update(ctx, goal); err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+// Delete deletes one of the user's goals if it is still at version
+func (s *GoalService) Delete(ctx context.Context, userID, id, version uint) error {
+	return s.goals.Delete(ctx, userID, id, version)
+}
+
+// apply validates req and copies it onto goal, converting a weight target
+// from the user's unit to kilograms
+func (s *GoalService) apply(user *models.User, goal *models.Goal, req models.ReplaceGoalRequest) error {
+	switch req.Type {
+	case models.GoalTargetWeight:
+		if req.Deadline == nil {
+			return fmt.Errorf("%w: a target_weight goal needs a deadline", ErrInvalidGoal)
+		}
+	case models.GoalActivityCount:
+	default:
+		if req.ActivityType != nil {
+			return fmt.Errorf("%w: only activity_count goals can be limited to an activity type", ErrInvalidGoal)
+		}
+	}
+	if req.StartsAt != nil && req.Deadline != nil && !req.Deadline.After(*req.StartsAt) {
+		return fmt.Errorf("%w: deadline must be after startsAt", ErrInvalidGoal)
+	}
+
+	target := req.Target
+	if req.Type == models.GoalTargetWeight {
+		target = units.ToKilograms(target, units.WeightUnit(user.Preference))
+		// The starting point is kept while the goal stays a weight goal
+		if goal.Type != models.GoalTargetWeight || goal.StartWeight == nil {
+			weight, ok := weightKilograms(user)
+			if !ok {
+				return ErrWeightUnknown
+			}
+			goal.StartWeight = &weight
+		}
+	} else {
+		goal.StartWeight = nil
+	}
+
+	goal.Type = req.Type
+	goal.Target = target
+	goal.ActivityType = req.ActivityType
+	goal.Deadline = nil
+	if req.Deadline != nil {
+		deadline := req.Deadline.UTC()
+		goal.Deadline = &deadline
+	}
+	if req.StartsAt != nil {
+		goal.StartsAt = req.StartsAt.UTC()
+	}
+	return nil
+}
+
+// Progress works out how far user is towards goal at now. Weight goals
+// compare the user's latest measured weight, or the profile weight when
+// nothing is measured, with the weight the goal started from;
+// weekly goals sum the activities of the current week, starting on Monday
+// in the user's time zone; activity count goals count activities since the
+// goal started
+func (s *GoalService) Progress(ctx context.Context, user *models.User, goal *models.Goal, now time.Time) (*models.GoalProgress, error) {
+	weightUnit := units.WeightUnit(user.Preference)
+	progress := &models.GoalProgress{
+		GoalID: goal.ID,
+		Type:   goal.Type,
+		Unit:   models.GoalUnit(goal.Type, weightUnit),
+		Target: goal.DisplayTarget(weightUnit),
+	}
+
+	if goal.Type == models.GoalTargetWeight {
+		current, err := s.currentWeight(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		// The fraction is the same in any unit, so work in kilograms
+		start, target := *goal.StartWeight, goal.Target
+		if math.Abs(start-target) < 0.05 {
+			progress.Percent = completion(math.Abs(current-target) < 0.05, 0)
+		} else {
+			progress.Percent = completion(false, (start-current)/(start-target))
+		}
+		displayStart := units.Round(units.FromKilograms(start, weightUnit))
+		progress.Start = &displayStart
+		progress.Current = units.Round(units.FromKilograms(current, weightUnit))
+		progress.Achieved = progress.Percent >= 100
+		return progress, nil
+	}
+
+	filter := repository.ActivityFilter{UserID: user.ID}
+	var from, until time.Time
+	if goal.Type == models.GoalActivityCount {
+		from = goal.StartsAt
+		if goal.ActivityType != nil {
+			filter.ActivityType = *goal.ActivityType
+		}
+		if goal.Deadline != nil {
+			until = *goal.Deadline
+		}
+	} else {
+		from = startOfPeriod(now.In(user.Location()), models.PeriodWeek)
+		until = from.AddDate(0, 0, 7)
+	}
+	// The bounds are compared with the stored times, which are in UTC
+	from, until = from.UTC(), until.UTC()
+	filter.DoneAtFrom = &from
+	progress.PeriodStart = &from
+	if !until.IsZero() {
+		filter.DoneAtBefore = &until
+		progress.PeriodEnd = &until
+	}
+
+	totals, err := s.activities.Totals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	switch goal.Type {
+	case models.GoalWeeklyCalories:
+		progress.Current = float64(totals.CaloriesBurned)
+	case models.GoalWeeklyMinutes:
+		progress.Current = float64(totals.Minutes)
+	default:
+		progress.Current = float64(totals.Count)
+	}
+	progress.Percent = completion(false, progress.Current/goal.Target)
+	progress.Achieved = progress.Current >= goal.Target
+	return progress, nil
+}
+
+// currentWeight returns the user's weight in kilograms from the latest
+// measurement, falling back to the profile weight
+func (s *GoalService) currentWeight(ctx context.Context, user *models.User) (float64, error) {
+	latest, err := s.measurements.LatestWeight(ctx, user.ID)
+	switch {
+	case err == nil:
+		return *latest.Weight, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return 0, err
+	}
+	weight, ok := weightKilograms(user)
+	if !ok {
+		return 0, ErrWeightUnknown
+	}
+	return weight, nil
+}
+
+// completion turns a fraction into a percentage between 0 and 100
+func completion(done bool, fraction float64) float64 {
+	if done {
+		return 100
+	}
+	return units.Round(math.Max(0, math.Min(1, fraction)) * 100)
+}
+
+// weightKilograms returns the user's recorded weight in kilograms. A weight
+// that is not positive counts as not recorded
+func weightKilograms(user *models.User) (float64, bool) {
+	if user.Weight == nil || *user.Weight <= 0 {
+		return 0, false
+	}
+	unit := units.Kilograms
+	if user.WeightUnit != nil {
+		unit = *user.WeightUnit
+	}
+	return units.ToKilograms(*user.Weight, unit), true
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+)
+
+// mustTime parses an RFC 3339 time
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func TestGoalProgressActivities(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "Asia/Tokyo")
+	activities := repository.NewActivityRepository(db)
+	for _, a := range []struct {
+		doneAt       string
+		activityType string
+		minutes      int
+	}{
+		{"2024-06-30T14:59:00Z", models.ActivityRunning, 10},      // Sunday June 30, 23:59
+		{"2024-06-30T10:01:00-05:00", models.ActivityRunning, 20}, // Monday July 1, 00:01
+		{"2024-07-07T14:59:00Z", models.ActivityYoga, 30},         // Sunday July 7, 23:59
+		{"2024-07-07T08:00:00-07:00", models.ActivityRunning, 40}, // Monday July 8, 00:00
+	} {
+		activity := &models.Activity{UserID: user.ID, ActivityType: a.activityType, DoneAt: mustTime(t, a.doneAt), DurationInMinutes: a.minutes}
+		if err := activities.Create(context.Background(), activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	running := models.ActivityRunning
+	deadline := mustTime(t, "2024-07-08T00:00:00+09:00")
+	tests := []struct {
+		name        string
+		goal        models.Goal
+		want        float64
+		wantPercent float64
+	}{
+		{"weekly minutes", models.Goal{Type: models.GoalWeeklyMinutes, Target: 100}, 50, 50},
+		{"weekly calories", models.Goal{Type: models.GoalWeeklyCalories, Target: 100}, 0, 0},
+		{"activity count", models.Goal{Type: models.GoalActivityCount, Target: 2, StartsAt: mustTime(t, "2024-07-01T00:00:00+09:00")}, 3, 100},
+		{"activity count by deadline", models.Goal{Type: models.GoalActivityCount, Target: 4, StartsAt: mustTime(t, "2024-06-30T10:00:00-05:00"), Deadline: &deadline}, 2, 50},
+		{"activity count of a type", models.Goal{Type: models.GoalActivityCount, Target: 4, ActivityType: &running, StartsAt: mustTime(t, "2024-06-01T00:00:00Z"), Deadline: &deadline}, 2, 50},
+	}
+	service := NewGoalService(repository.NewGoalRepository(db), activities, repository.NewMeasurementRepository(db))
+	// Wednesday July 3 in Tokyo, but still Tuesday in New York
+	now := mustTime(t, "2024-07-02T22:00:00-04:00")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, err := service.Progress(context.Background(), user, &tt.goal, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if progress.Current != tt.want || progress.Percent != tt.wantPercent {
+				t.Errorf("got %v (%v%%), want %v (%v%%)", progress.Current, progress.Percent, tt.want, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestGoalProgressWeekBounds(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "Asia/Tokyo")
+	service := NewGoalService(repository.NewGoalRepository(db), repository.NewActivityRepository(db), repository.NewMeasurementRepository(db))
+
+	goal := &models.Goal{Type: models.GoalWeeklyMinutes, Target: 60}
+	progress, err := service.Progress(context.Background(), user, goal, mustTime(t, "2024-07-02T22:00:00-04:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStart, wantEnd := mustTime(t, "2024-06-30T15:00:00Z"), mustTime(t, "2024-07-07T15:00:00Z")
+	if !progress.PeriodStart.Equal(wantStart) || !progress.PeriodEnd.Equal(wantEnd) {
+		t.Errorf("got period %v to %v, want %v to %v", progress.PeriodStart, progress.PeriodEnd, wantStart, wantEnd)
+	}
+}
+
+func TestGoalProgressWeight(t *testing.T) {
+	db := newTestDB(t)
+	measurements := repository.NewMeasurementRepository(db)
+	service := NewGoalService(repository.NewGoalRepository(db), repository.NewActivityRepository(db), measurements)
+
+	kg := func(v float64) *float64 { return &v }
+	goal := func() *models.Goal {
+		return &models.Goal{Type: models.GoalTargetWeight, Target: 80, StartWeight: kg(90)}
+	}
+
+	t.Run("latest measurement", func(t *testing.T) {
+		user := newTestUser(t, db, "")
+		user.Weight = kg(89)
+		for _, m := range []models.Measurement{
+			{UserID: user.ID, MeasuredAt: mustTime(t, "2024-07-02T08:00:00+02:00"), Weight: kg(85)},
+			{UserID: user.ID, MeasuredAt: mustTime(t, "2024-07-01T23:00:00-05:00"), Weight: kg(87)},
+			{UserID: user.ID, MeasuredAt: mustTime(t, "2024-07-03T08:00:00Z"), BodyFat: kg(20)},
+		} {
+			if err := measurements.Create(context.Background(), &m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		progress, err := service.Progress(context.Background(), user, goal(), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Current != 85 || progress.Percent != 50 || *progress.Start != 90 {
+			t.Errorf("got %v from %v (%v%%), want 85 from 90 (50%%)", progress.Current, *progress.Start, progress.Percent)
+		}
+	})
+
+	t.Run("profile weight", func(t *testing.T) {
+		user := newTestUser(t, db, "")
+		user.Weight = kg(80)
+		progress, err := service.Progress(context.Background(), user, goal(), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Current != 80 || !progress.Achieved {
+			t.Errorf("got %v, achieved %v, want 80 and achieved", progress.Current, progress.Achieved)
+		}
+	})
+
+	t.Run("pounds", func(t *testing.T) {
+		user := newTestUser(t, db, "")
+		preference, unit := units.Imperial, units.Pounds
+		user.Preference, user.WeightUnit, user.Weight = &preference, &unit, kg(187)
+		progress, err := service.Progress(context.Background(), user, goal(), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Unit != "lbs" || progress.Current != 187 {
+			t.Errorf("got %v %s, want 187 lbs", progress.Current, progress.Unit)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		user := newTestUser(t, db, "")
+		if _, err := service.Progress(context.Background(), user, goal(), time.Now()); !errors.Is(err, ErrWeightUnknown) {
+			t.Errorf("got error %v, want ErrWeightUnknown", err)
+		}
+	})
+}
+
+func TestGoalCreate(t *testing.T) {
+	db := newTestDB(t)
+	service := NewGoalService(repository.NewGoalRepository(db), repository.NewActivityRepository(db), repository.NewMeasurementRepository(db))
+	weight := 90.0
+	running := models.ActivityRunning
+	startsAt := mustTime(t, "2024-07-01T00:00:00+09:00")
+	deadline := mustTime(t, "2024-08-01T00:00:00+09:00")
+
+	tests := []struct {
+		name    string
+		weight  *float64
+		req     models.CreateGoalRequest
+		wantErr error
+	}{
+		{"weekly", nil, models.CreateGoalRequest{Type: models.GoalWeeklyCalories, Target: 2000}, nil},
+		{"count", nil, models.CreateGoalRequest{Type: models.GoalActivityCount, Target: 10, ActivityType: &running, StartsAt: &startsAt, Deadline: &deadline}, nil},
+		{"weight", &weight, models.CreateGoalRequest{Type: models.GoalTargetWeight, Target: 80, Deadline: &deadline}, nil},
+		{"weight without deadline", &weight, models.CreateGoalRequest{Type: models.GoalTargetWeight, Target: 80}, ErrInvalidGoal},
+		{"weight unknown", nil, models.CreateGoalRequest{Type: models.GoalTargetWeight, Target: 80, Deadline: &deadline}, ErrWeightUnknown},
+		{"deadline before start", nil, models.CreateGoalRequest{Type: models.GoalActivityCount, Target: 10, StartsAt: &deadline, Deadline: &startsAt}, ErrInvalidGoal},
+		{"activity type on weekly goal", nil, models.CreateGoalRequest{Type: models.GoalWeeklyMinutes, Target: 150, ActivityType: &running}, ErrInvalidGoal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, db, "")
+			user.Weight = tt.weight
+			goal, err := service.Create(context.Background(), user, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if goal.StartsAt.Location() != time.UTC || (goal.Deadline != nil && goal.Deadline.Location() != time.UTC) {
+				t.Errorf("got times %v and %v, want them in UTC", goal.StartsAt, goal.Deadline)
+			}
+			if tt.req.StartsAt != nil && !goal.StartsAt.Equal(*tt.req.StartsAt) {
+				t.Errorf("StartsAt = %v, want %v", goal.StartsAt, *tt.req.StartsAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+)
+
+// ErrInvalidMeasurement is returned for measurements that pass validation
+// but cannot be recorded, such as one without any values
+var ErrInvalidMeasurement = errors.New("invalid measurement")
+
+// weightSyncAttempts bounds the retries when the profile changes while its
+// weight is being updated
+const weightSyncAttempts = 3
+
+// MeasurementService records body measurements and keeps the profile
+// weight in line with the latest one
+type MeasurementService struct {
+	measurements repository.MeasurementRepository
+	users        repository.UserRepository
+}
+
+// NewMeasurementService creates a new measurement service
+func NewMeasurementService(measurements repository.MeasurementRepository, users repository.UserRepository) *MeasurementService {
+	return &MeasurementService{measurements: measurements, users: users}
+}
+
+// Create records a measurement for user, given in the units of the user's
+// preference. If it is the user's latest weight, the profile weight is
+// updated to match
+func (s *MeasurementService) Create(ctx context.Context, user *models.User, req models.CreateMeasurementRequest) (*models.Measurement, error) {
+	if req.Weight == nil && req.BodyFat == nil && req.Waist == nil && req.Hips == nil && req.Chest == nil {
+		return nil, fmt.Errorf("%w: at least one value is required", ErrInvalidMeasurement)
+	}
+	now := time.Now()
+	if req.MeasuredAt != nil && req.MeasuredAt.After(now) {
+		return nil, fmt.Errorf("%w: measuredAt cannot be in the future", ErrInvalidMeasurement)
+	}
+
+	weightUnit, lengthUnit := units.WeightUnit(user.Preference), units.LengthUnit(user.Preference)
+	toCentimeters := func(v float64) float64 { return units.ToCentimeters(v, lengthUnit) }
+	measurement := &models.Measurement{
+		UserID:     user.ID,
+		MeasuredAt: now,
+		Weight:     scale(req.Weight, func(v float64) float64 { return units.ToKilograms(v, weightUnit) }),
+		BodyFat:    req.BodyFat,
+		Waist:      scale(req.Waist, toCentimeters),
+		Hips:       scale(req.Hips, toCentimeters),
+		Chest:      scale(req.Chest, toCentimeters),
+	}
+	if req.MeasuredAt != nil {
+		measurement.MeasuredAt = *req.MeasuredAt
+	}
+	if err := s.measurements.Create(ctx, measurement); err != nil {
+		return nil, err
+	}
+
+	if measurement.Weight != nil {
+		latest, err := s.measurements.LatestWeight(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if latest.ID == measurement.ID {
+			if err := s.syncWeight(ctx, user, *measurement.Weight); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return measurement, nil
+}
+
+// List returns the user's measurements matching query, newest first, in
+// the units of the user's preference. Each carries the moving average of
+// the measurements taken in the query's window of days up to it
+func (s *MeasurementService) List(ctx context.Context, user *models.User, query models.MeasurementQuery) ([]models.MeasurementResponse, error) {
+	measurements, err := s.measurements.List(ctx, repository.MeasurementFilter{
+		UserID: user.ID,
+		From:   query.From,
+		To:     query.To,
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	})
+	if err != nil || len(measurements) == 0 {
+		return []models.MeasurementResponse{}, err
+	}
+
+	// The averages of the oldest measurements reach back past the page
+	window := time.Duration(query.Window) * 24 * time.Hour
+	oldest := measurements[len(measurements)-1].MeasuredAt.Add(-window)
+	history, err := s.measurements.List(ctx, repository.MeasurementFilter{
+		UserID: user.ID,
+		From:   &oldest,
+		To:     &measurements[0].MeasuredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]models.MeasurementResponse, len(measurements))
+	for i := range measurements {
+		data[i] = measurements[i].ToResponse(user.Preference)
+		average := movingAverage(history, measurements[i].MeasuredAt, window)
+		values := average.Values(user.Preference)
+		data[i].Average = &values
+	}
+	return data, nil
+}
+
+// syncWeight sets the profile weight of user to kg, in the user's weight
+// unit. Concurrent profile edits are retried against the fresh profile
+func (s *MeasurementService) syncWeight(ctx context.Context, user *models.User, kg float64) error {
+	for attempt := 1; ; attempt++ {
+		unit := units.Kilograms
+		if user.WeightUnit != nil {
+			unit = *user.WeightUnit
+		}
+		weight := units.Round(units.FromKilograms(kg, unit))
+		user.Weight = &weight
+
+		err := s.users.Update(ctx, user)
+		if !errors.Is(err, repository.ErrVersionConflict) || attempt == weightSyncAttempts {
+			return err
+		}
+		fresh, err := s.users.GetByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		*user = *fresh
+	}
+}
+
+// movingAverage averages each value over the measurements in history
+// taken in the window ending at end. history is ordered newest first
+func movingAverage(history []models.Measurement, end time.Time, window time.Duration) models.Measurement {
+	var sums, counts [5]float64
+	start := end.Add(-window)
+	for i := range history {
+		m := &history[i]
+		if m.MeasuredAt.After(end) {
+			continue
+		}
+		if !m.MeasuredAt.After(start) {
+			break
+		}
+		for j, v := range []*float64{m.Weight, m.BodyFat, m.Waist, m.Hips, m.Chest} {
+			if v != nil {
+				sums[j] += *v
+				counts[j]++
+			}
+		}
+	}
+
+	var average [5]*float64
+	for j := range sums {
+		if counts[j] > 0 {
+			v := sums[j] / counts[j]
+			average[j] = &v
+		}
+	}
+	return models.Measurement{
+		Weight:  average[0],
+		BodyFat: average[1],
+		Waist:   average[2],
+		Hips:    average[3],
+		Chest:   average[4],
+	}
+}
+
+// scale applies fn to an optional value
+func scale(v *float64, fn func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	out := fn(*v)
+	return &out
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+)
+
+func value(v float64) *float64 {
+	return &v
+}
+
+func TestMovingAverage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.July, d, 8, 0, 0, 0, time.UTC) }
+	// Newest first, as the repository lists them
+	history := []models.Measurement{
+		{MeasuredAt: day(10), Weight: value(80), Waist: value(90)},
+		{MeasuredAt: day(9), Weight: value(81)},
+		{MeasuredAt: day(7), BodyFat: value(20)},
+		{MeasuredAt: day(3), Weight: value(85), Waist: value(94)},
+		{MeasuredAt: day(1), Weight: value(90)},
+	}
+
+	tests := []struct {
+		name        string
+		end         time.Time
+		window      time.Duration
+		wantWeight  *float64
+		wantBodyFat *float64
+		wantWaist   *float64
+	}{
+		{"whole window", day(10), 8 * 24 * time.Hour, value(82), value(20), value(92)},
+		{"window excludes its start", day(10), 7 * 24 * time.Hour, value(80.5), value(20), value(90)},
+		{"later measurements ignored", day(9), 2 * 24 * time.Hour, value(81), nil, nil},
+		{"single day", day(3), 24 * time.Hour, value(85), nil, value(94)},
+		{"nothing in window", day(6), 24 * time.Hour, nil, nil, nil},
+		{"everything", day(10), 30 * 24 * time.Hour, value(84), value(20), value(92)},
+	}
+	equal := func(a, b *float64) bool {
+		return (a == nil) == (b == nil) && (a == nil || *a == *b)
+	}
+	show := func(v *float64) any {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := movingAverage(history, tt.end, tt.window)
+			if !equal(got.Weight, tt.wantWeight) || !equal(got.BodyFat, tt.wantBodyFat) || !equal(got.Waist, tt.wantWaist) {
+				t.Errorf("got weight %v, body fat %v, waist %v", show(got.Weight), show(got.BodyFat), show(got.Waist))
+			}
+			if got.Hips != nil || got.Chest != nil {
+				t.Errorf("got hips %v and chest %v, want nil", got.Hips, got.Chest)
+			}
+		})
+	}
+}
+
+func TestMeasurementCreateSyncsWeight(t *testing.T) {
+	db := newTestDB(t)
+	users := repository.NewUserRepository(db)
+	service := NewMeasurementService(repository.NewMeasurementRepository(db), users)
+	user := newTestUser(t, db, "")
+	preference, unit := units.Imperial, units.Pounds
+	user.Preference, user.WeightUnit = &preference, &unit
+	if err := users.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each measurement is sent in pounds with its own offset. The second is
+	// the later one, although its local time sorts first as text
+	steps := []struct {
+		measuredAt string
+		weight     float64
+		want       float64
+	}{
+		{"2024-07-02T01:00:00+02:00", 180, 180},
+		{"2024-07-01T23:30:00-05:00", 176, 176},
+		// Earlier than the latest, so the profile keeps 176
+		{"2024-07-02T03:00:00+03:00", 170, 176},
+		{"2024-07-03T09:00:00+09:00", 174, 174},
+	}
+	for _, step := range steps {
+		measuredAt := mustTime(t, step.measuredAt)
+		req := models.CreateMeasurementRequest{MeasuredAt: &measuredAt, Weight: value(step.weight)}
+		if _, err := service.Create(context.Background(), user, req); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := users.GetByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Weight == nil {
+			t.Fatalf("after %s the profile has no weight", step.measuredAt)
+		}
+		if *stored.Weight != step.want {
+			t.Errorf("after %s the profile weight is %v, want %v", step.measuredAt, *stored.Weight, step.want)
+		}
+	}
+
+	// A stale copy of the profile is refreshed and the weight still synced
+	stale := *user
+	stale.Version--
+	measuredAt := mustTime(t, "2024-07-04T09:00:00Z")
+	if _, err := service.Create(context.Background(), &stale, models.CreateMeasurementRequest{MeasuredAt: &measuredAt, Weight: value(172)}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := users.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stored.Weight != 172 || *stored.WeightUnit != units.Pounds {
+		t.Errorf("profile weight is %v %s, want 172 lbs", *stored.Weight, *stored.WeightUnit)
+	}
+}
+
+func TestMeasurementCreateInvalid(t *testing.T) {
+	db := newTestDB(t)
+	service := NewMeasurementService(repository.NewMeasurementRepository(db), repository.NewUserRepository(db))
+	user := newTestUser(t, db, "")
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		req  models.CreateMeasurementRequest
+	}{
+		{"no values", models.CreateMeasurementRequest{}},
+		{"in the future", models.CreateMeasurementRequest{MeasuredAt: &future, Weight: value(80)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Create(context.Background(), user, tt.req); !errors.Is(err, ErrInvalidMeasurement) {
+				t.Errorf("got error %v, want ErrInvalidMeasurement", err)
+			}
+		})
+	}
+}
+
+func TestMeasurementList(t *testing.T) {
+	db := newTestDB(t)
+	service := NewMeasurementService(repository.NewMeasurementRepository(db), repository.NewUserRepository(db))
+	user := newTestUser(t, db, "")
+	for _, m := range []struct {
+		measuredAt string
+		weight     float64
+	}{
+		{"2024-07-01T08:00:00Z", 84},
+		{"2024-07-02T01:00:00+02:00", 82}, // July 1, 23:00
+		{"2024-07-01T20:00:00-05:00", 80}, // July 2, 01:00
+		{"2024-07-03T08:00:00Z", 78},
+	} {
+		measuredAt := mustTime(t, m.measuredAt)
+		if _, err := service.Create(context.Background(), user, models.CreateMeasurementRequest{MeasuredAt: &measuredAt, Weight: value(m.weight)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from := mustTime(t, "2024-07-02T02:00:00+02:00") // July 2, 00:00
+	tests := []struct {
+		name         string
+		query        models.MeasurementQuery
+		wantWeights  []float64
+		wantAverages []float64
+	}{
+		{"all", models.MeasurementQuery{Window: 1}, []float64{78, 80, 82, 84}, []float64{78, 82, 83, 84}},
+		{"from", models.MeasurementQuery{From: &from, Window: 2}, []float64{78, 80}, []float64{80, 82}},
+		// The averages reach back past the page
+		{"page", models.MeasurementQuery{Offset: 1, Limit: 2, Window: 7}, []float64{80, 82}, []float64{82, 83}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := service.List(context.Background(), user, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != len(tt.wantWeights) {
+				t.Fatalf("got %d measurements, want %d", len(list), len(tt.wantWeights))
+			}
+			for i, m := range list {
+				if *m.Weight != tt.wantWeights[i] || *m.Average.Weight != tt.wantAverages[i] {
+					t.Errorf("measurement %d: got %v averaging %v, want %v averaging %v", i, *m.Weight, *m.Average.Weight, tt.wantWeights[i], tt.wantAverages[i])
+				}
+			}
+		})
+	}
+}
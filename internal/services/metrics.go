@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"fitbyte/internal/health"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/units"
+)
+
+// metricsDays is how many days of logged activities the metrics average over
+const metricsDays = 28
+
+// MetricsService derives health metrics from a user's profile and logged
+// activities
+type MetricsService struct {
+	activities repository.ActivityRepository
+}
+
+// NewMetricsService creates a new metrics service
+func NewMetricsService(activities repository.ActivityRepository) *MetricsService {
+	return &MetricsService{activities: activities}
+}
+
+// Metrics returns the health metrics of user at now. BMI needs the weight
+// and height; BMR and TDEE also need the birth date and sex. The activity
+// level is taken from query, or inferred from the last four weeks of
+// activities
+func (s *MetricsService) Metrics(ctx context.Context, user *models.User, query models.MetricsQuery, now time.Time) (*models.HealthMetrics, error) {
+	from := now.AddDate(0, 0, -metricsDays)
+	totals, err := s.activities.Totals(ctx, repository.ActivityFilter{UserID: user.ID, DoneAtFrom: &from, DoneAtTo: &now})
+	if err != nil {
+		return nil, err
+	}
+	weeklyMinutes := float64(totals.Minutes) * 7 / metricsDays
+
+	metrics := &models.HealthMetrics{
+		ActivityLevel:       query.ActivityLevel,
+		WeeklyActiveMinutes: units.Round(weeklyMinutes),
+		Missing:             []string{},
+	}
+	if metrics.ActivityLevel == "" {
+		metrics.ActivityLevel = health.ActivityLevel(weeklyMinutes)
+	}
+
+	weight, hasWeight := weightKilograms(user)
+	height, hasHeight := heightCentimeters(user)
+	if !hasWeight {
+		metrics.Missing = append(metrics.Missing, "weight")
+	}
+	if !hasHeight {
+		metrics.Missing = append(metrics.Missing, "height")
+	}
+	if user.BirthDate == nil {
+		metrics.Missing = append(metrics.Missing, "birthDate")
+	}
+	if user.Sex == nil {
+		metrics.Missing = append(metrics.Missing, "sex")
+	}
+
+	if user.BirthDate != nil {
+		if birthDate, err := time.Parse(time.DateOnly, *user.BirthDate); err == nil {
+			age := health.Age(birthDate, now)
+			metrics.Age = &age
+		}
+	}
+	if !hasWeight || !hasHeight {
+		return metrics, nil
+	}
+
+	bmi := health.BMI(weight, height)
+	category := health.BMICategory(bmi)
+	bmi = units.Round(bmi)
+	metrics.BMI, metrics.BMICategory = &bmi, &category
+	if metrics.Age == nil || user.Sex == nil {
+		return metrics, nil
+	}
+
+	mifflin, err := health.MifflinStJeor(weight, height, *metrics.Age, *user.Sex)
+	if err != nil {
+		return nil, err
+	}
+	harris, err := health.HarrisBenedict(weight, height, *metrics.Age, *user.Sex)
+	if err != nil {
+		return nil, err
+	}
+	tdee := units.Round(health.TDEE(mifflin, metrics.ActivityLevel))
+	logged := units.Round(health.TDEEFromActivities(mifflin, float64(totals.CaloriesBurned), metricsDays))
+	metrics.BMR = &models.BMR{MifflinStJeor: units.Round(mifflin), HarrisBenedict: units.Round(harris)}
+	metrics.TDEE, metrics.TDEEFromActivities = &tdee, &logged
+	return metrics, nil
+}
+
+// heightCentimeters returns the user's recorded height in centimeters. A
+// height that is not positive counts as not recorded
+func heightCentimeters(user *models.User) (float64, bool) {
+	if user.Height == nil || *user.Height <= 0 {
+		return 0, false
+	}
+	unit := units.Centimeters
+	if user.HeightUnit != nil {
+		unit = *user.HeightUnit
+	}
+	return units.ToCentimeters(*user.Height, unit), true
+}
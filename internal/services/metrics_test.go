@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+func TestMetricsNonPositiveMeasurements(t *testing.T) {
+	db := newTestDB(t)
+	service := NewMetricsService(repository.NewActivityRepository(db))
+	now := time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+	birthDate, sex := "1990-01-01", "female"
+
+	tests := []struct {
+		name        string
+		weight      *float64
+		height      *float64
+		wantMissing []string
+	}{
+		{"complete", value(60), value(165), []string{}},
+		{"zero height", value(60), value(0), []string{"height"}},
+		{"negative weight", value(-60), value(165), []string{"weight"}},
+		{"both invalid", value(0), value(-1), []string{"weight", "height"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser(t, db, "")
+			user.Weight, user.Height, user.BirthDate, user.Sex = tt.weight, tt.height, &birthDate, &sex
+
+			metrics, err := service.Metrics(context.Background(), user, models.MetricsQuery{}, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(metrics.Missing, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", metrics.Missing, tt.wantMissing)
+			}
+			complete := len(tt.wantMissing) == 0
+			if (metrics.BMI != nil) != complete || (metrics.BMR != nil) != complete || (metrics.TDEE != nil) != complete {
+				t.Errorf("BMI %v, BMR %v, TDEE %v computed, want them only with a valid weight and height", metrics.BMI, metrics.BMR, metrics.TDEE)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/database"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/storage"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// newTestDB returns a migrated SQLite database of the test's own, so that
+// times are stored as text the way they are in production SQLite
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open("sqlite://" + filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestUser stores a user in timeZone, or UTC when it is empty
+func newTestUser(t *testing.T, db *gorm.DB, timeZone string) *models.User {
+	t.Helper()
+	user := &models.User{Email: t.Name() + "@example.com", PasswordHash: "x", Role: models.RoleUser}
+	if timeZone != "" {
+		user.TimeZone = &timeZone
+	}
+	if err := repository.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newTestActivityService returns an activity service evaluating
+// achievements and writing its audit log to db
+func newTestActivityService(db *gorm.DB) *ActivityService {
+	activities, users := repository.NewActivityRepository(db), repository.NewUserRepository(db)
+	achievements := NewAchievementService(repository.NewAchievementRepository(db), activities, users)
+	return NewActivityService(activities, achievements, audit.NewLog(repository.NewAuditRepository(db)), zerolog.Nop())
+}
+
+// newTestExportService returns an export service keeping files and archives
+// in directories of the test's own
+func newTestExportService(t *testing.T, db *gorm.DB) *ExportService {
+	t.Helper()
+	files, err := storage.NewLocalStorage(filepath.Join(t.TempDir(), "uploads"), "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exports, err := NewExportService(repository.NewExportRepository(db), repository.NewUserRepository(db), repository.NewActivityRepository(db),
+		repository.NewGoalRepository(db), repository.NewMeasurementRepository(db), repository.NewAchievementRepository(db),
+		files, auth.NewURLSigner("secret"), audit.NewLog(repository.NewAuditRepository(db)), ExportConfig{
+			Dir:       filepath.Join(t.TempDir(), "exports"),
+			TTL:       time.Hour,
+			LinkTTL:   time.Hour,
+			PublicURL: "http://localhost",
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exports
+}
+
+// newTestUserService returns a user service keeping files and exports in
+// directories of the test's own, with users restorable for restorePeriod
+func newTestUserService(t *testing.T, db *gorm.DB, restorePeriod time.Duration) *UserService {
+	t.Helper()
+	exports := newTestExportService(t, db)
+	return NewUserService(exports.users, exports.files, exports, exports.audit, restorePeriod)
+}
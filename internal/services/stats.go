@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+var (
+	// ErrInvalidRange is returned for a summary whose range is reversed or
+	// spans too many periods
+	ErrInvalidRange = errors.New("invalid range")
+	// ErrInvalidTimeZone is returned for a time zone that is not in the IANA
+	// time zone database
+	ErrInvalidTimeZone = errors.New("unknown time zone")
+)
+
+// maxBuckets bounds how many periods one summary covers
+const maxBuckets = 366
+
+// defaultBuckets is how many periods a summary covers when from is omitted
+var defaultBuckets = map[string]int{
+	models.PeriodDay:   30,
+	models.PeriodWeek:  12,
+	models.PeriodMonth: 12,
+}
+
+// StatsService summarises logged activities
+type StatsService struct {
+	activities repository.ActivityRepository
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(activities repository.ActivityRepository) *StatsService {
+	return &StatsService{activities: activities}
+}
+
+// Summary returns the user's activity totals per day, week or month of
+// query, in the query's time zone or else the user's. The totals are summed
+// up by the database; periods without activities are filled in with zeros
+func (s *StatsService) Summary(ctx context.Context, user *models.User, query models.StatsQuery, now time.Time) (*models.StatsSummary, error) {
+	var err error
+	loc := user.Location()
+	if query.TimeZone != "" {
+		if loc, err = time.LoadLocation(query.TimeZone); err != nil || query.TimeZone == "Local" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, query.TimeZone)
+		}
+	}
+
+	to := startOfDay(now.In(loc))
+	if query.To != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, query.To, loc); err != nil {
+			return nil, fmt.Errorf("%w: to must be a date", ErrInvalidRange)
+		}
+	}
+	last := startOfPeriod(to, query.Period)
+	first := addPeriods(last, query.Period, 1-defaultBuckets[query.Period])
+	if query.From != "" {
+		from, err := time.ParseInLocation(time.DateOnly, query.From, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from must be a date", ErrInvalidRange)
+		}
+		if from.After(to) {
+			return nil, fmt.Errorf("%w: from is after to", ErrInvalidRange)
+		}
+		first = startOfPeriod(from, query.Period)
+	}
+
+	bounds := []time.Time{first}
+	for start := first; !start.After(last); {
+		if len(bounds) > maxBuckets {
+			return nil, fmt.Errorf("%w: more than %d periods", ErrInvalidRange, maxBuckets)
+		}
+		start = addPeriods(start, query.Period, 1)
+		bounds = append(bounds, start)
+	}
+
+	totals, err := s.activities.TotalsByBucket(ctx, user.ID, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.StatsSummary{
+		Period:   query.Period,
+		TimeZone: loc.String(),
+		From:     first.Format(time.DateOnly),
+		To:       bounds[len(bounds)-1].AddDate(0, 0, -1).Format(time.DateOnly),
+		Buckets:  make([]models.StatsBucket, len(bounds)-1),
+	}
+	for i := range summary.Buckets {
+		summary.Buckets[i] = models.StatsBucket{
+			Start:         bounds[i].Format(time.DateOnly),
+			ActivityTypes: []models.ActivityTypeTotals{},
+		}
+	}
+	for _, t := range totals {
+		if t.Bucket < 0 || t.Bucket >= len(summary.Buckets) {
+			continue
+		}
+		b := &summary.Buckets[t.Bucket]
+		b.Count += t.Count
+		b.Minutes += t.Minutes
+		b.CaloriesBurned += t.CaloriesBurned
+		b.ActivityTypes = append(b.ActivityTypes, models.ActivityTypeTotals{
+			ActivityType:   t.ActivityType,
+			Count:          t.Count,
+			Minutes:        t.Minutes,
+			CaloriesBurned: t.CaloriesBurned,
+		})
+		summary.Count += t.Count
+		summary.Minutes += t.Minutes
+		summary.CaloriesBurned += t.CaloriesBurned
+	}
+	return summary, nil
+}
+
+// startOfDay returns midnight at the start of t's day, in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfPeriod returns the start of the day, week (from Monday) or month
+// holding t, in t's location
+func startOfPeriod(t time.Time, period string) time.Time {
+	switch period {
+	case models.PeriodWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case models.PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return startOfDay(t)
+	}
+}
+
+// addPeriods moves the start of a period n periods on. Calendar arithmetic
+// keeps periods aligned to midnight across daylight saving changes
+func addPeriods(start time.Time, period string, n int) time.Time {
+	switch period {
+	case models.PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case models.PeriodMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
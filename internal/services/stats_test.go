@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+func TestSummaryBucketsAcrossOffsets(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "Asia/Tokyo")
+	activities := repository.NewActivityRepository(db)
+
+	// Each activity is logged with an offset other than the user's, close to
+	// a midnight in Tokyo
+	doneAt := []string{
+		"2024-06-30T14:30:00Z",      // June 30, 23:30
+		"2024-06-30T10:01:00-05:00", // July 1, 00:01
+		"2024-07-01T07:59:00-07:00", // July 1, 23:59
+		"2024-07-01T08:01:00-07:00", // July 2, 00:01
+		"2024-07-02T17:00:00+02:00", // July 3, 00:00
+	}
+	for _, s := range doneAt {
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		activity := &models.Activity{UserID: user.ID, ActivityType: models.ActivityRunning, DoneAt: at, DurationInMinutes: 10}
+		if err := activities.Create(context.Background(), activity); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query models.StatsQuery
+		want  []int64
+	}{
+		{"days", models.StatsQuery{Period: models.PeriodDay, From: "2024-06-30", To: "2024-07-03"}, []int64{1, 2, 1, 1}},
+		{"weeks", models.StatsQuery{Period: models.PeriodWeek, From: "2024-06-30", To: "2024-07-03"}, []int64{1, 4}},
+		{"months", models.StatsQuery{Period: models.PeriodMonth, From: "2024-06-30", To: "2024-07-03"}, []int64{1, 4}},
+		{"other time zone", models.StatsQuery{Period: models.PeriodDay, From: "2024-06-30", To: "2024-07-02", TimeZone: "UTC"}, []int64{2, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := NewStatsService(activities).Summary(context.Background(), user, tt.query, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(summary.Buckets) != len(tt.want) {
+				t.Fatalf("got %d buckets, want %d", len(summary.Buckets), len(tt.want))
+			}
+			for i, b := range summary.Buckets {
+				if b.Count != tt.want[i] {
+					t.Errorf("bucket %s has %d activities, want %d", b.Start, b.Count, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestListFiltersAcrossOffsets(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "")
+	activities := repository.NewActivityRepository(db)
+
+	at := time.Date(2024, time.July, 1, 23, 0, 0, 0, time.FixedZone("", -5*3600))
+	activity := &models.Activity{UserID: user.ID, ActivityType: models.ActivityWalking, DoneAt: at, DurationInMinutes: 30}
+	if err := activities.Create(context.Background(), activity); err != nil {
+		t.Fatal(err)
+	}
+
+	// July 2, 04:00 UTC, so outside a filter ending on July 1 in UTC but
+	// inside one starting on July 2 in Berlin
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	julyFirst := time.Date(2024, time.July, 1, 23, 59, 59, 0, time.UTC)
+	julySecond := time.Date(2024, time.July, 2, 0, 0, 0, 0, berlin)
+	tests := []struct {
+		name   string
+		filter repository.ActivityFilter
+		want   int
+	}{
+		{"to", repository.ActivityFilter{UserID: user.ID, DoneAtTo: &julyFirst}, 0},
+		{"from", repository.ActivityFilter{UserID: user.ID, DoneAtFrom: &julySecond}, 1},
+		{"before", repository.ActivityFilter{UserID: user.ID, DoneAtBefore: &julySecond}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := activities.List(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tt.want {
+				t.Errorf("got %d activities, want %d", len(list), tt.want)
+			}
+		})
+	}
+}
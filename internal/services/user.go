@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+	"fitbyte/internal/storage"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	// ErrRestoreExpired is returned when restoring a user deleted longer ago
+	// than the restore period
+	ErrRestoreExpired = errors.New("restore period has expired")
+	// ErrRestoreEmailTaken is returned when restoring a user whose email
+	// has been registered again since it was deleted
+	ErrRestoreEmailTaken = fmt.Errorf("%w by another user since this one was deleted", repository.ErrDuplicateEmail)
+	// ErrOwnRole is returned when an admin tries to change their own role,
+	// which could leave no admin behind
+	ErrOwnRole = errors.New("you cannot change your own role")
+	// ErrInvalidBirthDate is returned for a birth date that is not a date
+	// in the past
+	ErrInvalidBirthDate = errors.New("birthDate must be a past date (YYYY-MM-DD)")
+	// ErrInvalidLocale is returned for a locale that is not a BCP 47
+	// language tag
+	ErrInvalidLocale = errors.New("locale must be a BCP 47 language tag, such as en-US")
+)
+
+// purgeBatchSize is how many deleted users PurgeDeleted loads at a time
+const purgeBatchSize = 100
+
+// UserService handles user management
+type UserService struct {
+	users         repository.UserRepository
+	files         storage.Storage
+	exports       *ExportService
+	audit         audit.Recorder
+	restorePeriod time.Duration
+}
+
+// NewUserService creates a new user service. Deleted users can be restored
+// for restorePeriod
+func NewUserService(users repository.UserRepository, files storage.Storage, exports *ExportService,
+	recorder audit.Recorder, restorePeriod time.Duration) *UserService {
+	return &UserService{users: users, files: files, exports: exports, audit: recorder, restorePeriod: restorePeriod}
+}
+
+// List returns a page of users and the total number of users
+func (s *UserService) List(ctx context.Context, page, limit int) ([]models.User, int64, error) {
+	return s.users.List(ctx, (page-1)*limit, limit)
+}
+
+// Get returns a user by ID
+func (s *UserService) Get(ctx context.Context, id uint) (*models.User, error) {
+	return s.users.GetByID(ctx, id)
+}
+
+// Create creates a user from an admin request
+func (s *UserService) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	locale, err := checkSettings(req.BirthDate, req.TimeZone, req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		Email:      normalizeEmail(req.Email),
+		Name:       req.Name,
+		Preference: req.Preference,
+		WeightUnit: req.WeightUnit,
+		HeightUnit: req.HeightUnit,
+		Weight:     req.Weight,
+		Height:     req.Height,
+		BirthDate:  req.BirthDate,
+		Sex:        req.Sex,
+		TimeZone:   req.TimeZone,
+		Locale:     locale,
+		ImageURI:   req.ImageURI,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Replace overwrites every editable field of user with req. It fails with
+// repository.ErrVersionConflict if the user changed since it was read
+func (s *UserService) Replace(ctx context.Context, user *models.User, req models.ReplaceUserRequest) (*models.User, error) {
+	locale, err := checkSettings(req.BirthDate, req.TimeZone, req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	before := user.ToResponse()
+	user.Email = normalizeEmail(req.Email)
+	user.Name = req.Name
+	user.Preference = req.Preference
+	user.WeightUnit = req.WeightUnit
+	user.HeightUnit = req.HeightUnit
+	user.Weight = req.Weight
+	user.Height = req.Height
+	user.BirthDate = req.BirthDate
+	user.Sex = req.Sex
+	user.TimeZone = req.TimeZone
+	user.Locale = locale
+	user.ImageURI = req.ImageURI
+
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if changes := audit.Diff(before, user.ToResponse()); len(changes) > 0 {
+		err := s.audit.Record(ctx, audit.Event{Action: audit.ActionUserUpdated, UserID: user.ID, Changes: changes})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// SetRole changes the role of user on behalf of the admin actorID. It fails
+// with repository.ErrVersionConflict if the user changed since it was read
+func (s *UserService) SetRole(ctx context.Context, actorID uint, user *models.User, role string) (*models.User, error) {
+	if user.ID == actorID {
+		return nil, ErrOwnRole
+	}
+	before := user.ToResponse()
+	user.Role = role
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	err := s.audit.Record(ctx, audit.Event{
+		Action:  audit.ActionRoleChanged,
+		UserID:  user.ID,
+		Changes: audit.Diff(before, user.ToResponse()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Delete soft deletes a user if it is still at version. The user can be
+// restored until the restore period ends and is purged later
+func (s *UserService) Delete(ctx context.Context, id, version uint) error {
+	if err := s.users.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, audit.Event{Action: audit.ActionUserDeleted, UserID: id})
+}
+
+// DeleteAccount permanently deletes a user's own account with their
+// activities, uploaded files and exports. Unlike Delete it cannot be undone,
+// and the user's tokens stop working immediately. The deletion is audited
+// first, so an account is never erased without a record of it
+func (s *UserService) DeleteAccount(ctx context.Context, user *models.User) error {
+	if err := s.audit.Record(ctx, audit.Event{Action: audit.ActionAccountDeleted, UserID: user.ID, ActorID: user.ID}); err != nil {
+		return err
+	}
+	return s.erase(ctx, user.ID)
+}
+
+// Restore undoes the deletion of a user within the restore period
+func (s *UserService) Restore(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.users.GetDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(user.DeletedAt.Time) > s.restorePeriod {
+		return nil, ErrRestoreExpired
+	}
+	if err := s.users.Restore(ctx, id); errors.Is(err, repository.ErrDuplicateEmail) {
+		return nil, ErrRestoreEmailTaken
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(ctx, audit.Event{Action: audit.ActionUserRestored, UserID: id}); err != nil {
+		return nil, err
+	}
+	return s.users.GetByID(ctx, id)
+}
+
+// PurgeDeleted permanently deletes users deleted before the given time,
+// with their activities and uploaded files, and returns how many it purged
+func (s *UserService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for {
+		users, err := s.users.ListDeleted(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, user := range users {
+			if err := s.erase(ctx, user.ID); err != nil {
+				return purged, err
+			}
+			if err := s.audit.Record(ctx, audit.Event{Action: audit.ActionUserPurged, UserID: user.ID}); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(users) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// erase permanently deletes a user and everything stored about them. Files
+// and exports go first: if that fails the user still exists and deleting
+// them again retries
+func (s *UserService) erase(ctx context.Context, id uint) error {
+	if err := s.files.DeletePrefix(ctx, storage.UserPrefix(id)); err != nil {
+		return fmt.Errorf("delete files of user %d: %w", id, err)
+	}
+	if err := s.exports.DeleteForUser(ctx, id); err != nil {
+		return fmt.Errorf("delete exports of user %d: %w", id, err)
+	}
+	if err := s.users.Purge(ctx, id); err != nil {
+		return fmt.Errorf("purge user %d: %w", id, err)
+	}
+	return nil
+}
+
+// GetByEmail returns a user by email
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.users.GetByEmail(ctx, normalizeEmail(email))
+}
+
+// SetPassword replaces the user's password
+func (s *UserService) SetPassword(ctx context.Context, id uint, password string) error {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash, err = auth.HashPassword(password); err != nil {
+		return err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, audit.Event{Action: audit.ActionPasswordChanged, UserID: id})
+}
+
+// Disable prevents the user from logging in
+func (s *UserService) Disable(ctx context.Context, id uint) error {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+	now := time.Now()
+	user.DisabledAt = &now
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, audit.Event{Action: audit.ActionUserDisabled, UserID: id})
+}
+
+// checkSettings rejects birth dates that are malformed or after today, time
+// zones missing from the IANA database and locales that are not language
+// tags. It returns the locale in canonical form, e.g. en-US for en_us
+func checkSettings(birthDate, timeZone, locale *string) (*string, error) {
+	if birthDate != nil {
+		date, err := time.Parse(time.DateOnly, *birthDate)
+		if err != nil || date.After(time.Now()) {
+			return nil, ErrInvalidBirthDate
+		}
+	}
+	// LoadLocation takes "" and "Local" to mean UTC and the server's zone
+	if timeZone != nil {
+		if _, err := time.LoadLocation(*timeZone); err != nil || *timeZone == "" || *timeZone == "Local" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTimeZone, *timeZone)
+		}
+	}
+	if locale == nil {
+		return nil, nil
+	}
+	tag, err := language.Parse(strings.ReplaceAll(*locale, "_", "-"))
+	if err != nil {
+		return nil, ErrInvalidLocale
+	}
+	canonical := tag.String()
+	return &canonical, nil
+}
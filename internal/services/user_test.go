@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"fitbyte/internal/audit"
+	"fitbyte/internal/auth"
+	"fitbyte/internal/lockout"
+	"fitbyte/internal/models"
+	"fitbyte/internal/repository"
+)
+
+func TestUserRestore(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestUserService(t, db, time.Hour)
+	users := repository.NewUserRepository(db)
+
+	user := newTestUser(t, db, "")
+	if err := s.Delete(ctx, user.ID, user.Version); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := s.Restore(ctx, user.ID)
+	if err != nil || restored.Email != user.Email {
+		t.Fatalf("Restore() = %+v, %v", restored, err)
+	}
+
+	// Once the email is registered again the old user cannot be restored
+	if err := s.Delete(ctx, restored.ID, restored.Version); err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.Create(ctx, models.CreateUserRequest{Email: user.Email})
+	if err != nil {
+		t.Fatalf("Create() with the email of a deleted user = %v", err)
+	}
+	_, err = s.Restore(ctx, user.ID)
+	if !errors.Is(err, ErrRestoreEmailTaken) || !errors.Is(err, repository.ErrDuplicateEmail) {
+		t.Errorf("Restore() = %v, want ErrRestoreEmailTaken", err)
+	}
+	if _, err := users.GetByID(ctx, again.ID); err != nil {
+		t.Errorf("the new user is gone after a failed restore: %v", err)
+	}
+
+	// Past the restore period, purging still removes only the old user
+	if err := db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).
+		Update("deleted_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restore(ctx, user.ID); !errors.Is(err, ErrRestoreExpired) {
+		t.Errorf("Restore() after the restore period = %v, want ErrRestoreExpired", err)
+	}
+	purged, err := s.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	if err != nil || purged != 1 {
+		t.Errorf("PurgeDeleted() = %d, %v, want 1", purged, err)
+	}
+	if _, err := users.GetDeleted(ctx, user.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetDeleted() of a purged user = %v, want ErrNotFound", err)
+	}
+	if _, err := users.GetByID(ctx, again.ID); err != nil {
+		t.Errorf("purge removed the new user: %v", err)
+	}
+}
+
+// failingRecorder is an audit.Recorder that cannot write
+type failingRecorder struct{}
+
+func (failingRecorder) Record(context.Context, audit.Event) error {
+	return errors.New("audit log unavailable")
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestUserService(t, db, time.Hour)
+	users, entries := repository.NewUserRepository(db), repository.NewAuditRepository(db)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(), lockout.Policy{
+		MaxEmailFailures: 2, MaxIPFailures: 10, Window: time.Hour, LockoutDuration: time.Hour,
+	})
+	authService := NewAuthService(users, auth.NewTokenManager("secret", time.Hour), guard, audit.NewLog(entries))
+
+	hash, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "runner@example.com", PasswordHash: hash}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	login, err := authService.Login(ctx, models.LoginRequest{Email: user.Email, Password: "correct horse"}, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A built export with a signed download link
+	e, err := s.exports.Request(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.exports.ProcessPending(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e, err = s.exports.Get(ctx, user.ID, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	res := s.exports.Response(e)
+	if res.DownloadURL == nil {
+		t.Fatalf("export %+v has no download link", res)
+	}
+	link, err := url.Parse(*res.DownloadURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.exports.Open(ctx, e.ID, link.Query()); err != nil {
+		t.Fatalf("Open() before the deletion = %v", err)
+	}
+
+	// The password is confirmed first, and failures count towards a lockout
+	if err := authService.ConfirmPassword(ctx, user, "wrong", "10.0.0.1"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("ConfirmPassword() = %v, want ErrWrongPassword", err)
+	}
+	var locked *lockout.LockedError
+	if err := authService.ConfirmPassword(ctx, user, "wrong", "10.0.0.1"); !errors.As(err, &locked) {
+		t.Fatalf("ConfirmPassword() = %v, want *lockout.LockedError", err)
+	}
+	if err := authService.ConfirmPassword(ctx, user, "correct horse", "10.0.0.1"); !errors.As(err, &locked) {
+		t.Fatalf("ConfirmPassword() while locked = %v, want *lockout.LockedError", err)
+	}
+	if err := guard.Clear(ctx, lockout.EmailKey(user.Email)); err != nil {
+		t.Fatal(err)
+	}
+	if err := authService.ConfirmPassword(ctx, user, "correct horse", "10.0.0.1"); err != nil {
+		t.Fatalf("ConfirmPassword() = %v", err)
+	}
+
+	// Nothing is erased when the deletion cannot be audited
+	unaudited := *s
+	unaudited.audit = failingRecorder{}
+	if err := unaudited.DeleteAccount(ctx, user); err == nil {
+		t.Fatal("DeleteAccount() without an audit log succeeded")
+	}
+	if _, err := authService.Authenticate(ctx, login.Token); err != nil {
+		t.Fatalf("Authenticate() after a failed deletion = %v", err)
+	}
+
+	if err := s.DeleteAccount(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authService.Authenticate(ctx, login.Token); !errors.Is(err, auth.ErrRevokedToken) {
+		t.Errorf("Authenticate() after the deletion = %v, want auth.ErrRevokedToken", err)
+	}
+	if _, err := s.exports.Open(ctx, e.ID, link.Query()); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Open() after the deletion = %v, want ErrNotFound", err)
+	}
+	if _, err := users.GetDeleted(ctx, user.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetDeleted() = %v, want ErrNotFound since the account is purged", err)
+	}
+	if _, err := s.Restore(ctx, user.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Restore() = %v, want ErrNotFound", err)
+	}
+
+	logged, total, err := entries.List(ctx, repository.AuditFilter{Action: audit.ActionAccountDeleted, Limit: 10})
+	if err != nil || total != 1 {
+		t.Fatalf("logged %d account deletions, %v, want 1", total, err)
+	}
+	if logged[0].UserID == nil || *logged[0].UserID != user.ID || logged[0].ActorID == nil || *logged[0].ActorID != user.ID {
+		t.Errorf("entry %+v, want the user acting on their own account", logged[0])
+	}
+}
+
+// text returns a pointer to s
+func text(s string) *string {
+	return &s
+}
+
+func TestUserSettings(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	s := newTestUserService(t, db, time.Hour)
+	today := time.Now().Format(time.DateOnly)
+	future := time.Now().AddDate(0, 0, 2).Format(time.DateOnly)
+
+	tests := []struct {
+		name       string
+		birthDate  *string
+		timeZone   *string
+		locale     *string
+		wantErr    error
+		wantLocale *string
+	}{
+		{"nothing set", nil, nil, nil, nil, nil},
+		{"time zone", nil, text("Europe/Berlin"), nil, nil, nil},
+		{"empty time zone", nil, text(""), nil, ErrInvalidTimeZone, nil},
+		{"server time zone", nil, text("Local"), nil, ErrInvalidTimeZone, nil},
+		{"unknown time zone", nil, text("Mars/Olympus_Mons"), nil, ErrInvalidTimeZone, nil},
+		{"canonical locale", nil, nil, text("en-US"), nil, text("en-US")},
+		{"locale with underscore", nil, nil, text("en_us"), nil, text("en-US")},
+		{"locale with script", nil, nil, text("zh_hant_tw"), nil, text("zh-Hant-TW")},
+		{"invalid locale", nil, nil, text("not a locale"), ErrInvalidLocale, nil},
+		{"birth date", text("1990-02-28"), nil, nil, nil, nil},
+		{"born today", &today, nil, nil, nil, nil},
+		{"born in the future", &future, nil, nil, ErrInvalidBirthDate, nil},
+		{"birth date not a day", text("1990-02-30"), nil, nil, ErrInvalidBirthDate, nil},
+		{"birth date in another format", text("28/02/1990"), nil, nil, ErrInvalidBirthDate, nil},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := "settings" + strconv.Itoa(i) + "@example.com"
+			check := func(op string, user *models.User, err error) {
+				t.Helper()
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("%s error = %v, want %v", op, err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if (user.Locale == nil) != (tt.wantLocale == nil) || user.Locale != nil && *user.Locale != *tt.wantLocale {
+					t.Errorf("%s stored locale %v, want %v", op, user.Locale, tt.wantLocale)
+				}
+			}
+
+			created, err := s.Create(ctx, models.CreateUserRequest{Email: email, BirthDate: tt.birthDate, TimeZone: tt.timeZone, Locale: tt.locale})
+			check("Create", created, err)
+
+			user, err := s.Create(ctx, models.CreateUserRequest{Email: "replaced" + email})
+			if err != nil {
+				t.Fatal(err)
+			}
+			replaced, err := s.Replace(ctx, user, models.ReplaceUserRequest{Email: user.Email, BirthDate: tt.birthDate, TimeZone: tt.timeZone, Locale: tt.locale})
+			check("Replace", replaced, err)
+		})
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		timeZone *string
+		want     *time.Location
+	}{
+		{"not set", nil, time.UTC},
+		{"set", text("Europe/Berlin"), berlin},
+		// Stored before time zones were checked, or removed from the database
+		{"unknown", text("Mars/Olympus_Mons"), time.UTC},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{TimeZone: tt.timeZone}
+			if got := user.Location(); got.String() != tt.want.String() {
+				t.Errorf("Location() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
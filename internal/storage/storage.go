@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores uploaded files and returns public URIs for them
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader) (string, error)
+	Delete(ctx context.Context, uri string) error
+	// DeletePrefix removes every file whose key starts with prefix + "/"
+	DeletePrefix(ctx context.Context, prefix string) error
+	// List returns the keys of the files whose key starts with prefix + "/"
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Open returns the contents of the file stored under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// UserPrefix is the key prefix of the files uploaded by a user
+func UserPrefix(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// LocalStorage keeps files in a directory served by the API under URLPath
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// URLPath is where the router serves files saved by LocalStorage
+const URLPath = "/uploads"
+
+// NewLocalStorage creates a storage rooted at dir. Returned URIs are
+// prefixed with publicURL, e.g. http://localhost:8080/uploads/<key>
+func NewLocalStorage(dir, publicURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create upload directory: %w", err)
+	}
+	return &LocalStorage{dir: dir, baseURL: strings.TrimSuffix(publicURL, "/") + URLPath + "/"}, nil
+}
+
+// Dir returns the directory files are stored in
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// Save writes r to key and returns its URI
+func (s *LocalStorage) Save(_ context.Context, key string, r io.Reader) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return s.baseURL + key, nil
+}
+
+// Delete removes the file behind a URI returned by Save
+func (s *LocalStorage) Delete(_ context.Context, uri string) error {
+	key, ok := strings.CutPrefix(uri, s.baseURL)
+	if !ok {
+		return ErrNotFound
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix removes the directory holding the keys under prefix
+func (s *LocalStorage) DeletePrefix(_ context.Context, prefix string) error {
+	path, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// List returns the keys stored under prefix, in lexical order
+func (s *LocalStorage) List(_ context.Context, prefix string) ([]string, error) {
+	root, err := s.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		// Skip directories and partial uploads
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	return keys, err
+}
+
+// Open returns the file stored under key
+func (s *LocalStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// path maps a key to a file inside dir, rejecting keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
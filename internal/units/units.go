@@ -0,0 +1,110 @@
+// Package units converts body measurements and distances between metric and
+// imperial units
+package units
+
+import "math"
+
+// Unit systems a user can prefer
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+// Weight, height and distance units
+const (
+	Kilograms   = "kg"
+	Pounds      = "lbs"
+	Centimeters = "cm"
+	Inches      = "in"
+	Kilometers  = "km"
+	Miles       = "mi"
+)
+
+const (
+	poundsPerKilogram  = 2.20462262185
+	centimetersPerInch = 2.54
+	metersPerMile      = 1609.344
+)
+
+// WeightUnit returns the weight unit of a unit system preference, kilograms
+// unless it is imperial
+func WeightUnit(preference *string) string {
+	if preference != nil && *preference == Imperial {
+		return Pounds
+	}
+	return Kilograms
+}
+
+// LengthUnit returns the length unit of a unit system preference,
+// centimeters unless it is imperial
+func LengthUnit(preference *string) string {
+	if preference != nil && *preference == Imperial {
+		return Inches
+	}
+	return Centimeters
+}
+
+// DistanceUnit returns the distance unit of a unit system preference,
+// kilometers unless it is imperial
+func DistanceUnit(preference *string) string {
+	if preference != nil && *preference == Imperial {
+		return Miles
+	}
+	return Kilometers
+}
+
+// ToKilograms converts a weight in unit to kilograms. Unknown units are
+// taken as kilograms
+func ToKilograms(weight float64, unit string) float64 {
+	if unit == Pounds {
+		return weight / poundsPerKilogram
+	}
+	return weight
+}
+
+// FromKilograms converts a weight in kilograms to unit
+func FromKilograms(kg float64, unit string) float64 {
+	if unit == Pounds {
+		return kg * poundsPerKilogram
+	}
+	return kg
+}
+
+// ToCentimeters converts a height in unit to centimeters. Unknown units are
+// taken as centimeters
+func ToCentimeters(height float64, unit string) float64 {
+	if unit == Inches {
+		return height * centimetersPerInch
+	}
+	return height
+}
+
+// FromCentimeters converts a length in centimeters to unit
+func FromCentimeters(cm float64, unit string) float64 {
+	if unit == Inches {
+		return cm / centimetersPerInch
+	}
+	return cm
+}
+
+// ToMeters converts a distance in unit to meters. Unknown units are taken
+// as kilometers
+func ToMeters(distance float64, unit string) float64 {
+	if unit == Miles {
+		return distance * metersPerMile
+	}
+	return distance * 1000
+}
+
+// FromMeters converts a distance in meters to unit
+func FromMeters(m float64, unit string) float64 {
+	if unit == Miles {
+		return m / metersPerMile
+	}
+	return m / 1000
+}
+
+// Round rounds v to two decimal places for display
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
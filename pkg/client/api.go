@@ -0,0 +1,236 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Register creates an account and starts using its token
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/v1/register", req)
+}
+
+// Login authenticates and starts using the returned token
+func (c *Client) Login(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/v1/login", req)
+}
+
+func (c *Client) authenticate(ctx context.Context, path string, body interface{}) (*AuthResponse, error) {
+	req, err := jsonRequest(http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	var res AuthResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	c.SetToken(res.Token)
+	return &res, nil
+}
+
+// GetProfile returns the authenticated user's profile
+func (c *Client) GetProfile(ctx context.Context, opts ...RequestOption) (*User, error) {
+	req := request{method: http.MethodGet, path: "/api/v1/user"}
+	req.apply(opts)
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateProfile updates the non-nil fields of the authenticated user's
+// profile. Use ReplaceProfile to clear fields
+func (c *Client) UpdateProfile(ctx context.Context, update UpdateUserRequest, opts ...RequestOption) (*User, error) {
+	req, err := mergePatchRequest("/api/v1/user", update)
+	if err != nil {
+		return nil, err
+	}
+	req.apply(opts)
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ReplaceProfile replaces the authenticated user's profile; nil fields are cleared
+func (c *Client) ReplaceProfile(ctx context.Context, profile ReplaceUserRequest, opts ...RequestOption) (*User, error) {
+	req, err := jsonRequest(http.MethodPut, "/api/v1/user", profile)
+	if err != nil {
+		return nil, err
+	}
+	req.apply(opts)
+	var user User
+	if err := c.do(ctx, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteAccount permanently deletes the authenticated user's account,
+// confirmed with their password, and forgets the token
+func (c *Client) DeleteAccount(ctx context.Context, password string, opts ...RequestOption) error {
+	req, err := jsonRequest(http.MethodDelete, "/api/v1/user", DeleteAccountRequest{Password: password})
+	if err != nil {
+		return err
+	}
+	req.apply(opts)
+	if err := c.do(ctx, req, nil); err != nil {
+		return err
+	}
+	c.SetToken("")
+	return nil
+}
+
+// ListActivities returns the authenticated user's activities matching query
+func (c *Client) ListActivities(ctx context.Context, query ActivityQuery) ([]Activity, error) {
+	req := request{method: http.MethodGet, path: "/api/v1/activity/", query: activityQuery(query).Encode()}
+	var activities []Activity
+	if err := c.do(ctx, req, &activities); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// GetActivity returns one of the authenticated user's activities
+func (c *Client) GetActivity(ctx context.Context, id uint, opts ...RequestOption) (*Activity, error) {
+	req := request{method: http.MethodGet, path: activityPath(id)}
+	req.apply(opts)
+	var res Activity
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CreateActivity logs an activity. The request carries a random
+// Idempotency-Key, so retries cannot log it twice
+func (c *Client) CreateActivity(ctx context.Context, activity CreateActivityRequest, opts ...RequestOption) (*Activity, error) {
+	req, err := jsonRequest(http.MethodPost, "/api/v1/activity/", activity)
+	if err != nil {
+		return nil, err
+	}
+	if req.headers, err = idempotencyKey(); err != nil {
+		return nil, err
+	}
+	req.apply(opts)
+	var res Activity
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UpdateActivity updates the non-nil fields of an activity
+func (c *Client) UpdateActivity(ctx context.Context, id uint, update UpdateActivityRequest, opts ...RequestOption) (*Activity, error) {
+	req, err := mergePatchRequest(activityPath(id), update)
+	if err != nil {
+		return nil, err
+	}
+	req.apply(opts)
+	var res Activity
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ReplaceActivity replaces an activity
+func (c *Client) ReplaceActivity(ctx context.Context, id uint, activity ReplaceActivityRequest, opts ...RequestOption) (*Activity, error) {
+	req, err := jsonRequest(http.MethodPut, activityPath(id), activity)
+	if err != nil {
+		return nil, err
+	}
+	req.apply(opts)
+	var res Activity
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteActivity deletes an activity
+func (c *Client) DeleteActivity(ctx context.Context, id uint, opts ...RequestOption) error {
+	req := request{method: http.MethodDelete, path: activityPath(id)}
+	req.apply(opts)
+	return c.do(ctx, req, nil)
+}
+
+// activityPath returns the path of an activity
+func activityPath(id uint) string {
+	return "/api/v1/activity/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// UploadFile uploads a JPEG or PNG image and returns its URI
+func (c *Client) UploadFile(ctx context.Context, filename string, r io.Reader) (*FileResponse, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("fitbyte: read upload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	req := request{
+		method:      http.MethodPost,
+		path:        "/api/v1/file",
+		body:        buf.Bytes(),
+		contentType: w.FormDataContentType(),
+	}
+	var res FileResponse
+	if err := c.do(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// idempotencyKey returns headers carrying a new random Idempotency-Key
+func idempotencyKey() (map[string]string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return map[string]string{"Idempotency-Key": hex.EncodeToString(key)}, nil
+}
+
+// activityQuery encodes an ActivityQuery using its form tags
+func activityQuery(q ActivityQuery) url.Values {
+	v := url.Values{}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.ActivityType != "" {
+		v.Set("activityType", q.ActivityType)
+	}
+	if q.DoneAtFrom != nil {
+		v.Set("doneAtFrom", q.DoneAtFrom.Format(time.RFC3339))
+	}
+	if q.DoneAtTo != nil {
+		v.Set("doneAtTo", q.DoneAtTo.Format(time.RFC3339))
+	}
+	if q.CaloriesBurnedMin != nil {
+		v.Set("caloriesBurnedMin", strconv.Itoa(*q.CaloriesBurnedMin))
+	}
+	if q.CaloriesBurnedMax != nil {
+		v.Set("caloriesBurnedMax", strconv.Itoa(*q.CaloriesBurnedMax))
+	}
+	return v
+}
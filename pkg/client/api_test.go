@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"fitbyte/internal/models"
+)
+
+// versionedServer serves one activity at a version, enforcing If-Match on
+// writes the way the API does with REQUIRE_IF_MATCH
+type versionedServer struct {
+	mu      sync.Mutex
+	version int
+}
+
+func (s *versionedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag := strconv.Quote(strconv.Itoa(s.version))
+	respond := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+
+	if r.Method != http.MethodGet {
+		switch r.Header.Get("If-Match") {
+		case "":
+			respond(http.StatusPreconditionRequired, models.ErrorResponse{Error: "If-Match header required", Code: http.StatusPreconditionRequired})
+			return
+		case tag:
+			s.version++
+			tag = strconv.Quote(strconv.Itoa(s.version))
+		default:
+			respond(http.StatusPreconditionFailed, models.ErrorResponse{Error: "Resource has been modified", Code: http.StatusPreconditionFailed})
+			return
+		}
+	}
+	w.Header().Set("ETag", tag)
+	respond(http.StatusOK, models.APIResponse{Success: true, Data: models.ActivityResponse{ID: 7, DurationInMinutes: 30}})
+}
+
+func TestETags(t *testing.T) {
+	srv := httptest.NewServer(&versionedServer{version: 1})
+	defer srv.Close()
+	c := New(srv.URL, WithRetries(0, 0, 0))
+	ctx := context.Background()
+
+	var etag string
+	if _, err := c.GetActivity(ctx, 7, ETag(&etag)); err != nil {
+		t.Fatal(err)
+	}
+	if etag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", etag)
+	}
+
+	minutes := 40
+	update := UpdateActivityRequest{DurationInMinutes: &minutes}
+	if _, err := c.UpdateActivity(ctx, 7, update); !hasStatus(err, http.StatusPreconditionRequired) {
+		t.Errorf("write without If-Match error = %v, want 428", err)
+	}
+
+	stale := etag
+	if _, err := c.UpdateActivity(ctx, 7, update, IfMatch(etag), ETag(&etag)); err != nil {
+		t.Fatal(err)
+	}
+	if etag != `"2"` {
+		t.Errorf("ETag after update = %s, want \"2\"", etag)
+	}
+
+	err := c.DeleteActivity(ctx, 7, IfMatch(stale))
+	if !IsPreconditionFailed(err) {
+		t.Errorf("write with a stale ETag error = %v, want 412", err)
+	}
+	if err := c.DeleteActivity(ctx, 7, IfMatch(etag)); err != nil {
+		t.Errorf("DeleteActivity error = %v", err)
+	}
+}
+
+func TestETagLeftUnsetOnError(t *testing.T) {
+	srv := httptest.NewServer(&versionedServer{version: 1})
+	defer srv.Close()
+	c := New(srv.URL, WithRetries(0, 0, 0))
+
+	etag := "unchanged"
+	if _, err := c.ReplaceProfile(context.Background(), ReplaceUserRequest{}, ETag(&etag)); err == nil {
+		t.Fatal("ReplaceProfile succeeded without If-Match")
+	}
+	if etag != "unchanged" {
+		t.Errorf("ETag = %s after a failed call, want it left alone", etag)
+	}
+}
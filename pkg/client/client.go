@@ -0,0 +1,307 @@
+// Package client is a typed Go client for the FitByte API.
+//
+//	c := client.New("https://api.fitbyte.example")
+//	if _, err := c.Login(ctx, client.LoginRequest{Email: email, Password: password}); err != nil {
+//		return err
+//	}
+//	activities, err := c.ListActivities(ctx, client.ActivityQuery{Limit: 10})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client calls the FitByte API. It is safe for concurrent use
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithToken sets the bearer token sent with authenticated requests
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithRetries sets how many times a failed request is retried and the
+// bounds of the exponential backoff between attempts
+func WithRetries(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New creates a client for the API at baseURL, e.g. https://api.fitbyte.example
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "fitbyte-go-client/1.0",
+		maxRetries: 3,
+		minBackoff: 200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Token returns the bearer token currently in use
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetToken replaces the bearer token sent with authenticated requests
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// envelope is the success response wrapper used by the API
+type envelope struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// request describes a call to the API
+type request struct {
+	method      string
+	path        string
+	query       string
+	body        []byte
+	contentType string
+	headers     map[string]string
+	// etag receives the ETag header of a successful response
+	etag *string
+}
+
+// RequestOption adjusts a single call
+type RequestOption func(*request)
+
+// IfMatch makes a write conditional on the resource still being at etag, as
+// captured with ETag. The API answers 412 when it has changed since, which
+// IsPreconditionFailed detects. Servers running with REQUIRE_IF_MATCH reject
+// writes without it
+func IfMatch(etag string) RequestOption {
+	return func(r *request) { r.setHeader("If-Match", etag) }
+}
+
+// ETag stores the ETag of the resource returned by a call in etag, to be
+// passed to IfMatch on the next write
+func ETag(etag *string) RequestOption {
+	return func(r *request) { r.etag = etag }
+}
+
+// setHeader sets a header sent with the request
+func (r *request) setHeader(key, value string) {
+	if r.headers == nil {
+		r.headers = map[string]string{}
+	}
+	r.headers[key] = value
+}
+
+// apply applies opts to the request
+func (r *request) apply(opts []RequestOption) {
+	for _, opt := range opts {
+		opt(r)
+	}
+}
+
+// jsonRequest builds a request with a JSON body
+func jsonRequest(method, path string, body interface{}) (request, error) {
+	req := request{method: method, path: path}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return request{}, fmt.Errorf("fitbyte: encode request: %w", err)
+		}
+		req.body = data
+		req.contentType = "application/json"
+	}
+	return req, nil
+}
+
+// mergePatchRequest builds a PATCH request with a JSON merge patch body
+func mergePatchRequest(path string, patch interface{}) (request, error) {
+	req, err := jsonRequest(http.MethodPatch, path, patch)
+	req.contentType = "application/merge-patch+json"
+	return req, err
+}
+
+// do sends req, retrying when allowed, and decodes the envelope's data into out
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		res, body, err := c.send(ctx, req)
+		if err == nil && res.StatusCode < 300 {
+			if req.etag != nil {
+				*req.etag = res.Header.Get("ETag")
+			}
+			return decodeData(body, out)
+		}
+
+		var retryAfter time.Duration
+		if err == nil {
+			apiErr := decodeError(res, body)
+			lastErr = apiErr
+			retryAfter = apiErr.RetryAfter
+			if !c.retryable(req, res.StatusCode) {
+				return apiErr
+			}
+		} else {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			if !idempotent(req) {
+				return err
+			}
+		}
+
+		if attempt >= c.maxRetries {
+			return lastErr
+		}
+		if err := sleep(ctx, c.backoff(attempt, retryAfter)); err != nil {
+			return err
+		}
+	}
+}
+
+// send performs one HTTP round trip and reads the whole response body
+func (c *Client) send(ctx context.Context, req request) (*http.Response, []byte, error) {
+	url := c.baseURL + req.path
+	if req.query != "" {
+		url += "?" + req.query
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, url, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fitbyte: build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if token := c.Token(); token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range req.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	res, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fitbyte: %s %s: %w", req.method, req.path, err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fitbyte: read response: %w", err)
+	}
+	return res, data, nil
+}
+
+// retryable reports whether a response status may be retried. A 429 means
+// the request was not processed, so it is always safe to retry; server errors
+// are only retried for idempotent requests
+func (c *Client) retryable(req request, status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return true
+	case status >= 500:
+		return idempotent(req)
+	default:
+		return false
+	}
+}
+
+func idempotent(req request) bool {
+	switch req.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return req.headers["Idempotency-Key"] != ""
+	}
+}
+
+// backoff returns the delay before the next attempt: the server's
+// Retry-After when given, otherwise exponential backoff with full jitter
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.maxBackoff)
+	}
+	d := c.minBackoff << attempt
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+func decodeData(body []byte, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return fmt.Errorf("fitbyte: decode response: %w", err)
+	}
+	if len(env.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("fitbyte: decode response data: %w", err)
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
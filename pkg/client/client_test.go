@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fitbyte/internal/models"
+)
+
+// scriptedServer answers each request with the next of its responses,
+// repeating the last one, and records the requests it received
+type scriptedServer struct {
+	mu        sync.Mutex
+	responses []scriptedResponse
+	requests  []*http.Request
+}
+
+type scriptedResponse struct {
+	status  int
+	headers map[string]string
+	body    interface{}
+}
+
+func newScriptedServer(t *testing.T, responses ...scriptedResponse) (*scriptedServer, *httptest.Server) {
+	s := &scriptedServer{responses: responses}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	res := s.responses[min(len(s.requests), len(s.responses))-1]
+	for k, v := range res.headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.status)
+	json.NewEncoder(w).Encode(res.body)
+}
+
+func (s *scriptedServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func failure(status int) scriptedResponse {
+	return scriptedResponse{status: status, body: models.ErrorResponse{Error: http.StatusText(status), Code: status}}
+}
+
+var activityOK = scriptedResponse{status: http.StatusOK, body: models.APIResponse{Success: true, Data: models.ActivityResponse{ID: 7}}}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		call      func(c *Client) error
+		responses []scriptedResponse
+		wantCalls int
+		wantErr   int
+	}{
+		{
+			name:      "GET is retried on server errors",
+			call:      func(c *Client) error { _, err := c.GetActivity(ctx, 7); return err },
+			responses: []scriptedResponse{failure(http.StatusBadGateway), failure(http.StatusServiceUnavailable), activityOK},
+			wantCalls: 3,
+		},
+		{
+			name:      "DELETE is retried on server errors",
+			call:      func(c *Client) error { return c.DeleteActivity(ctx, 7) },
+			responses: []scriptedResponse{failure(http.StatusInternalServerError), activityOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "POST with an Idempotency-Key is retried",
+			call:      func(c *Client) error { _, err := c.CreateActivity(ctx, CreateActivityRequest{}); return err },
+			responses: []scriptedResponse{failure(http.StatusInternalServerError), activityOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "PATCH is not retried on server errors",
+			call:      func(c *Client) error { _, err := c.UpdateActivity(ctx, 7, UpdateActivityRequest{}); return err },
+			responses: []scriptedResponse{failure(http.StatusInternalServerError), activityOK},
+			wantCalls: 1,
+			wantErr:   http.StatusInternalServerError,
+		},
+		{
+			name:      "PATCH is retried on 429",
+			call:      func(c *Client) error { _, err := c.UpdateActivity(ctx, 7, UpdateActivityRequest{}); return err },
+			responses: []scriptedResponse{failure(http.StatusTooManyRequests), activityOK},
+			wantCalls: 2,
+		},
+		{
+			name:      "client errors are not retried",
+			call:      func(c *Client) error { _, err := c.GetActivity(ctx, 7); return err },
+			responses: []scriptedResponse{failure(http.StatusNotFound), activityOK},
+			wantCalls: 1,
+			wantErr:   http.StatusNotFound,
+		},
+		{
+			name:      "retries run out",
+			call:      func(c *Client) error { _, err := c.GetActivity(ctx, 7); return err },
+			responses: []scriptedResponse{failure(http.StatusServiceUnavailable)},
+			wantCalls: 3,
+			wantErr:   http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, srv := newScriptedServer(t, tt.responses...)
+			c := New(srv.URL, WithRetries(2, time.Millisecond, time.Millisecond))
+
+			err := tt.call(c)
+			if tt.wantErr == 0 && err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if tt.wantErr != 0 && !hasStatus(err, tt.wantErr) {
+				t.Fatalf("error = %v, want status %d", err, tt.wantErr)
+			}
+			if got := s.calls(); got != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryKeepsIdempotencyKey(t *testing.T) {
+	s, srv := newScriptedServer(t, failure(http.StatusInternalServerError), activityOK)
+	c := New(srv.URL, WithRetries(1, time.Millisecond, time.Millisecond))
+	if _, err := c.CreateActivity(context.Background(), CreateActivityRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := s.requests[0].Header.Get("Idempotency-Key"), s.requests[1].Header.Get("Idempotency-Key")
+	if first == "" || first != second {
+		t.Errorf("Idempotency-Key %q then %q, want the same key", first, second)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	limited := failure(http.StatusTooManyRequests)
+	limited.headers = map[string]string{"Retry-After": "1"}
+	_, srv := newScriptedServer(t, limited, activityOK)
+	c := New(srv.URL, WithRetries(1, time.Millisecond, 5*time.Second))
+
+	start := time.Now()
+	if _, err := c.GetActivity(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %v, want the 1s from Retry-After", waited)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	limited := failure(http.StatusTooManyRequests)
+	limited.headers = map[string]string{"Retry-After": "60"}
+	s, srv := newScriptedServer(t, limited)
+	c := New(srv.URL, WithRetries(3, time.Millisecond, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetActivity(ctx, 7); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := s.calls(); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New("", WithRetries(5, 100*time.Millisecond, time.Second))
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		max        time.Duration
+	}{
+		{0, 0, 100 * time.Millisecond},
+		{2, 0, 400 * time.Millisecond},
+		{5, 0, time.Second},
+		{62, 0, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if d := c.backoff(tt.attempt, tt.retryAfter); d < 0 || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want within [0, %v]", tt.attempt, d, tt.max)
+			}
+		}
+	}
+	if d := c.backoff(0, 300*time.Millisecond); d != 300*time.Millisecond {
+		t.Errorf("backoff with Retry-After = %v, want 300ms", d)
+	}
+	if d := c.backoff(0, time.Hour); d != time.Second {
+		t.Errorf("backoff with a long Retry-After = %v, want the 1s maximum", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"0", 0, 0},
+		{"30", 30 * time.Second, 30 * time.Second},
+		{"-1", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want within [%v, %v]", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	lockedUntil := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		response scriptedResponse
+		want     Error
+		is       func(error) bool
+	}{
+		{
+			name:     "error envelope",
+			response: failure(http.StatusNotFound),
+			want:     Error{StatusCode: http.StatusNotFound, Message: "Not Found"},
+			is:       IsNotFound,
+		},
+		{
+			name: "locked out",
+			response: scriptedResponse{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "3600"}, body: map[string]interface{}{
+				"success": false, "error": "too many failed login attempts", "code": 429, "locked_until": lockedUntil,
+			}},
+			want: Error{StatusCode: http.StatusTooManyRequests, Message: "too many failed login attempts", RetryAfter: time.Hour, LockedUntil: lockedUntil},
+			is:   IsRateLimited,
+		},
+		{
+			name:     "no envelope",
+			response: scriptedResponse{status: http.StatusUnauthorized, body: "nope"},
+			want:     Error{StatusCode: http.StatusUnauthorized, Message: "Unauthorized"},
+			is:       IsUnauthorized,
+		},
+		{
+			name:     "precondition failed",
+			response: scriptedResponse{status: http.StatusPreconditionFailed, body: models.ErrorResponse{Error: "Resource has been modified"}},
+			want:     Error{StatusCode: http.StatusPreconditionFailed, Message: "Resource has been modified"},
+			is:       IsPreconditionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newScriptedServer(t, tt.response)
+			c := New(srv.URL, WithRetries(0, 0, 0))
+
+			_, err := c.Login(context.Background(), LoginRequest{Email: "a@example.com", Password: "x"})
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *Error", err)
+			}
+			if apiErr.StatusCode != tt.want.StatusCode || apiErr.Message != tt.want.Message ||
+				apiErr.RetryAfter != tt.want.RetryAfter || !apiErr.LockedUntil.Equal(tt.want.LockedUntil) {
+				t.Errorf("error = %+v, want %+v", *apiErr, tt.want)
+			}
+			if !tt.is(err) {
+				t.Errorf("%v is not reported as its status", err)
+			}
+			if c.Token() != "" {
+				t.Errorf("failed login set token %q", c.Token())
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	s, srv := newScriptedServer(t,
+		scriptedResponse{status: http.StatusOK, body: models.APIResponse{Success: true, Data: models.AuthResponse{Token: "fresh"}}},
+		activityOK,
+	)
+	c := New(srv.URL, WithToken("stale"))
+	if _, err := c.Login(context.Background(), LoginRequest{Email: "a@example.com", Password: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Token() != "fresh" {
+		t.Errorf("Token() = %q, want fresh", c.Token())
+	}
+	if _, err := c.GetActivity(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	c.SetToken("")
+	if _, err := c.GetActivity(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Bearer stale", "Bearer fresh", ""}
+	for i, r := range s.requests {
+		if got := r.Header.Get("Authorization"); got != want[i] {
+			t.Errorf("request %d Authorization = %q, want %q", i+1, got, want[i])
+		}
+		if !strings.HasPrefix(r.Header.Get("User-Agent"), "fitbyte-go-client/") {
+			t.Errorf("request %d User-Agent = %q", i+1, r.Header.Get("User-Agent"))
+		}
+	}
+}
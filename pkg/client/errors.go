@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error is returned for non-2xx responses. It carries the decoded
+// ErrorResponse when the API sent one
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter is set from the Retry-After header on 429 and 503 responses
+	RetryAfter time.Duration
+	// LockedUntil is set when a login is locked out
+	LockedUntil time.Time
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fitbyte: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is a 401 from the API
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited reports whether err is a 429 from the API
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsPreconditionFailed reports whether err is a 412 from the API: the
+// resource changed since the ETag passed to IfMatch was read
+func IsPreconditionFailed(err error) bool {
+	return hasStatus(err, http.StatusPreconditionFailed)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// decodeError builds an *Error from a failed response
+func decodeError(res *http.Response, body []byte) *Error {
+	apiErr := &Error{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+
+	var payload struct {
+		ErrorResponse
+		LockedUntil time.Time `json:"locked_until"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+		apiErr.LockedUntil = payload.LockedUntil
+	}
+	apiErr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"))
+	return apiErr
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or HTTP date form
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,23 @@
+package client
+
+import "fitbyte/internal/models"
+
+// The API's request and response types, re-exported so that code outside
+// this module can name them
+type (
+	RegisterRequest        = models.RegisterRequest
+	LoginRequest           = models.LoginRequest
+	DeleteAccountRequest   = models.DeleteAccountRequest
+	AuthResponse           = models.AuthResponse
+	User                   = models.UserResponse
+	UpdateUserRequest      = models.UpdateUserRequest
+	ReplaceUserRequest     = models.ReplaceUserRequest
+	Activity               = models.ActivityResponse
+	ActivityQuery          = models.ActivityQuery
+	CreateActivityRequest  = models.CreateActivityRequest
+	UpdateActivityRequest  = models.UpdateActivityRequest
+	ReplaceActivityRequest = models.ReplaceActivityRequest
+	TrackPoint             = models.TrackPointRequest
+	FileResponse           = models.FileResponse
+	ErrorResponse          = models.ErrorResponse
+)